@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/integrations/store"
+)
+
+// Error codes of rejected requests
+const (
+	CodeUnauthorized = "unauthorized"
+	CodeForbidden    = "forbidden"
+	CodeRateLimited  = "rate_limited"
+)
+
+// keyContextKey holds the authenticated *store.APIKey in a request context
+type keyContextKey struct{}
+
+// requestKey returns the API key that authenticated the request, or nil when auth is off
+func requestKey(r *http.Request) *store.APIKey {
+	key, _ := r.Context().Value(keyContextKey{}).(*store.APIKey)
+	return key
+}
+
+// lookupKey finds an active API key by its token, first among the configured keys,
+// then in the database
+func (s *Server) lookupKey(ctx context.Context, token string) (*store.APIKey, error) {
+	hash := store.HashAPIKey(token)
+	for i := range s.config.Keys {
+		if s.config.Keys[i].Hash == hash {
+			return &s.config.Keys[i], nil
+		}
+	}
+	if s.config.Store == nil {
+		return nil, nil
+	}
+	key, err := s.config.Store.FindAPIKey(ctx, hash)
+	if err != nil || key == nil || key.Revoked() {
+		return nil, err
+	}
+	return key, nil
+}
+
+// authorize requires a bearer API key with the given scope when auth is enabled.
+// An empty scope leaves the endpoint public. Rejections are logged with the key's
+// prefix only; the request body is never read.
+func (s *Server) authorize(scope string, next http.HandlerFunc) http.HandlerFunc {
+	if scope == "" {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.config.RequireAuth {
+			next(w, r)
+			return
+		}
+
+		reject := func(status int, code, message, key string) {
+			log.Printf("%sRejected %s %s from %s: %s (key %s)", s.config.LogPrefix, r.Method, r.URL.Path, r.RemoteAddr, code, coalesce(key, "none"))
+			writeError(w, status, code, message)
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="kwgn"`)
+			reject(http.StatusUnauthorized, CodeUnauthorized, "An API key is required (Authorization: Bearer <key>)", "")
+			return
+		}
+		key, err := s.lookupKey(r.Context(), token)
+		if err != nil {
+			log.Printf("%sError looking up API key: %v", s.config.LogPrefix, err)
+			writeError(w, http.StatusInternalServerError, CodeInternal, "Could not check the API key")
+			return
+		}
+		if key == nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="kwgn", error="invalid_token"`)
+			reject(http.StatusUnauthorized, CodeUnauthorized, "Invalid or revoked API key", "")
+			return
+		}
+		if !key.HasScope(scope) {
+			reject(http.StatusForbidden, CodeForbidden, "The API key lacks the '"+scope+"' scope", key.Prefix)
+			return
+		}
+		if wait := s.limiter.take(key.Hash, key.RateLimit); wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			reject(http.StatusTooManyRequests, CodeRateLimited, "Rate limit of "+strconv.Itoa(key.RateLimit)+" requests per minute exceeded", key.Prefix)
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), keyContextKey{}, key)))
+	}
+}
+
+// rateLimiter keeps a token bucket per API key, refilled continuously at the key's
+// rate per minute and holding at most a minute's worth of requests
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+// take spends a request of key's budget. It returns 0 when the request is allowed,
+// otherwise how long until it would be. perMinute 0 means unlimited.
+func (l *rateLimiter) take(key string, perMinute int) time.Duration {
+	if perMinute <= 0 {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	capacity := float64(perMinute)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Minutes()*capacity)
+	b.last = now
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / capacity * float64(time.Minute))
+	}
+	b.tokens--
+	return 0
+}
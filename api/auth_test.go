@@ -0,0 +1,188 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/integrations/store"
+)
+
+// authServer returns a server requiring API keys: one configured key with every
+// scope, and keys stored in the database named after their scopes
+func authServer(t *testing.T) (*Server, map[string]string) {
+	t.Helper()
+	ctx := context.Background()
+	db := newTestStore(t)
+	tokens := make(map[string]string)
+
+	configToken, configKey, err := store.NewAPIKey("config", store.Scopes, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens["config"] = configToken
+
+	for name, scopes := range map[string][]string{
+		"extract": {store.ScopeExtract},
+		"read":    {store.ScopeRead},
+		"other":   {store.ScopeExtract},
+		"revoked": {store.ScopeExtract},
+	} {
+		rateLimit := 0
+		if name == "read" {
+			rateLimit = 2
+		}
+		token, key, err := store.NewAPIKey(name, scopes, rateLimit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := db.CreateAPIKey(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name == "revoked" {
+			if ok, err := db.RevokeAPIKey(ctx, id); !ok || err != nil {
+				t.Fatalf("RevokeAPIKey = %v, %v", ok, err)
+			}
+		}
+		tokens[name] = token
+	}
+
+	cfg := DefaultConfig()
+	cfg.Store = db
+	cfg.RequireAuth = true
+	cfg.Keys = []store.APIKey{configKey}
+	server := New(cfg)
+	t.Cleanup(server.Close)
+	return server, tokens
+}
+
+// authRequest sends a request with an optional bearer token
+func authRequest(server *Server, method, url, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	return w
+}
+
+func TestAuth(t *testing.T) {
+	server, tokens := authServer(t)
+
+	tests := []struct {
+		name   string
+		url    string
+		token  string
+		status int
+		code   string
+	}{
+		{"no key", "/v1/networth", "", http.StatusUnauthorized, CodeUnauthorized},
+		{"unknown key", "/v1/networth", "kwgn_unknown", http.StatusUnauthorized, CodeUnauthorized},
+		{"revoked key", "/v1/jobs/abc", tokens["revoked"], http.StatusUnauthorized, CodeUnauthorized},
+		{"missing scope", "/v1/networth", tokens["extract"], http.StatusForbidden, CodeForbidden},
+		{"import scope", "/v1/import", tokens["extract"], http.StatusForbidden, CodeForbidden},
+		{"database key", "/v1/networth", tokens["read"], http.StatusOK, ""},
+		{"config key", "/v1/networth", tokens["config"], http.StatusOK, ""},
+		{"legacy endpoint", "/extract", "", http.StatusUnauthorized, CodeUnauthorized},
+		{"public health", "/v1/health", "", http.StatusOK, ""},
+		{"public legacy health", "/health", "", http.StatusOK, ""},
+		{"public spec", "/openapi.json", "", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := authRequest(server, http.MethodGet, tt.url, tt.token)
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body)
+			}
+			if tt.code == "" {
+				return
+			}
+			var resp ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || resp.Error.Code != tt.code {
+				t.Errorf("Expected error code %s, got %+v (%v)", tt.code, resp.Error, err)
+			}
+			if tt.status == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("Expected a WWW-Authenticate header")
+			}
+		})
+	}
+
+	// Editing needs the write scope, on the same paths as reading
+	if w := authRequest(server, http.MethodPatch, "/v1/transactions/abc", tokens["other"]); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 without the write scope, got %d", w.Code)
+	}
+	if w := authRequest(server, http.MethodPatch, "/v1/transactions/abc", tokens["config"]); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected the empty edit to be rejected after auth, got %d", w.Code)
+	}
+}
+
+func TestAuth_RateLimit(t *testing.T) {
+	server, tokens := authServer(t)
+
+	// The read key allows 2 requests per minute
+	for i := 0; i < 2; i++ {
+		if w := authRequest(server, http.MethodGet, "/v1/networth", tokens["read"]); w.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected status 200, got %d", i+1, w.Code)
+		}
+	}
+	w := authRequest(server, http.MethodGet, "/v1/networth", tokens["read"])
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("Expected 429 with Retry-After, got %d (%q)", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// Other keys have their own budget
+	if w := authRequest(server, http.MethodGet, "/v1/networth", tokens["config"]); w.Code != http.StatusOK {
+		t.Errorf("Expected another key to be unaffected, got %d", w.Code)
+	}
+}
+
+func TestAuth_JobsArePrivate(t *testing.T) {
+	server, tokens := authServer(t)
+
+	body, contentType := multipartFiles(t, "tng.csv", tngCSV)
+	req := httptest.NewRequest(http.MethodPost, "/v1/jobs", body)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+tokens["extract"])
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body)
+	}
+	var job Job
+	json.NewDecoder(w.Body).Decode(&job)
+
+	if w := authRequest(server, http.MethodGet, "/v1/jobs/"+job.ID, tokens["extract"]); w.Code != http.StatusOK {
+		t.Errorf("Expected the submitting key to see the job, got %d", w.Code)
+	}
+	if w := authRequest(server, http.MethodGet, "/v1/jobs/"+job.ID, tokens["other"]); w.Code != http.StatusNotFound {
+		t.Errorf("Expected another key to get 404, got %d", w.Code)
+	}
+}
+
+func TestRateLimiter_Refill(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newRateLimiter()
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 60; i++ {
+		if wait := l.take("k", 60); wait != 0 {
+			t.Fatalf("Request %d was limited", i+1)
+		}
+	}
+	if wait := l.take("k", 60); wait != time.Second {
+		t.Fatalf("Expected to wait 1s, got %v", wait)
+	}
+
+	now = now.Add(time.Second)
+	if wait := l.take("k", 60); wait != 0 {
+		t.Errorf("Expected one request after a second, got wait %v", wait)
+	}
+	if wait := l.take("unlimited", 0); wait != 0 {
+		t.Errorf("Expected no limit for 0, got wait %v", wait)
+	}
+}
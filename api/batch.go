@@ -0,0 +1,119 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/aqlanhadi/kwgn/extractor"
+	"github.com/aqlanhadi/kwgn/extractor/common"
+)
+
+// maxArchiveMemberSize caps how much is decompressed per zip member
+const maxArchiveMemberSize = 100 << 20
+
+// BatchResult is the response of POST /v1/extract/batch
+type BatchResult struct {
+	Files   int          `json:"files"`
+	Failed  int          `json:"failed"`
+	Results []FileResult `json:"results"`
+}
+
+// expandUploads replaces zip archives by the PDF and CSV files they contain, in
+// lexical order. Archive members are named <archive>.zip/<member path>. An archive
+// that cannot be read is kept as a single upload that fails with the reason.
+func expandUploads(uploads []upload) []upload {
+	var expanded []upload
+	for _, u := range uploads {
+		if !extractor.IsZipFile(u.name) {
+			expanded = append(expanded, u)
+			continue
+		}
+
+		members, err := unzipUpload(u)
+		if err != nil {
+			expanded = append(expanded, upload{name: u.name, err: err})
+			continue
+		}
+		if len(members) == 0 {
+			expanded = append(expanded, upload{name: u.name, err: fmt.Errorf("archive contains no PDF or CSV files")})
+			continue
+		}
+		expanded = append(expanded, members...)
+	}
+	return expanded
+}
+
+// unzipUpload reads the statement files of an uploaded zip archive
+func unzipUpload(u upload) ([]upload, error) {
+	archive, err := zip.NewReader(bytes.NewReader(u.data), int64(len(u.data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid zip archive: %w", err)
+	}
+
+	var members []upload
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() || !extractor.IsStatementFile(f.Name) {
+			continue
+		}
+
+		// Statements from archive members are traced back to the bundle, as in 'kwgn extract'
+		member := upload{
+			name:   u.name + "/" + f.Name,
+			source: path.Base(u.name) + "/" + strings.TrimSuffix(f.Name, path.Ext(f.Name)),
+			member: f.Name,
+		}
+		if f.UncompressedSize64 > maxArchiveMemberSize {
+			member.err = fmt.Errorf("file is larger than %d MB", maxArchiveMemberSize>>20)
+		} else if rc, err := f.Open(); err != nil {
+			member.err = fmt.Errorf("could not open archive member: %w", err)
+		} else {
+			member.data, err = io.ReadAll(io.LimitReader(rc, maxArchiveMemberSize))
+			rc.Close()
+			if err != nil {
+				member.err = fmt.Errorf("could not read archive member: %w", err)
+			}
+		}
+		members = append(members, member)
+	}
+
+	sort.Slice(members, func(i, j int) bool { return members[i].name < members[j].name })
+	return members, nil
+}
+
+// handleBatch handles POST /v1/extract/batch: several files, or zip archives of them,
+// extracted in one request with a result per file
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	log.Printf("%sReceived batch request from %s", s.config.LogPrefix, r.RemoteAddr)
+
+	if !s.parseMultipart(w, r) {
+		return
+	}
+	uploads, err := s.readUploads(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeNoFile, "Could not read uploaded files: "+err.Error())
+		return
+	}
+	uploads = expandUploads(uploads)
+	opts := s.parseExtractOptions(r)
+
+	// Results are stored by index so they follow the upload (and archive) order
+	result := BatchResult{Files: len(uploads), Results: make([]FileResult, len(uploads))}
+	common.ForEach(len(uploads), s.config.JobWorkers, func(i int) {
+		result.Results[i] = extractUpload(uploads[i], opts)
+	})
+	for _, fr := range result.Results {
+		if fr.Status == FileFailed {
+			result.Failed++
+		}
+	}
+	log.Printf("%sBatch done: %d files, %d failed", s.config.LogPrefix, result.Files, result.Failed)
+
+	writeJSON(w, http.StatusOK, result)
+}
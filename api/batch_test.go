@@ -0,0 +1,100 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// zipOf builds a zip archive from name/content pairs
+func zipOf(t *testing.T, files ...string) string {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for i := 0; i+1 < len(files); i += 2 {
+		f, err := zw.Create(files[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(files[i+1]))
+	}
+	zw.Close()
+	return buf.String()
+}
+
+// postBatch sends files to /v1/extract/batch and decodes the result
+func postBatch(t *testing.T, query string, files ...string) BatchResult {
+	t.Helper()
+	server := New(DefaultConfig())
+	defer server.Close()
+
+	body, contentType := multipartFiles(t, files...)
+	req := httptest.NewRequest(http.MethodPost, "/v1/extract/batch"+query, body)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+	}
+	var result BatchResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return result
+}
+
+func TestBatch_MultipleFiles(t *testing.T) {
+	result := postBatch(t, "?statement_only=true", "a.csv", tngCSV, "b.pdf", "not a valid pdf", "c.csv", tngCSV)
+
+	if result.Files != 3 || result.Failed != 1 || len(result.Results) != 3 {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+	for i, name := range []string{"a.csv", "b.pdf", "c.csv"} {
+		if result.Results[i].Filename != name {
+			t.Errorf("Result %d is %s, expected %s", i, result.Results[i].Filename, name)
+		}
+	}
+	if stmt := result.Results[0].Statements[0]; len(stmt.Transactions) > 0 || stmt.Source == "" {
+		t.Errorf("Expected a statement without transactions, got %+v", stmt)
+	}
+}
+
+func TestBatch_Zip(t *testing.T) {
+	archive := zipOf(t, "2025/feb.csv", tngCSV, "2025/jan.pdf", "not a valid pdf", "readme.txt", "ignored")
+	result := postBatch(t, "", "bundle.zip", archive, "broken.zip", "not a zip", "empty.zip", zipOf(t, "notes.txt", "x"))
+
+	if result.Files != 4 || result.Failed != 3 {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+	feb := result.Results[0]
+	if feb.Filename != "bundle.zip/2025/feb.csv" || feb.Status != FileOK {
+		t.Errorf("Unexpected archive member result: %+v", feb)
+	}
+	if source := feb.Statements[0].Source; source != "bundle.zip/2025/feb" {
+		t.Errorf("Expected source to name the archive, got %s", source)
+	}
+	if r := result.Results[1]; r.Filename != "bundle.zip/2025/jan.pdf" || r.Status != FileFailed {
+		t.Errorf("Unexpected archive member result: %+v", r)
+	}
+	if r := result.Results[2]; r.Filename != "broken.zip" || r.Error == "" {
+		t.Errorf("Expected the invalid archive to fail with a reason: %+v", r)
+	}
+	if r := result.Results[3]; r.Filename != "empty.zip" || r.Status != FileFailed {
+		t.Errorf("Expected the archive without statements to fail: %+v", r)
+	}
+}
+
+func TestBatch_MethodNotAllowed(t *testing.T) {
+	server := New(DefaultConfig())
+	defer server.Close()
+
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/extract/batch", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", w.Code)
+	}
+}
@@ -0,0 +1,315 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/api/kwgnpb"
+	"github.com/aqlanhadi/kwgn/extractor/common"
+	"github.com/aqlanhadi/kwgn/integrations/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// grpcScopes maps each gRPC method to the API key scope it requires. Other
+// methods (the health service) are public.
+var grpcScopes = map[string]string{
+	kwgnpb.Kwgn_Extract_FullMethodName:      store.ScopeExtract,
+	kwgnpb.Kwgn_ExtractBatch_FullMethodName: store.ScopeExtract,
+	kwgnpb.Kwgn_Import_FullMethodName:       store.ScopeImport,
+}
+
+// grpcService implements kwgnpb.KwgnServer with the server's configuration
+type grpcService struct {
+	kwgnpb.UnimplementedKwgnServer
+	s *Server
+}
+
+// newGRPCServer builds the gRPC server with the Kwgn and health services. It shares
+// the API keys, body limit, TLS certificate, database and metrics of the HTTP server.
+func (s *Server) newGRPCServer() (*grpc.Server, *health.Server, error) {
+	maxMsg := math.MaxInt32
+	if s.config.MaxBodyBytes > 0 && s.config.MaxBodyBytes < math.MaxInt32 {
+		maxMsg = int(s.config.MaxBodyBytes)
+	}
+	opts := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(maxMsg),
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := s.grpcAuthorize(ctx, info.FullMethod); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := s.grpcAuthorize(ss.Context(), info.FullMethod); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	}
+	if s.config.TLSCertFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(s.config.TLSCertFile, s.config.TLSKeyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	gs := grpc.NewServer(opts...)
+	kwgnpb.RegisterKwgnServer(gs, &grpcService{s: s})
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(gs, hs)
+	return gs, hs, nil
+}
+
+// ServeGRPC serves the gRPC service on ln until ctx is done. Health checks then
+// report NOT_SERVING for Config.ShutdownDelay, and in-flight calls are given
+// Config.ShutdownTimeout to finish.
+func (s *Server) ServeGRPC(ctx context.Context, ln net.Listener) error {
+	gs, hs, err := s.newGRPCServer()
+	if err != nil {
+		ln.Close()
+		return err
+	}
+	errc := make(chan error, 1)
+	go func() {
+		log.Printf("%sStarting gRPC server on %s", s.config.LogPrefix, ln.Addr())
+		errc <- gs.Serve(ln)
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	hs.Shutdown()
+	if s.config.ShutdownDelay > 0 {
+		time.Sleep(s.config.ShutdownDelay)
+	}
+	stopped := make(chan struct{})
+	go func() {
+		gs.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(s.config.ShutdownTimeout):
+		gs.Stop()
+		<-stopped
+		return fmt.Errorf("gRPC calls still running after %s were cut off", s.config.ShutdownTimeout)
+	}
+	log.Printf("%sgRPC server stopped", s.config.LogPrefix)
+	return nil
+}
+
+// grpcAuthorize requires an API key with the method's scope in the "authorization"
+// metadata when auth is enabled, mirroring authorize
+func (s *Server) grpcAuthorize(ctx context.Context, method string) error {
+	scope, ok := grpcScopes[method]
+	if !ok || !s.config.RequireAuth {
+		return nil
+	}
+
+	reject := func(code codes.Code, message, key string) error {
+		addr := "unknown"
+		if p, ok := peer.FromContext(ctx); ok {
+			addr = p.Addr.String()
+		}
+		log.Printf("%sRejected %s from %s: %s (key %s)", s.config.LogPrefix, method, addr, code, coalesce(key, "none"))
+		return status.Error(code, message)
+	}
+
+	var token string
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("authorization"); len(values) > 0 {
+		token, _ = strings.CutPrefix(values[0], "Bearer ")
+	}
+	if token == "" {
+		return reject(codes.Unauthenticated, "An API key is required (authorization: Bearer <key>)", "")
+	}
+	key, err := s.lookupKey(ctx, token)
+	if err != nil {
+		log.Printf("%sError looking up API key: %v", s.config.LogPrefix, err)
+		return status.Error(codes.Internal, "Could not check the API key")
+	}
+	if key == nil {
+		return reject(codes.Unauthenticated, "Invalid or revoked API key", "")
+	}
+	if !key.HasScope(scope) {
+		return reject(codes.PermissionDenied, "The API key lacks the '"+scope+"' scope", key.Prefix)
+	}
+	if wait := s.limiter.take(key.Hash, key.RateLimit); wait > 0 {
+		return reject(codes.ResourceExhausted, fmt.Sprintf("Rate limit of %d requests per minute exceeded, retry in %s",
+			key.RateLimit, wait.Round(time.Second)), key.Prefix)
+	}
+	return nil
+}
+
+// receiveFile checks and records a file sent over gRPC
+func (s *Server) receiveFile(f *kwgnpb.File) (upload, error) {
+	if f.GetName() == "" || len(f.GetContent()) == 0 {
+		return upload{}, status.Error(codes.InvalidArgument, "A file with a name and content is required")
+	}
+	s.config.Metrics.ObserveUpload(int64(len(f.GetContent())))
+	return upload{name: f.GetName(), data: f.GetContent()}, nil
+}
+
+// Extract implements kwgnpb.KwgnServer, like POST /v1/extract
+func (g *grpcService) Extract(ctx context.Context, req *kwgnpb.ExtractRequest) (*kwgnpb.ExtractResponse, error) {
+	u, err := g.s.receiveFile(req.GetFile())
+	if err != nil {
+		return nil, err
+	}
+	statements, err := extractStatements(u, req.GetStatementType())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &kwgnpb.ExtractResponse{Filename: u.name, Statements: statementsToProto(statements)}, nil
+}
+
+// ExtractBatch implements kwgnpb.KwgnServer, like POST /v1/extract/batch with
+// results sent as each file is extracted
+func (g *grpcService) ExtractBatch(stream kwgnpb.Kwgn_ExtractBatchServer) error {
+	files, failed := 0, 0
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			log.Printf("%sgRPC batch done: %d files, %d failed", g.s.config.LogPrefix, files, failed)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		u, err := g.s.receiveFile(req.GetFile())
+		if err != nil {
+			return err
+		}
+
+		for _, u := range expandUploads([]upload{u}) {
+			files++
+			resp := &kwgnpb.ExtractResponse{Filename: u.name}
+			if statements, err := extractStatements(u, req.GetStatementType()); err != nil {
+				failed++
+				resp.Error = err.Error()
+			} else {
+				resp.Statements = statementsToProto(statements)
+			}
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Import implements kwgnpb.KwgnServer, like POST /v1/import with each file
+// imported as it arrives
+func (g *grpcService) Import(stream kwgnpb.Kwgn_ImportServer) error {
+	if g.s.config.Store == nil {
+		return status.Error(codes.FailedPrecondition, "No database configured (start the server with --db-url)")
+	}
+
+	total := &kwgnpb.ImportResponse{}
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		u, err := g.s.receiveFile(req.GetFile())
+		if err != nil {
+			return err
+		}
+
+		opts := store.ImportOptions{Force: req.GetForce(), StatementType: req.GetStatementType()}
+		resp := g.s.importUploads(stream.Context(), expandUploads([]upload{u}), opts)
+		total.Files += int32(resp.Files)
+		total.Processed += int32(resp.Processed)
+		total.Skipped += int32(resp.Skipped)
+		total.Failed += int32(resp.Failed)
+		total.TransactionsInserted += int32(resp.TransactionsInserted)
+		total.TransactionsSkipped += int32(resp.TransactionsSkipped)
+		total.Errors = append(total.Errors, resp.Errors...)
+	}
+
+	log.Printf("%sImported %d files over gRPC: %d processed, %d skipped, %d failed", g.s.config.LogPrefix,
+		total.Files, total.Processed, total.Skipped, total.Failed)
+	return stream.SendAndClose(total)
+}
+
+// statementsToProto converts extracted statements to their protobuf messages
+func statementsToProto(statements []common.Statement) []*kwgnpb.Statement {
+	out := make([]*kwgnpb.Statement, 0, len(statements))
+	for _, stmt := range statements {
+		msg := &kwgnpb.Statement{
+			Source:                  stmt.Source,
+			StartingBalance:         stmt.StartingBalance.String(),
+			EndingBalance:           stmt.EndingBalance.String(),
+			TotalCredit:             stmt.TotalCredit.String(),
+			TotalDebit:              stmt.TotalDebit.String(),
+			Nett:                    stmt.Nett.String(),
+			CalculatedEndingBalance: stmt.CalculatedEndingBalance.String(),
+			TransactionStartDate:    timestampOrNil(stmt.TransactionStartDate),
+			TransactionEndDate:      timestampOrNil(stmt.TransactionEndDate),
+			Account: &kwgnpb.Account{
+				AccountNumber: stmt.Account.AccountNumber,
+				AccountName:   stmt.Account.AccountName,
+				AccountType:   stmt.Account.AccountType,
+				DebitCredit:   stmt.Account.DebitCredit,
+				Reconciliable: stmt.Account.Reconciliable,
+			},
+		}
+		if stmt.StatementDate != nil {
+			msg.StatementDate = timestampOrNil(*stmt.StatementDate)
+		}
+		for _, tx := range stmt.Transactions {
+			msg.Transactions = append(msg.Transactions, transactionToProto(tx))
+		}
+		out = append(out, msg)
+	}
+	return out
+}
+
+// transactionToProto converts an extracted transaction to its protobuf message
+func transactionToProto(tx common.Transaction) *kwgnpb.Transaction {
+	msg := &kwgnpb.Transaction{
+		Sequence:     int32(tx.Sequence),
+		Date:         timestampOrNil(tx.Date),
+		Descriptions: tx.Descriptions,
+		Type:         tx.Type,
+		Amount:       tx.Amount.String(),
+		Balance:      tx.Balance.String(),
+		Reference:    tx.Reference,
+		Tags:         tx.Tags,
+	}
+	if len(tx.Data) > 0 {
+		// Data holds JSON values, which always convert
+		msg.Data, _ = structpb.NewStruct(tx.Data)
+	}
+	return msg
+}
+
+// timestampOrNil converts t, leaving zero times unset
+func timestampOrNil(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
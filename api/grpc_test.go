@@ -0,0 +1,196 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/aqlanhadi/kwgn/api/kwgnpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// grpcClient serves server's gRPC service in memory and returns a connected client
+func grpcClient(t *testing.T, server *Server) (kwgnpb.KwgnClient, *grpc.ClientConn) {
+	t.Helper()
+	ln := bufconn.Listen(1 << 20)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.ServeGRPC(ctx, ln) }()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Unexpected gRPC server error: %v", err)
+		}
+	})
+	return kwgnpb.NewKwgnClient(conn), conn
+}
+
+func TestGRPC_Extract(t *testing.T) {
+	server := New(DefaultConfig())
+	defer server.Close()
+	client, _ := grpcClient(t, server)
+
+	resp, err := client.Extract(context.Background(), &kwgnpb.ExtractRequest{File: &kwgnpb.File{Name: "tng.csv", Content: []byte(tngCSV)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Statements) != 1 || len(resp.Statements[0].Transactions) != 2 {
+		t.Fatalf("Unexpected response: %v", resp)
+	}
+	stmt := resp.Statements[0]
+	if stmt.Account.GetAccountNumber() != "2222222222" || stmt.EndingBalance != "140" || stmt.Transactions[1].Amount != "50" {
+		t.Errorf("Expected the statement with decimal strings, got %v", stmt)
+	}
+	if stmt.Transactions[0].Date.AsTime().Format("2006-01-02") != "2025-01-01" {
+		t.Errorf("Unexpected transaction date %v", stmt.Transactions[0].Date)
+	}
+
+	_, err = client.Extract(context.Background(), &kwgnpb.ExtractRequest{File: &kwgnpb.File{Name: "bad.pdf", Content: []byte("not a pdf")}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for an unreadable file, got %v", err)
+	}
+	if _, err = client.Extract(context.Background(), &kwgnpb.ExtractRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument without a file, got %v", err)
+	}
+}
+
+func TestGRPC_ExtractBatch(t *testing.T) {
+	server := New(DefaultConfig())
+	defer server.Close()
+	client, _ := grpcClient(t, server)
+
+	stream, err := client.ExtractBatch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := zipOf(t, "2025/feb.csv", tngCSV, "2025/jan.pdf", "not a valid pdf")
+	for _, f := range []*kwgnpb.File{{Name: "bundle.zip", Content: []byte(archive)}, {Name: "tng.csv", Content: []byte(tngCSV)}} {
+		if err := stream.Send(&kwgnpb.ExtractRequest{File: f}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stream.CloseSend()
+
+	var got []string
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, resp.Filename+":"+map[bool]string{true: "failed", false: "ok"}[resp.Error != ""])
+	}
+	want := "bundle.zip/2025/feb.csv:ok,bundle.zip/2025/jan.pdf:failed,tng.csv:ok"
+	if strings.Join(got, ",") != want {
+		t.Errorf("Expected %s, got %s", want, strings.Join(got, ","))
+	}
+}
+
+func TestGRPC_Import(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Store = newTestStore(t)
+	server := New(cfg)
+	defer server.Close()
+	client, _ := grpcClient(t, server)
+
+	importFiles := func(files ...*kwgnpb.ImportRequest) *kwgnpb.ImportResponse {
+		t.Helper()
+		stream, err := client.Import(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range files {
+			if err := stream.Send(f); err != nil {
+				t.Fatal(err)
+			}
+		}
+		resp, err := stream.CloseAndRecv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := importFiles(&kwgnpb.ImportRequest{File: &kwgnpb.File{Name: "tng.csv", Content: []byte(tngCSV)}},
+		&kwgnpb.ImportRequest{File: &kwgnpb.File{Name: "broken.zip", Content: []byte("not a zip")}})
+	if resp.Files != 2 || resp.Processed != 1 || resp.Failed != 1 || resp.TransactionsInserted != 2 || len(resp.Errors) != 1 {
+		t.Fatalf("Unexpected import: %v", resp)
+	}
+	resp = importFiles(&kwgnpb.ImportRequest{File: &kwgnpb.File{Name: "copy.csv", Content: []byte(tngCSV)}})
+	if resp.Skipped != 1 || resp.Processed != 0 {
+		t.Errorf("Expected the copy to be skipped, got %v", resp)
+	}
+	resp = importFiles(&kwgnpb.ImportRequest{File: &kwgnpb.File{Name: "copy.csv", Content: []byte(tngCSV)}, Force: true})
+	if resp.Processed != 1 {
+		t.Errorf("Expected force to import again, got %v", resp)
+	}
+
+	// Without a database
+	server = New(DefaultConfig())
+	defer server.Close()
+	client, _ = grpcClient(t, server)
+	stream, _ := client.Import(context.Background())
+	if _, err := stream.CloseAndRecv(); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition without a database, got %v", err)
+	}
+}
+
+func TestGRPC_Auth(t *testing.T) {
+	server, tokens := authServer(t)
+	client, conn := grpcClient(t, server)
+	file := &kwgnpb.File{Name: "tng.csv", Content: []byte(tngCSV)}
+
+	withKey := func(token string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	}
+	tests := []struct {
+		name string
+		ctx  context.Context
+		code codes.Code
+	}{
+		{"no key", context.Background(), codes.Unauthenticated},
+		{"unknown key", withKey("kwgn_unknown"), codes.Unauthenticated},
+		{"revoked key", withKey(tokens["revoked"]), codes.Unauthenticated},
+		{"missing scope", withKey(tokens["read"]), codes.PermissionDenied},
+		{"extract key", withKey(tokens["extract"]), codes.OK},
+	}
+	for _, tt := range tests {
+		_, err := client.Extract(tt.ctx, &kwgnpb.ExtractRequest{File: file})
+		if status.Code(err) != tt.code {
+			t.Errorf("%s: expected %s, got %v", tt.name, tt.code, err)
+		}
+	}
+
+	// Streams are checked the same way
+	stream, err := client.Import(withKey(tokens["extract"]))
+	if err == nil {
+		_, err = stream.CloseAndRecv()
+	}
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected import without the import scope to be denied, got %v", err)
+	}
+
+	// Health checks are public
+	health, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil || health.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Expected SERVING, got %v (%v)", health, err)
+	}
+}
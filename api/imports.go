@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/aqlanhadi/kwgn/extractor"
+	"github.com/aqlanhadi/kwgn/integrations/store"
+)
+
+// ImportResponse is the response of POST /v1/import. Counts are of statements, as
+// in 'kwgn import'; a file that was already imported counts as one skipped.
+type ImportResponse struct {
+	Files                int      `json:"files"`
+	Processed            int      `json:"processed"`
+	Skipped              int      `json:"skipped"`
+	Failed               int      `json:"failed"`
+	TransactionsInserted int      `json:"transactions_inserted"`
+	TransactionsSkipped  int      `json:"transactions_skipped"`
+	Errors               []string `json:"errors"`
+}
+
+// handleImport handles POST /v1/import: files (or zip archives of them) are
+// extracted and stored in the database, with the same deduplication as 'kwgn import'
+func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
+	if !s.requireStore(w) {
+		return
+	}
+	if !s.parseMultipart(w, r) {
+		return
+	}
+	uploads, err := s.readUploads(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeNoFile, "Could not read uploaded files: "+err.Error())
+		return
+	}
+	uploads = expandUploads(uploads)
+
+	opts := store.ImportOptions{
+		Force:         r.FormValue("force") == "true" || r.URL.Query().Get("force") == "true",
+		StatementType: s.parseExtractOptions(r).StatementType,
+	}
+	resp := s.importUploads(r.Context(), uploads, opts)
+
+	log.Printf("%sImported %d files from %s: %d processed, %d skipped, %d failed", s.config.LogPrefix,
+		resp.Files, r.RemoteAddr, resp.Processed, resp.Skipped, resp.Failed)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// importUploads imports uploaded files, with zip archives already expanded. Files
+// that could not be read count as failed.
+func (s *Server) importUploads(ctx context.Context, uploads []upload, opts store.ImportOptions) ImportResponse {
+	opts.Jobs = s.config.JobWorkers
+	if s.config.Webhooks != nil {
+		opts.OnEvent = s.config.Webhooks.ObserveImport
+	}
+
+	resp := ImportResponse{Files: len(uploads), Errors: []string{}}
+	var inputs []extractor.Input
+	for _, u := range uploads {
+		if u.err != nil {
+			resp.Failed++
+			resp.Errors = append(resp.Errors, u.name+": "+u.err.Error())
+			s.config.Webhooks.ObserveImport(store.ImportEvent{File: u.name, Outcome: store.OutcomeFailed, Error: u.name + ": " + u.err.Error()})
+			continue
+		}
+		inputs = append(inputs, uploadInput(u))
+	}
+
+	result := s.importer.ImportInputs(ctx, inputs, opts)
+	s.config.Metrics.ObserveImport(result)
+	resp.Processed += result.Processed
+	resp.Skipped += result.Skipped
+	resp.Failed += result.Failed
+	resp.TransactionsInserted = result.TransactionsInserted
+	resp.TransactionsSkipped = result.TransactionsSkipped
+	resp.Errors = append(resp.Errors, result.Errors...)
+	return resp
+}
+
+// uploadInput wraps an upload for the importer. Archive members keep their archive
+// as the path, so their statements are traced back to the bundle.
+func uploadInput(u upload) extractor.Input {
+	if u.member == "" {
+		return extractor.Input{Path: u.name, Data: u.data}
+	}
+	return extractor.Input{Path: strings.TrimSuffix(u.name, "/"+u.member), Member: u.member, Data: u.data}
+}
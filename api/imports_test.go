@@ -0,0 +1,136 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aqlanhadi/kwgn/integrations/store"
+	"github.com/aqlanhadi/kwgn/integrations/webhook"
+)
+
+// postImport uploads name/content pairs to /v1/import
+func postImport(t *testing.T, server *Server, url string, files ...string) ImportResponse {
+	t.Helper()
+	body, contentType := multipartFiles(t, files...)
+	req := httptest.NewRequest(http.MethodPost, url, body)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+	}
+	var resp ImportResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return resp
+}
+
+func TestImport(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Store = newTestStore(t)
+	server := New(cfg)
+	defer server.Close()
+
+	resp := postImport(t, server, "/v1/import", "tng.csv", tngCSV)
+	if resp.Files != 1 || resp.Processed != 1 || resp.Failed != 0 || resp.TransactionsInserted != 2 {
+		t.Fatalf("Unexpected first import: %+v", resp)
+	}
+
+	// The same content is recognised under another name
+	resp = postImport(t, server, "/v1/import", "copy.csv", tngCSV)
+	if resp.Processed != 0 || resp.Skipped != 1 {
+		t.Errorf("Expected the copy to be skipped, got %+v", resp)
+	}
+	records, err := cfg.Store.ListFileRecords(context.Background(), "upload:copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Outcome != store.OutcomeDuplicate || records[0].DuplicateOf != "upload:tng.csv" {
+		t.Errorf("Expected the copy to be recorded as a duplicate, got %+v", records)
+	}
+
+	resp = postImport(t, server, "/v1/import?force=true", "copy.csv", tngCSV)
+	if resp.Skipped != 0 || resp.Processed != 1 {
+		t.Errorf("Expected force to import again, got %+v", resp)
+	}
+
+	records, err = cfg.Store.ListFileRecords(context.Background(), "upload:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Path != "upload:copy.csv" {
+		t.Errorf("Expected one ledger entry for the upload, got %+v", records)
+	}
+}
+
+func TestImport_Failures(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Store = newTestStore(t)
+	server := New(cfg)
+	defer server.Close()
+
+	archive := zipOf(t, "2025/feb.csv", tngCSV, "2025/jan.pdf", "not a valid pdf")
+	resp := postImport(t, server, "/v1/import", "bundle.zip", archive, "broken.zip", "not a zip")
+	if resp.Files != 3 || resp.Processed != 1 || resp.Failed != 2 || len(resp.Errors) != 2 {
+		t.Fatalf("Unexpected result: %+v", resp)
+	}
+	if !strings.HasPrefix(resp.Errors[0], "broken.zip: ") || !strings.HasPrefix(resp.Errors[1], "bundle.zip/2025/jan.pdf: ") {
+		t.Errorf("Expected errors naming the files, got %q", resp.Errors)
+	}
+
+	statements, err := cfg.Store.ListStatements(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, stmt := range statements {
+		found = found || stmt.Source == "bundle.zip/2025/feb"
+	}
+	if !found {
+		t.Errorf("Expected a statement sourced from the archive member, got %+v", statements)
+	}
+}
+
+func TestImport_Webhooks(t *testing.T) {
+	var mu sync.Mutex
+	var events []webhook.Event
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e webhook.Event
+		json.NewDecoder(r.Body).Decode(&e)
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+
+	hooks := webhook.DefaultConfig()
+	hooks.Endpoints = []webhook.Endpoint{{URL: receiver.URL, Secret: "s", Events: []string{webhook.EventStatementImported, webhook.EventImportFailed}}}
+	notifier, err := webhook.New(hooks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Store = newTestStore(t)
+	cfg.Webhooks = notifier
+	server := New(cfg)
+	defer server.Close()
+
+	postImport(t, server, "/v1/import", "tng.csv", tngCSV, "broken.zip", "not a zip")
+	postImport(t, server, "/v1/import", "copy.csv", tngCSV) // Skipped, not reported
+	notifier.Close(context.Background())
+
+	got := make(map[string]string) // Event type -> file
+	for _, e := range events {
+		got[e.Type] = e.Data.File
+	}
+	if len(events) != 2 || got[webhook.EventStatementImported] != "tng.csv" || got[webhook.EventImportFailed] != "broken.zip" {
+		t.Errorf("Unexpected events: %+v", events)
+	}
+}
@@ -0,0 +1,409 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/extractor"
+	"github.com/aqlanhadi/kwgn/extractor/common"
+)
+
+// Job states
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"   // Every file was processed; individual files may still have failed
+	JobFailed  = "failed" // The job could not run at all
+)
+
+// File outcomes
+const (
+	FileOK     = "ok"
+	FileFailed = "failed"
+)
+
+// errQueueFull is returned when no more jobs can be accepted
+var errQueueFull = errors.New("job queue is full")
+
+// errShuttingDown is returned for jobs submitted once shutdown has started
+var errShuttingDown = errors.New("server is shutting down")
+
+// upload is a file received in a request, held in memory until it is processed
+type upload struct {
+	name   string
+	data   []byte
+	source string // Statement source override, set for zip archive members
+	member string // Path inside the zip archive, set for archive members
+	err    error  // Why the file cannot be processed, reported as its result
+}
+
+// FileResult is the extraction outcome of one uploaded file
+type FileResult struct {
+	Filename     string               `json:"filename"`
+	Status       string               `json:"status"`
+	Error        string               `json:"error,omitempty"`
+	Statements   []StatementResponse  `json:"statements,omitempty"`
+	Transactions []common.Transaction `json:"transactions,omitempty"` // Instead of statements with transaction_only
+}
+
+// JobProgress counts processed files
+type JobProgress struct {
+	Total     int `json:"total"`
+	Processed int `json:"processed"`
+	Failed    int `json:"failed"`
+}
+
+// Job is an asynchronous extraction of one or more uploaded files
+type Job struct {
+	ID         string       `json:"id"`
+	Status     string       `json:"status"`
+	Error      string       `json:"error,omitempty"`
+	Progress   JobProgress  `json:"progress"`
+	CreatedAt  time.Time    `json:"created_at"`
+	StartedAt  *time.Time   `json:"started_at,omitempty"`
+	FinishedAt *time.Time   `json:"finished_at,omitempty"`
+	ExpiresAt  *time.Time   `json:"expires_at,omitempty"`
+	Results    []FileResult `json:"results,omitempty"` // Set once the job is done
+
+	opts    ExtractOptions
+	uploads []upload
+	owner   string // Hash of the API key that submitted the job, if any
+}
+
+// jobQueue runs extraction jobs on a fixed number of workers.
+// Finished jobs are kept until they expire (ttl 0 keeps them forever).
+type jobQueue struct {
+	mu       sync.Mutex
+	jobs     map[string]*Job
+	queue    chan *Job
+	ttl      time.Duration
+	prefix   string
+	stopping bool           // No more jobs are accepted
+	pending  sync.WaitGroup // Jobs queued or running
+	done     chan struct{}
+	closed   sync.Once
+	wg       sync.WaitGroup
+}
+
+// newJobQueue starts workers goroutines taking jobs from a queue of the given size
+func newJobQueue(workers, size int, ttl time.Duration, logPrefix string) *jobQueue {
+	if workers < 1 {
+		workers = 1
+	}
+	if size < 1 {
+		size = 1
+	}
+	q := &jobQueue{
+		jobs:   make(map[string]*Job),
+		queue:  make(chan *Job, size),
+		ttl:    ttl,
+		prefix: logPrefix,
+		done:   make(chan struct{}),
+	}
+	for i := 0; i < workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+	if ttl > 0 {
+		q.wg.Add(1)
+		go q.expire()
+	}
+	return q
+}
+
+// submit queues a job for the uploads, failing when the queue is full
+func (q *jobQueue) submit(uploads []upload, opts ExtractOptions, owner string) (*Job, error) {
+	job := &Job{
+		ID:        newJobID(),
+		Status:    JobQueued,
+		Progress:  JobProgress{Total: len(uploads)},
+		CreatedAt: time.Now().UTC(),
+		opts:      opts,
+		uploads:   uploads,
+		owner:     owner,
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.stopping {
+		return nil, errShuttingDown
+	}
+	select {
+	case q.queue <- job:
+	default:
+		return nil, errQueueFull
+	}
+	q.pending.Add(1)
+	q.jobs[job.ID] = job
+	return job.snapshot(), nil
+}
+
+// get returns a copy of a job, or nil if it is unknown or expired
+func (q *jobQueue) get(id string) *Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return nil
+	}
+	return job.snapshot()
+}
+
+// snapshot copies the exported fields so they can be encoded without holding the lock
+func (j *Job) snapshot() *Job {
+	c := *j
+	c.Results = append([]FileResult(nil), j.Results...)
+	c.opts, c.uploads = ExtractOptions{}, nil
+	return &c
+}
+
+// work processes queued jobs until the queue is closed
+func (q *jobQueue) work() {
+	defer q.wg.Done()
+	for {
+		select {
+		case <-q.done:
+			return
+		case job := <-q.queue:
+			q.run(job)
+		}
+	}
+}
+
+// run extracts every file of a job, updating its progress as it goes
+func (q *jobQueue) run(job *Job) {
+	q.mu.Lock()
+	now := time.Now().UTC()
+	job.Status, job.StartedAt = JobRunning, &now
+	uploads, opts := job.uploads, job.opts
+	q.mu.Unlock()
+
+	log.Printf("%sJob %s started (%d files)", q.prefix, job.ID, len(uploads))
+	results := make([]FileResult, len(uploads))
+	for i, u := range uploads {
+		results[i] = extractUpload(u, opts)
+
+		q.mu.Lock()
+		job.Progress.Processed++
+		if results[i].Status == FileFailed {
+			job.Progress.Failed++
+		}
+		q.mu.Unlock()
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	defer q.pending.Done()
+	q.finish(job, JobDone, results, "")
+	log.Printf("%sJob %s done: %d processed, %d failed", q.prefix, job.ID, job.Progress.Processed, job.Progress.Failed)
+}
+
+// finish records the outcome of a job; q.mu must be held
+func (q *jobQueue) finish(job *Job, status string, results []FileResult, reason string) {
+	now := time.Now().UTC()
+	job.Status, job.FinishedAt, job.Results, job.Error = status, &now, results, reason
+	job.uploads = nil // Release the file contents
+	if q.ttl > 0 {
+		expires := now.Add(q.ttl)
+		job.ExpiresAt = &expires
+	}
+}
+
+// expire periodically forgets finished jobs past their expiry
+func (q *jobQueue) expire() {
+	defer q.wg.Done()
+	interval := q.ttl / 2
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q.done:
+			return
+		case now := <-ticker.C:
+			q.mu.Lock()
+			for id, job := range q.jobs {
+				if job.ExpiresAt != nil && now.After(*job.ExpiresAt) {
+					delete(q.jobs, id)
+				}
+			}
+			q.mu.Unlock()
+		}
+	}
+}
+
+// stop makes submit fail with errShuttingDown
+func (q *jobQueue) stop() {
+	q.mu.Lock()
+	q.stopping = true
+	q.mu.Unlock()
+}
+
+// drain stops accepting jobs and waits until every queued and running job has
+// finished, or ctx is done. It reports whether the queue was drained.
+func (q *jobQueue) drain(ctx context.Context) bool {
+	q.stop()
+
+	drained := make(chan struct{})
+	go func() {
+		q.pending.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// close stops accepting jobs, fails those still queued and stops the workers
+// after their current job
+func (q *jobQueue) close() {
+	q.mu.Lock()
+	q.stopping = true
+	failed := 0
+drop:
+	for {
+		select {
+		case job := <-q.queue:
+			q.finish(job, JobFailed, nil, "The server shut down before the job could start")
+			q.pending.Done()
+			failed++
+		default:
+			break drop
+		}
+	}
+	if failed > 0 {
+		log.Printf("%s%d queued jobs failed: shutting down", q.prefix, failed)
+	}
+	q.mu.Unlock()
+
+	q.closed.Do(func() { close(q.done) })
+	q.wg.Wait()
+}
+
+// extractUpload runs multi-statement extraction on one uploaded file
+func extractUpload(u upload, opts ExtractOptions) FileResult {
+	result := FileResult{Filename: u.name, Status: FileOK}
+	statements, err := extractStatements(u, opts.StatementType)
+	if err != nil {
+		result.Status, result.Error = FileFailed, err.Error()
+		return result
+	}
+	for _, stmt := range statements {
+		if opts.TransactionOnly {
+			result.Transactions = append(result.Transactions, stmt.Transactions...)
+			continue
+		}
+		result.Statements = append(result.Statements, newStatementResponse(stmt, opts.StatementOnly))
+	}
+	return result
+}
+
+// extractStatements returns the statements of one uploaded file, failing when
+// none could be extracted
+func extractStatements(u upload, statementType string) ([]common.Statement, error) {
+	if u.err != nil {
+		return nil, u.err
+	}
+	statements := extractor.ProcessReaderMulti(bytes.NewReader(u.data), u.name, statementType)
+	if len(statements) == 0 {
+		return nil, errors.New("no statement could be extracted from the file")
+	}
+	if u.source != "" {
+		for i := range statements {
+			statements[i].Source = u.source
+		}
+	}
+	return statements, nil
+}
+
+// keyHash identifies the API key of a request, empty when auth is off
+func keyHash(r *http.Request) string {
+	if key := requestKey(r); key != nil {
+		return key.Hash
+	}
+	return ""
+}
+
+// newJobID returns a random 128-bit hex id
+func newJobID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// readUploads reads every "file" part of a parsed multipart form into memory
+func (s *Server) readUploads(r *http.Request) ([]upload, error) {
+	if r.MultipartForm == nil || len(r.MultipartForm.File["file"]) == 0 {
+		return nil, errors.New("no file uploaded (use one or more 'file' form fields)")
+	}
+
+	var uploads []upload
+	for _, fh := range r.MultipartForm.File["file"] {
+		f, err := fh.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		s.config.Metrics.ObserveUpload(int64(len(data)))
+		uploads = append(uploads, upload{name: fh.Filename, data: data})
+	}
+	return uploads, nil
+}
+
+// handleJobs handles POST /v1/jobs: files (or zip archives of them) are queued for
+// extraction and a job id is returned at once
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	if !s.parseMultipart(w, r) {
+		return
+	}
+	uploads, err := s.readUploads(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeNoFile, "Could not read uploaded files: "+err.Error())
+		return
+	}
+	uploads = expandUploads(uploads)
+
+	job, err := s.jobs.submit(uploads, s.parseExtractOptions(r), keyHash(r))
+	if errors.Is(err, errShuttingDown) {
+		writeError(w, http.StatusServiceUnavailable, CodeShuttingDown, "The server is shutting down, try another instance")
+		return
+	}
+	if err != nil {
+		w.Header().Set("Retry-After", "30")
+		writeError(w, http.StatusServiceUnavailable, CodeQueueFull, "Too many jobs queued, try again later")
+		return
+	}
+	log.Printf("%sQueued job %s (%d files) from %s", s.config.LogPrefix, job.ID, len(uploads), r.RemoteAddr)
+
+	w.Header().Set("Location", "/v1/jobs/"+job.ID)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// handleJob handles GET /v1/jobs/{id}: status, progress and, once done, the results
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	// Jobs are private to the API key that submitted them
+	job := s.jobs.get(r.PathValue("id"))
+	if job == nil || job.owner != keyHash(r) {
+		writeError(w, http.StatusNotFound, CodeNotFound, "Job not found (it may have expired)")
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
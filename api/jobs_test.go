@@ -0,0 +1,202 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const tngCSV = `MFG Number,Trans. No.,Transaction Date/Time,Posted Date,Trans. Type,Sector,Entry Location,Entry SP,Exit Location,Exit SP,Reload Location,Trans. Amount (RM),Balance (RM),Vehicle Class,Device No.,Transaction ID,Vehicle Number
+2222222222,1,2025-01-01 10:00:00,2025-01-02 00:00:00,Usage,TOLL,TOLL A,SP_A,TOLL A,SP_A,,10.00,90.00,00,,TX001,
+2222222222,2,2025-01-03 10:00:00,2025-01-04 00:00:00,Reload,INTERNET RELOAD,OTA-TNGD,TD_TNG,OTA-TNGD,TD_TNG,OTA-TNGD,50.00,140.00,00,,TX002,`
+
+// multipartFiles builds a multipart body with one "file" part per name/content pair
+func multipartFiles(t *testing.T, files ...string) (*bytes.Buffer, string) {
+	t.Helper()
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for i := 0; i+1 < len(files); i += 2 {
+		part, _ := writer.CreateFormFile("file", files[i])
+		part.Write([]byte(files[i+1]))
+	}
+	writer.Close()
+	return body, writer.FormDataContentType()
+}
+
+// waitForJob polls GET /v1/jobs/{id} until the job is done
+func waitForJob(t *testing.T, server *Server, id string) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/jobs/"+id, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		var job Job
+		if err := json.NewDecoder(w.Body).Decode(&job); err != nil {
+			t.Fatalf("Failed to decode job: %v", err)
+		}
+		if job.Status == JobDone {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Job still %s after 5s", job.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestJobs_SubmitAndPoll(t *testing.T) {
+	server := New(DefaultConfig())
+	defer server.Close()
+
+	body, contentType := multipartFiles(t, "tng.csv", tngCSV, "broken.pdf", "not a valid pdf")
+	req := httptest.NewRequest(http.MethodPost, "/v1/jobs?transaction_only=true", body)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body)
+	}
+	var queued Job
+	if err := json.NewDecoder(w.Body).Decode(&queued); err != nil {
+		t.Fatalf("Failed to decode job: %v", err)
+	}
+	if queued.ID == "" || queued.Progress.Total != 2 || w.Header().Get("Location") != "/v1/jobs/"+queued.ID {
+		t.Fatalf("Unexpected queued job: %+v (Location %s)", queued, w.Header().Get("Location"))
+	}
+
+	job := waitForJob(t, server, queued.ID)
+	if job.Progress.Processed != 2 || job.Progress.Failed != 1 || job.ExpiresAt == nil {
+		t.Errorf("Unexpected progress: %+v", job)
+	}
+	if len(job.Results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(job.Results))
+	}
+	if r := job.Results[0]; r.Filename != "tng.csv" || r.Status != FileOK || len(r.Statements) != 0 || len(r.Transactions) == 0 {
+		t.Errorf("Unexpected CSV result: %+v", r)
+	}
+	if r := job.Results[1]; r.Status != FileFailed || r.Error == "" {
+		t.Errorf("Expected the PDF to fail with a reason: %+v", r)
+	}
+}
+
+func TestJobs_NotFound(t *testing.T) {
+	server := New(DefaultConfig())
+	defer server.Close()
+
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/jobs/unknown", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestJobs_NoFile(t *testing.T) {
+	server := New(DefaultConfig())
+	defer server.Close()
+
+	body, contentType := multipartFiles(t)
+	req := httptest.NewRequest(http.MethodPost, "/v1/jobs", body)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestJobQueue_Full(t *testing.T) {
+	// No workers, so nothing is taken off the queue
+	q := &jobQueue{jobs: make(map[string]*Job), queue: make(chan *Job, 1)}
+
+	if _, err := q.submit([]upload{{name: "a.pdf"}}, ExtractOptions{}, ""); err != nil {
+		t.Fatalf("First job should be queued: %v", err)
+	}
+	if _, err := q.submit([]upload{{name: "b.pdf"}}, ExtractOptions{}, ""); err != errQueueFull {
+		t.Errorf("Expected errQueueFull, got %v", err)
+	}
+}
+
+func TestJobQueue_Expiry(t *testing.T) {
+	q := newJobQueue(1, 1, 20*time.Millisecond, "")
+	defer q.close()
+
+	job, err := q.submit([]upload{{name: "a.pdf", data: []byte("x")}}, ExtractOptions{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for q.get(job.ID) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Job did not expire")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestJobQueue_Drain(t *testing.T) {
+	q := newJobQueue(1, 10, 0, "")
+	defer q.close()
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		job, err := q.submit([]upload{{name: "tng.csv", data: []byte(tngCSV)}}, ExtractOptions{}, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, job.ID)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if !q.drain(ctx) {
+		t.Fatal("Expected the queue to drain")
+	}
+	for _, id := range ids {
+		if job := q.get(id); job.Status != JobDone {
+			t.Errorf("Expected job %s to be done, got %s", id, job.Status)
+		}
+	}
+	if _, err := q.submit([]upload{{name: "a.pdf"}}, ExtractOptions{}, ""); err != errShuttingDown {
+		t.Errorf("Expected errShuttingDown, got %v", err)
+	}
+}
+
+func TestJobQueue_CloseFailsQueued(t *testing.T) {
+	// No workers, so the job is still queued when the queue closes
+	q := &jobQueue{jobs: make(map[string]*Job), queue: make(chan *Job, 1), done: make(chan struct{})}
+	job, err := q.submit([]upload{{name: "a.pdf"}}, ExtractOptions{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.close()
+
+	if got := q.get(job.ID); got.Status != JobFailed || got.Error == "" || got.FinishedAt == nil {
+		t.Errorf("Expected the queued job to fail with a reason, got %+v", got)
+	}
+}
+
+func TestJobs_RefusedWhileShuttingDown(t *testing.T) {
+	server := New(DefaultConfig())
+	defer server.Close()
+	server.jobs.stop()
+
+	body, contentType := multipartFiles(t, "tng.csv", tngCSV)
+	req := httptest.NewRequest(http.MethodPost, "/v1/jobs", body)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+
+	var resp ErrorResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusServiceUnavailable || resp.Error.Code != CodeShuttingDown {
+		t.Errorf("Expected 503 %s, got %d %+v", CodeShuttingDown, w.Code, resp)
+	}
+}
@@ -0,0 +1,800 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: kwgn/v1/kwgn.proto
+
+package kwgnpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// File is an uploaded statement file or zip archive.
+type File struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // Used to detect the format and as the statement source
+	Content       []byte                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *File) Reset() {
+	*x = File{}
+	mi := &file_kwgn_v1_kwgn_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *File) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*File) ProtoMessage() {}
+
+func (x *File) ProtoReflect() protoreflect.Message {
+	mi := &file_kwgn_v1_kwgn_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use File.ProtoReflect.Descriptor instead.
+func (*File) Descriptor() ([]byte, []int) {
+	return file_kwgn_v1_kwgn_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *File) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *File) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+type ExtractRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	File          *File                  `protobuf:"bytes,1,opt,name=file,proto3" json:"file,omitempty"`
+	StatementType string                 `protobuf:"bytes,2,opt,name=statement_type,json=statementType,proto3" json:"statement_type,omitempty"` // Overrides detection, e.g. "MAYBANK_2_CC"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExtractRequest) Reset() {
+	*x = ExtractRequest{}
+	mi := &file_kwgn_v1_kwgn_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExtractRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExtractRequest) ProtoMessage() {}
+
+func (x *ExtractRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kwgn_v1_kwgn_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExtractRequest.ProtoReflect.Descriptor instead.
+func (*ExtractRequest) Descriptor() ([]byte, []int) {
+	return file_kwgn_v1_kwgn_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ExtractRequest) GetFile() *File {
+	if x != nil {
+		return x.File
+	}
+	return nil
+}
+
+func (x *ExtractRequest) GetStatementType() string {
+	if x != nil {
+		return x.StatementType
+	}
+	return ""
+}
+
+type ExtractResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filename      string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"` // <archive>.zip/<member path> for files inside archives
+	Statements    []*Statement           `protobuf:"bytes,2,rep,name=statements,proto3" json:"statements,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"` // Set by ExtractBatch when nothing could be extracted
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExtractResponse) Reset() {
+	*x = ExtractResponse{}
+	mi := &file_kwgn_v1_kwgn_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExtractResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExtractResponse) ProtoMessage() {}
+
+func (x *ExtractResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kwgn_v1_kwgn_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExtractResponse.ProtoReflect.Descriptor instead.
+func (*ExtractResponse) Descriptor() ([]byte, []int) {
+	return file_kwgn_v1_kwgn_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ExtractResponse) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *ExtractResponse) GetStatements() []*Statement {
+	if x != nil {
+		return x.Statements
+	}
+	return nil
+}
+
+func (x *ExtractResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ImportRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	File          *File                  `protobuf:"bytes,1,opt,name=file,proto3" json:"file,omitempty"`
+	StatementType string                 `protobuf:"bytes,2,opt,name=statement_type,json=statementType,proto3" json:"statement_type,omitempty"`
+	Force         bool                   `protobuf:"varint,3,opt,name=force,proto3" json:"force,omitempty"` // Replace statements already imported, losing their tags and data
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportRequest) Reset() {
+	*x = ImportRequest{}
+	mi := &file_kwgn_v1_kwgn_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportRequest) ProtoMessage() {}
+
+func (x *ImportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kwgn_v1_kwgn_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportRequest.ProtoReflect.Descriptor instead.
+func (*ImportRequest) Descriptor() ([]byte, []int) {
+	return file_kwgn_v1_kwgn_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ImportRequest) GetFile() *File {
+	if x != nil {
+		return x.File
+	}
+	return nil
+}
+
+func (x *ImportRequest) GetStatementType() string {
+	if x != nil {
+		return x.StatementType
+	}
+	return ""
+}
+
+func (x *ImportRequest) GetForce() bool {
+	if x != nil {
+		return x.Force
+	}
+	return false
+}
+
+// ImportResponse counts statements, as 'kwgn import' does; a file that was already
+// imported counts as one skipped.
+type ImportResponse struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Files                int32                  `protobuf:"varint,1,opt,name=files,proto3" json:"files,omitempty"`
+	Processed            int32                  `protobuf:"varint,2,opt,name=processed,proto3" json:"processed,omitempty"`
+	Skipped              int32                  `protobuf:"varint,3,opt,name=skipped,proto3" json:"skipped,omitempty"`
+	Failed               int32                  `protobuf:"varint,4,opt,name=failed,proto3" json:"failed,omitempty"`
+	TransactionsInserted int32                  `protobuf:"varint,5,opt,name=transactions_inserted,json=transactionsInserted,proto3" json:"transactions_inserted,omitempty"`
+	TransactionsSkipped  int32                  `protobuf:"varint,6,opt,name=transactions_skipped,json=transactionsSkipped,proto3" json:"transactions_skipped,omitempty"`
+	Errors               []string               `protobuf:"bytes,7,rep,name=errors,proto3" json:"errors,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *ImportResponse) Reset() {
+	*x = ImportResponse{}
+	mi := &file_kwgn_v1_kwgn_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportResponse) ProtoMessage() {}
+
+func (x *ImportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kwgn_v1_kwgn_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportResponse.ProtoReflect.Descriptor instead.
+func (*ImportResponse) Descriptor() ([]byte, []int) {
+	return file_kwgn_v1_kwgn_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ImportResponse) GetFiles() int32 {
+	if x != nil {
+		return x.Files
+	}
+	return 0
+}
+
+func (x *ImportResponse) GetProcessed() int32 {
+	if x != nil {
+		return x.Processed
+	}
+	return 0
+}
+
+func (x *ImportResponse) GetSkipped() int32 {
+	if x != nil {
+		return x.Skipped
+	}
+	return 0
+}
+
+func (x *ImportResponse) GetFailed() int32 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *ImportResponse) GetTransactionsInserted() int32 {
+	if x != nil {
+		return x.TransactionsInserted
+	}
+	return 0
+}
+
+func (x *ImportResponse) GetTransactionsSkipped() int32 {
+	if x != nil {
+		return x.TransactionsSkipped
+	}
+	return 0
+}
+
+func (x *ImportResponse) GetErrors() []string {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+// Statement mirrors common.Statement. Decimals are strings such as "-12.30".
+type Statement struct {
+	state                   protoimpl.MessageState `protogen:"open.v1"`
+	Source                  string                 `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	StartingBalance         string                 `protobuf:"bytes,2,opt,name=starting_balance,json=startingBalance,proto3" json:"starting_balance,omitempty"`
+	EndingBalance           string                 `protobuf:"bytes,3,opt,name=ending_balance,json=endingBalance,proto3" json:"ending_balance,omitempty"`
+	StatementDate           *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=statement_date,json=statementDate,proto3" json:"statement_date,omitempty"`
+	Account                 *Account               `protobuf:"bytes,5,opt,name=account,proto3" json:"account,omitempty"`
+	Transactions            []*Transaction         `protobuf:"bytes,6,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	TotalCredit             string                 `protobuf:"bytes,7,opt,name=total_credit,json=totalCredit,proto3" json:"total_credit,omitempty"`
+	TotalDebit              string                 `protobuf:"bytes,8,opt,name=total_debit,json=totalDebit,proto3" json:"total_debit,omitempty"`
+	Nett                    string                 `protobuf:"bytes,9,opt,name=nett,proto3" json:"nett,omitempty"`
+	TransactionStartDate    *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=transaction_start_date,json=transactionStartDate,proto3" json:"transaction_start_date,omitempty"`
+	TransactionEndDate      *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=transaction_end_date,json=transactionEndDate,proto3" json:"transaction_end_date,omitempty"`
+	CalculatedEndingBalance string                 `protobuf:"bytes,12,opt,name=calculated_ending_balance,json=calculatedEndingBalance,proto3" json:"calculated_ending_balance,omitempty"`
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
+}
+
+func (x *Statement) Reset() {
+	*x = Statement{}
+	mi := &file_kwgn_v1_kwgn_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Statement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Statement) ProtoMessage() {}
+
+func (x *Statement) ProtoReflect() protoreflect.Message {
+	mi := &file_kwgn_v1_kwgn_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Statement.ProtoReflect.Descriptor instead.
+func (*Statement) Descriptor() ([]byte, []int) {
+	return file_kwgn_v1_kwgn_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Statement) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Statement) GetStartingBalance() string {
+	if x != nil {
+		return x.StartingBalance
+	}
+	return ""
+}
+
+func (x *Statement) GetEndingBalance() string {
+	if x != nil {
+		return x.EndingBalance
+	}
+	return ""
+}
+
+func (x *Statement) GetStatementDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StatementDate
+	}
+	return nil
+}
+
+func (x *Statement) GetAccount() *Account {
+	if x != nil {
+		return x.Account
+	}
+	return nil
+}
+
+func (x *Statement) GetTransactions() []*Transaction {
+	if x != nil {
+		return x.Transactions
+	}
+	return nil
+}
+
+func (x *Statement) GetTotalCredit() string {
+	if x != nil {
+		return x.TotalCredit
+	}
+	return ""
+}
+
+func (x *Statement) GetTotalDebit() string {
+	if x != nil {
+		return x.TotalDebit
+	}
+	return ""
+}
+
+func (x *Statement) GetNett() string {
+	if x != nil {
+		return x.Nett
+	}
+	return ""
+}
+
+func (x *Statement) GetTransactionStartDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.TransactionStartDate
+	}
+	return nil
+}
+
+func (x *Statement) GetTransactionEndDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.TransactionEndDate
+	}
+	return nil
+}
+
+func (x *Statement) GetCalculatedEndingBalance() string {
+	if x != nil {
+		return x.CalculatedEndingBalance
+	}
+	return ""
+}
+
+// Account mirrors common.Account.
+type Account struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccountNumber string                 `protobuf:"bytes,1,opt,name=account_number,json=accountNumber,proto3" json:"account_number,omitempty"`
+	AccountName   string                 `protobuf:"bytes,2,opt,name=account_name,json=accountName,proto3" json:"account_name,omitempty"`
+	AccountType   string                 `protobuf:"bytes,3,opt,name=account_type,json=accountType,proto3" json:"account_type,omitempty"`
+	DebitCredit   string                 `protobuf:"bytes,4,opt,name=debit_credit,json=debitCredit,proto3" json:"debit_credit,omitempty"`
+	Reconciliable bool                   `protobuf:"varint,5,opt,name=reconciliable,proto3" json:"reconciliable,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Account) Reset() {
+	*x = Account{}
+	mi := &file_kwgn_v1_kwgn_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Account) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Account) ProtoMessage() {}
+
+func (x *Account) ProtoReflect() protoreflect.Message {
+	mi := &file_kwgn_v1_kwgn_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Account.ProtoReflect.Descriptor instead.
+func (*Account) Descriptor() ([]byte, []int) {
+	return file_kwgn_v1_kwgn_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Account) GetAccountNumber() string {
+	if x != nil {
+		return x.AccountNumber
+	}
+	return ""
+}
+
+func (x *Account) GetAccountName() string {
+	if x != nil {
+		return x.AccountName
+	}
+	return ""
+}
+
+func (x *Account) GetAccountType() string {
+	if x != nil {
+		return x.AccountType
+	}
+	return ""
+}
+
+func (x *Account) GetDebitCredit() string {
+	if x != nil {
+		return x.DebitCredit
+	}
+	return ""
+}
+
+func (x *Account) GetReconciliable() bool {
+	if x != nil {
+		return x.Reconciliable
+	}
+	return false
+}
+
+// Transaction mirrors common.Transaction.
+type Transaction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sequence      int32                  `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Date          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=date,proto3" json:"date,omitempty"`
+	Descriptions  []string               `protobuf:"bytes,3,rep,name=descriptions,proto3" json:"descriptions,omitempty"`
+	Type          string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"` // "debit" or "credit"
+	Amount        string                 `protobuf:"bytes,5,opt,name=amount,proto3" json:"amount,omitempty"`
+	Balance       string                 `protobuf:"bytes,6,opt,name=balance,proto3" json:"balance,omitempty"`
+	Reference     string                 `protobuf:"bytes,7,opt,name=reference,proto3" json:"reference,omitempty"`
+	Tags          []string               `protobuf:"bytes,8,rep,name=tags,proto3" json:"tags,omitempty"`
+	Data          *structpb.Struct       `protobuf:"bytes,9,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Transaction) Reset() {
+	*x = Transaction{}
+	mi := &file_kwgn_v1_kwgn_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transaction) ProtoMessage() {}
+
+func (x *Transaction) ProtoReflect() protoreflect.Message {
+	mi := &file_kwgn_v1_kwgn_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
+func (*Transaction) Descriptor() ([]byte, []int) {
+	return file_kwgn_v1_kwgn_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Transaction) GetSequence() int32 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *Transaction) GetDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Date
+	}
+	return nil
+}
+
+func (x *Transaction) GetDescriptions() []string {
+	if x != nil {
+		return x.Descriptions
+	}
+	return nil
+}
+
+func (x *Transaction) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Transaction) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+func (x *Transaction) GetBalance() string {
+	if x != nil {
+		return x.Balance
+	}
+	return ""
+}
+
+func (x *Transaction) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
+func (x *Transaction) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Transaction) GetData() *structpb.Struct {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+var File_kwgn_v1_kwgn_proto protoreflect.FileDescriptor
+
+const file_kwgn_v1_kwgn_proto_rawDesc = "" +
+	"\n" +
+	"\x12kwgn/v1/kwgn.proto\x12\akwgn.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"4\n" +
+	"\x04File\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\fR\acontent\"Z\n" +
+	"\x0eExtractRequest\x12!\n" +
+	"\x04file\x18\x01 \x01(\v2\r.kwgn.v1.FileR\x04file\x12%\n" +
+	"\x0estatement_type\x18\x02 \x01(\tR\rstatementType\"w\n" +
+	"\x0fExtractResponse\x12\x1a\n" +
+	"\bfilename\x18\x01 \x01(\tR\bfilename\x122\n" +
+	"\n" +
+	"statements\x18\x02 \x03(\v2\x12.kwgn.v1.StatementR\n" +
+	"statements\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"o\n" +
+	"\rImportRequest\x12!\n" +
+	"\x04file\x18\x01 \x01(\v2\r.kwgn.v1.FileR\x04file\x12%\n" +
+	"\x0estatement_type\x18\x02 \x01(\tR\rstatementType\x12\x14\n" +
+	"\x05force\x18\x03 \x01(\bR\x05force\"\xf6\x01\n" +
+	"\x0eImportResponse\x12\x14\n" +
+	"\x05files\x18\x01 \x01(\x05R\x05files\x12\x1c\n" +
+	"\tprocessed\x18\x02 \x01(\x05R\tprocessed\x12\x18\n" +
+	"\askipped\x18\x03 \x01(\x05R\askipped\x12\x16\n" +
+	"\x06failed\x18\x04 \x01(\x05R\x06failed\x123\n" +
+	"\x15transactions_inserted\x18\x05 \x01(\x05R\x14transactionsInserted\x121\n" +
+	"\x14transactions_skipped\x18\x06 \x01(\x05R\x13transactionsSkipped\x12\x16\n" +
+	"\x06errors\x18\a \x03(\tR\x06errors\"\xd2\x04\n" +
+	"\tStatement\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\x12)\n" +
+	"\x10starting_balance\x18\x02 \x01(\tR\x0fstartingBalance\x12%\n" +
+	"\x0eending_balance\x18\x03 \x01(\tR\rendingBalance\x12A\n" +
+	"\x0estatement_date\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\rstatementDate\x12*\n" +
+	"\aaccount\x18\x05 \x01(\v2\x10.kwgn.v1.AccountR\aaccount\x128\n" +
+	"\ftransactions\x18\x06 \x03(\v2\x14.kwgn.v1.TransactionR\ftransactions\x12!\n" +
+	"\ftotal_credit\x18\a \x01(\tR\vtotalCredit\x12\x1f\n" +
+	"\vtotal_debit\x18\b \x01(\tR\n" +
+	"totalDebit\x12\x12\n" +
+	"\x04nett\x18\t \x01(\tR\x04nett\x12P\n" +
+	"\x16transaction_start_date\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\x14transactionStartDate\x12L\n" +
+	"\x14transaction_end_date\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\x12transactionEndDate\x12:\n" +
+	"\x19calculated_ending_balance\x18\f \x01(\tR\x17calculatedEndingBalance\"\xbf\x01\n" +
+	"\aAccount\x12%\n" +
+	"\x0eaccount_number\x18\x01 \x01(\tR\raccountNumber\x12!\n" +
+	"\faccount_name\x18\x02 \x01(\tR\vaccountName\x12!\n" +
+	"\faccount_type\x18\x03 \x01(\tR\vaccountType\x12!\n" +
+	"\fdebit_credit\x18\x04 \x01(\tR\vdebitCredit\x12$\n" +
+	"\rreconciliable\x18\x05 \x01(\bR\rreconciliable\"\xa2\x02\n" +
+	"\vTransaction\x12\x1a\n" +
+	"\bsequence\x18\x01 \x01(\x05R\bsequence\x12.\n" +
+	"\x04date\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04date\x12\"\n" +
+	"\fdescriptions\x18\x03 \x03(\tR\fdescriptions\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\tR\x04type\x12\x16\n" +
+	"\x06amount\x18\x05 \x01(\tR\x06amount\x12\x18\n" +
+	"\abalance\x18\x06 \x01(\tR\abalance\x12\x1c\n" +
+	"\treference\x18\a \x01(\tR\treference\x12\x12\n" +
+	"\x04tags\x18\b \x03(\tR\x04tags\x12+\n" +
+	"\x04data\x18\t \x01(\v2\x17.google.protobuf.StructR\x04data2\xc8\x01\n" +
+	"\x04Kwgn\x12<\n" +
+	"\aExtract\x12\x17.kwgn.v1.ExtractRequest\x1a\x18.kwgn.v1.ExtractResponse\x12E\n" +
+	"\fExtractBatch\x12\x17.kwgn.v1.ExtractRequest\x1a\x18.kwgn.v1.ExtractResponse(\x010\x01\x12;\n" +
+	"\x06Import\x12\x16.kwgn.v1.ImportRequest\x1a\x17.kwgn.v1.ImportResponse(\x01B-Z+github.com/aqlanhadi/kwgn/api/kwgnpb;kwgnpbb\x06proto3"
+
+var (
+	file_kwgn_v1_kwgn_proto_rawDescOnce sync.Once
+	file_kwgn_v1_kwgn_proto_rawDescData []byte
+)
+
+func file_kwgn_v1_kwgn_proto_rawDescGZIP() []byte {
+	file_kwgn_v1_kwgn_proto_rawDescOnce.Do(func() {
+		file_kwgn_v1_kwgn_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_kwgn_v1_kwgn_proto_rawDesc), len(file_kwgn_v1_kwgn_proto_rawDesc)))
+	})
+	return file_kwgn_v1_kwgn_proto_rawDescData
+}
+
+var file_kwgn_v1_kwgn_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_kwgn_v1_kwgn_proto_goTypes = []any{
+	(*File)(nil),                  // 0: kwgn.v1.File
+	(*ExtractRequest)(nil),        // 1: kwgn.v1.ExtractRequest
+	(*ExtractResponse)(nil),       // 2: kwgn.v1.ExtractResponse
+	(*ImportRequest)(nil),         // 3: kwgn.v1.ImportRequest
+	(*ImportResponse)(nil),        // 4: kwgn.v1.ImportResponse
+	(*Statement)(nil),             // 5: kwgn.v1.Statement
+	(*Account)(nil),               // 6: kwgn.v1.Account
+	(*Transaction)(nil),           // 7: kwgn.v1.Transaction
+	(*timestamppb.Timestamp)(nil), // 8: google.protobuf.Timestamp
+	(*structpb.Struct)(nil),       // 9: google.protobuf.Struct
+}
+var file_kwgn_v1_kwgn_proto_depIdxs = []int32{
+	0,  // 0: kwgn.v1.ExtractRequest.file:type_name -> kwgn.v1.File
+	5,  // 1: kwgn.v1.ExtractResponse.statements:type_name -> kwgn.v1.Statement
+	0,  // 2: kwgn.v1.ImportRequest.file:type_name -> kwgn.v1.File
+	8,  // 3: kwgn.v1.Statement.statement_date:type_name -> google.protobuf.Timestamp
+	6,  // 4: kwgn.v1.Statement.account:type_name -> kwgn.v1.Account
+	7,  // 5: kwgn.v1.Statement.transactions:type_name -> kwgn.v1.Transaction
+	8,  // 6: kwgn.v1.Statement.transaction_start_date:type_name -> google.protobuf.Timestamp
+	8,  // 7: kwgn.v1.Statement.transaction_end_date:type_name -> google.protobuf.Timestamp
+	8,  // 8: kwgn.v1.Transaction.date:type_name -> google.protobuf.Timestamp
+	9,  // 9: kwgn.v1.Transaction.data:type_name -> google.protobuf.Struct
+	1,  // 10: kwgn.v1.Kwgn.Extract:input_type -> kwgn.v1.ExtractRequest
+	1,  // 11: kwgn.v1.Kwgn.ExtractBatch:input_type -> kwgn.v1.ExtractRequest
+	3,  // 12: kwgn.v1.Kwgn.Import:input_type -> kwgn.v1.ImportRequest
+	2,  // 13: kwgn.v1.Kwgn.Extract:output_type -> kwgn.v1.ExtractResponse
+	2,  // 14: kwgn.v1.Kwgn.ExtractBatch:output_type -> kwgn.v1.ExtractResponse
+	4,  // 15: kwgn.v1.Kwgn.Import:output_type -> kwgn.v1.ImportResponse
+	13, // [13:16] is the sub-list for method output_type
+	10, // [10:13] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_kwgn_v1_kwgn_proto_init() }
+func file_kwgn_v1_kwgn_proto_init() {
+	if File_kwgn_v1_kwgn_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_kwgn_v1_kwgn_proto_rawDesc), len(file_kwgn_v1_kwgn_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_kwgn_v1_kwgn_proto_goTypes,
+		DependencyIndexes: file_kwgn_v1_kwgn_proto_depIdxs,
+		MessageInfos:      file_kwgn_v1_kwgn_proto_msgTypes,
+	}.Build()
+	File_kwgn_v1_kwgn_proto = out.File
+	file_kwgn_v1_kwgn_proto_goTypes = nil
+	file_kwgn_v1_kwgn_proto_depIdxs = nil
+}
@@ -0,0 +1,207 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: kwgn/v1/kwgn.proto
+
+package kwgnpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Kwgn_Extract_FullMethodName      = "/kwgn.v1.Kwgn/Extract"
+	Kwgn_ExtractBatch_FullMethodName = "/kwgn.v1.Kwgn/ExtractBatch"
+	Kwgn_Import_FullMethodName       = "/kwgn.v1.Kwgn/Import"
+)
+
+// KwgnClient is the client API for Kwgn service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Kwgn extracts statements and imports them into the server's database.
+//
+// When the server requires API keys, send one as "authorization: Bearer <key>"
+// metadata. Extract and ExtractBatch need the extract scope, Import the import scope.
+type KwgnClient interface {
+	// Extract reads every statement in one PDF or CSV file.
+	Extract(ctx context.Context, in *ExtractRequest, opts ...grpc.CallOption) (*ExtractResponse, error)
+	// ExtractBatch extracts files as they are sent, answering each in order. Zip
+	// archives are answered with one response per PDF or CSV file they contain.
+	// Files that cannot be extracted get a response with an error; the stream goes on.
+	ExtractBatch(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ExtractRequest, ExtractResponse], error)
+	// Import stores the statements of every file sent (or of the files in zip
+	// archives) in the database, as 'kwgn import' does, and returns the totals.
+	Import(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ImportRequest, ImportResponse], error)
+}
+
+type kwgnClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewKwgnClient(cc grpc.ClientConnInterface) KwgnClient {
+	return &kwgnClient{cc}
+}
+
+func (c *kwgnClient) Extract(ctx context.Context, in *ExtractRequest, opts ...grpc.CallOption) (*ExtractResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExtractResponse)
+	err := c.cc.Invoke(ctx, Kwgn_Extract_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kwgnClient) ExtractBatch(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ExtractRequest, ExtractResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Kwgn_ServiceDesc.Streams[0], Kwgn_ExtractBatch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExtractRequest, ExtractResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Kwgn_ExtractBatchClient = grpc.BidiStreamingClient[ExtractRequest, ExtractResponse]
+
+func (c *kwgnClient) Import(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ImportRequest, ImportResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Kwgn_ServiceDesc.Streams[1], Kwgn_Import_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ImportRequest, ImportResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Kwgn_ImportClient = grpc.ClientStreamingClient[ImportRequest, ImportResponse]
+
+// KwgnServer is the server API for Kwgn service.
+// All implementations must embed UnimplementedKwgnServer
+// for forward compatibility.
+//
+// Kwgn extracts statements and imports them into the server's database.
+//
+// When the server requires API keys, send one as "authorization: Bearer <key>"
+// metadata. Extract and ExtractBatch need the extract scope, Import the import scope.
+type KwgnServer interface {
+	// Extract reads every statement in one PDF or CSV file.
+	Extract(context.Context, *ExtractRequest) (*ExtractResponse, error)
+	// ExtractBatch extracts files as they are sent, answering each in order. Zip
+	// archives are answered with one response per PDF or CSV file they contain.
+	// Files that cannot be extracted get a response with an error; the stream goes on.
+	ExtractBatch(grpc.BidiStreamingServer[ExtractRequest, ExtractResponse]) error
+	// Import stores the statements of every file sent (or of the files in zip
+	// archives) in the database, as 'kwgn import' does, and returns the totals.
+	Import(grpc.ClientStreamingServer[ImportRequest, ImportResponse]) error
+	mustEmbedUnimplementedKwgnServer()
+}
+
+// UnimplementedKwgnServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedKwgnServer struct{}
+
+func (UnimplementedKwgnServer) Extract(context.Context, *ExtractRequest) (*ExtractResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Extract not implemented")
+}
+func (UnimplementedKwgnServer) ExtractBatch(grpc.BidiStreamingServer[ExtractRequest, ExtractResponse]) error {
+	return status.Error(codes.Unimplemented, "method ExtractBatch not implemented")
+}
+func (UnimplementedKwgnServer) Import(grpc.ClientStreamingServer[ImportRequest, ImportResponse]) error {
+	return status.Error(codes.Unimplemented, "method Import not implemented")
+}
+func (UnimplementedKwgnServer) mustEmbedUnimplementedKwgnServer() {}
+func (UnimplementedKwgnServer) testEmbeddedByValue()              {}
+
+// UnsafeKwgnServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to KwgnServer will
+// result in compilation errors.
+type UnsafeKwgnServer interface {
+	mustEmbedUnimplementedKwgnServer()
+}
+
+func RegisterKwgnServer(s grpc.ServiceRegistrar, srv KwgnServer) {
+	// If the following call panics, it indicates UnimplementedKwgnServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Kwgn_ServiceDesc, srv)
+}
+
+func _Kwgn_Extract_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExtractRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KwgnServer).Extract(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Kwgn_Extract_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KwgnServer).Extract(ctx, req.(*ExtractRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kwgn_ExtractBatch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(KwgnServer).ExtractBatch(&grpc.GenericServerStream[ExtractRequest, ExtractResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Kwgn_ExtractBatchServer = grpc.BidiStreamingServer[ExtractRequest, ExtractResponse]
+
+func _Kwgn_Import_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(KwgnServer).Import(&grpc.GenericServerStream[ImportRequest, ImportResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Kwgn_ImportServer = grpc.ClientStreamingServer[ImportRequest, ImportResponse]
+
+// Kwgn_ServiceDesc is the grpc.ServiceDesc for Kwgn service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Kwgn_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "kwgn.v1.Kwgn",
+	HandlerType: (*KwgnServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Extract",
+			Handler:    _Kwgn_Extract_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExtractBatch",
+			Handler:       _Kwgn_ExtractBatch_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Import",
+			Handler:       _Kwgn_Import_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "kwgn/v1/kwgn.proto",
+}
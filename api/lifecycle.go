@@ -0,0 +1,209 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// CodeTooLarge is returned when a request body exceeds Config.MaxBodyBytes
+const CodeTooLarge = "request_too_large"
+
+// Readiness check outcomes
+const (
+	CheckOK     = "ok"
+	CheckFailed = "failed"
+)
+
+// ReadinessResponse is returned by GET /v1/health/ready
+type ReadinessResponse struct {
+	Status string        `json:"status"` // "ready" or "not_ready"
+	Checks []HealthCheck `json:"checks"`
+}
+
+// HealthCheck is the outcome of one readiness check
+type HealthCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// httpServer builds the http.Server for the configured address, timeouts and TLS
+func (s *Server) httpServer() *http.Server {
+	srv := &http.Server{
+		Addr:              s.config.Port,
+		Handler:           s.mux,
+		ReadHeaderTimeout: s.config.ReadHeaderTimeout,
+		ReadTimeout:       s.config.ReadTimeout,
+		WriteTimeout:      s.config.WriteTimeout,
+		IdleTimeout:       s.config.IdleTimeout,
+		ErrorLog:          log.Default(),
+	}
+	if s.config.TLSCertFile != "" {
+		srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return srv
+}
+
+// Run listens on Config.Port, and on Config.GRPCPort when set, and serves until ctx
+// is done or either server fails; see Serve and ServeGRPC
+func (s *Server) Run(ctx context.Context) error {
+	if (s.config.TLSCertFile == "") != (s.config.TLSKeyFile == "") {
+		return fmt.Errorf("TLS needs both a certificate and a key file")
+	}
+	ln, err := net.Listen("tcp", s.config.Port)
+	if err != nil {
+		return err
+	}
+	if s.config.GRPCPort == "" {
+		return s.Serve(ctx, ln)
+	}
+	gln, err := net.Listen("tcp", s.config.GRPCPort)
+	if err != nil {
+		ln.Close()
+		return err
+	}
+
+	// Both servers shut down together
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	grpcErr := make(chan error, 1)
+	go func() {
+		grpcErr <- s.ServeGRPC(ctx, gln)
+		cancel()
+	}()
+	err = s.Serve(ctx, ln)
+	cancel()
+	return errors.Join(err, <-grpcErr)
+}
+
+// Serve serves HTTP (or HTTPS when a certificate is configured) on ln until ctx is
+// done, then shuts down gracefully: readiness fails for Config.ShutdownDelay while
+// requests are still accepted, so load balancers stop routing here; in-flight
+// requests and jobs are then given Config.ShutdownTimeout to finish. New jobs are
+// refused from the start of shutdown; jobs still queued at the timeout fail.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	srv := s.httpServer()
+	errc := make(chan error, 1)
+	go func() {
+		if s.config.TLSCertFile != "" {
+			log.Printf("%sStarting server on %s (TLS)", s.config.LogPrefix, ln.Addr())
+			errc <- srv.ServeTLS(ln, s.config.TLSCertFile, s.config.TLSKeyFile)
+		} else {
+			log.Printf("%sStarting server on %s", s.config.LogPrefix, ln.Addr())
+			errc <- srv.Serve(ln)
+		}
+	}()
+
+	select {
+	case err := <-errc:
+		s.Close()
+		return err
+	case <-ctx.Done():
+	}
+
+	s.draining.Store(true)
+	s.jobs.stop()
+	if s.config.ShutdownDelay > 0 {
+		log.Printf("%sShutting down in %s", s.config.LogPrefix, s.config.ShutdownDelay)
+		time.Sleep(s.config.ShutdownDelay)
+	}
+	log.Printf("%sShutting down, waiting up to %s for in-flight requests and jobs", s.config.LogPrefix, s.config.ShutdownTimeout)
+
+	// Jobs finish first, while their status can still be fetched
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+	if !s.jobs.drain(shutdownCtx) {
+		log.Printf("%sJobs still queued or running after %s", s.config.LogPrefix, s.config.ShutdownTimeout)
+	}
+	err := srv.Shutdown(shutdownCtx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		srv.Close()
+		err = fmt.Errorf("requests still running after %s were cut off", s.config.ShutdownTimeout)
+	}
+	s.Close()
+	log.Printf("%sServer stopped", s.config.LogPrefix)
+	return err
+}
+
+// limitBody caps the request body at Config.MaxBodyBytes (0 = no limit)
+func (s *Server) limitBody(next http.HandlerFunc) http.HandlerFunc {
+	if s.config.MaxBodyBytes <= 0 {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > s.config.MaxBodyBytes {
+			s.writeTooLarge(w)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, s.config.MaxBodyBytes)
+		next(w, r)
+	}
+}
+
+// parseMultipart parses an upload, writing the error response and returning false
+// when the form is invalid or larger than allowed
+func (s *Server) parseMultipart(w http.ResponseWriter, r *http.Request) bool {
+	err := r.ParseMultipartForm(32 << 20)
+	if err == nil {
+		return true
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		s.writeTooLarge(w)
+		return false
+	}
+	log.Printf("%sError parsing multipart form: %v", s.config.LogPrefix, err)
+	writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Could not parse multipart form: "+err.Error())
+	return false
+}
+
+// writeTooLarge rejects a request over Config.MaxBodyBytes
+func (s *Server) writeTooLarge(w http.ResponseWriter) {
+	writeError(w, http.StatusRequestEntityTooLarge, CodeTooLarge,
+		"Request body is larger than "+strconv.FormatInt(s.config.MaxBodyBytes>>20, 10)+" MB")
+}
+
+// handleLive handles GET /v1/health/live: the process is up and serving
+func (s *Server) handleLive(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, HealthResponse{Status: "ok"})
+}
+
+// handleReady handles GET /v1/health/ready: the server should receive traffic.
+// It fails while shutting down and when the database (if any) is unreachable.
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	resp := ReadinessResponse{Status: "ready"}
+	check := func(name string, err error) {
+		c := HealthCheck{Name: name, Status: CheckOK}
+		if err != nil {
+			c.Status, c.Error = CheckFailed, err.Error()
+			resp.Status = "not_ready"
+		}
+		resp.Checks = append(resp.Checks, c)
+	}
+
+	var err error
+	if s.draining.Load() {
+		err = errors.New("shutting down")
+	}
+	check("server", err)
+
+	if s.config.Store != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		_, _, err := s.config.Store.SchemaVersion(ctx)
+		cancel()
+		check("database", err)
+	}
+
+	status := http.StatusOK
+	if resp.Status != "ready" {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, resp)
+}
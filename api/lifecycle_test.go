@@ -0,0 +1,162 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBodyLimit(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxBodyBytes = 1 << 10
+	server := New(cfg)
+	defer server.Close()
+
+	for _, url := range []string{"/v1/extract", "/v1/extract/batch", "/v1/jobs", "/extract"} {
+		for _, chunked := range []bool{false, true} {
+			body, contentType := multipartFiles(t, "big.csv", strings.Repeat("x", 4<<10))
+			req := httptest.NewRequest(http.MethodPost, url, body)
+			req.Header.Set("Content-Type", contentType)
+			if chunked {
+				req.ContentLength = -1 // Only caught while reading the body
+			}
+			w := httptest.NewRecorder()
+			server.Handler().ServeHTTP(w, req)
+
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("%s (chunked %v): expected status 413, got %d", url, chunked, w.Code)
+				continue
+			}
+			if url == "/extract" {
+				continue // Unversioned endpoint, plain text errors
+			}
+			var resp ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || resp.Error.Code != CodeTooLarge {
+				t.Errorf("%s: expected error code %s, got %+v (%v)", url, CodeTooLarge, resp.Error, err)
+			}
+		}
+	}
+
+	// Small uploads are unaffected
+	body, contentType := multipartFiles(t, "tng.csv", tngCSV)
+	req := httptest.NewRequest(http.MethodPost, "/v1/extract", body)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 under the limit, got %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Store = newTestStore(t)
+	server := New(cfg)
+	defer server.Close()
+
+	ready := func() (int, ReadinessResponse) {
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/health/ready", nil))
+		var resp ReadinessResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode readiness: %v", err)
+		}
+		return w.Code, resp
+	}
+
+	code, resp := ready()
+	if code != http.StatusOK || resp.Status != "ready" || len(resp.Checks) != 2 {
+		t.Fatalf("Expected ready with server and database checks, got %d %+v", code, resp)
+	}
+
+	server.draining.Store(true)
+	code, resp = ready()
+	if code != http.StatusServiceUnavailable || resp.Status != "not_ready" || resp.Checks[0].Status != CheckFailed {
+		t.Errorf("Expected not ready while shutting down, got %d %+v", code, resp)
+	}
+
+	// Liveness is unaffected
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/health/live", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected live while shutting down, got %d", w.Code)
+	}
+}
+
+func TestServe_GracefulShutdown(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShutdownDelay = 50 * time.Millisecond
+	server := New(cfg)
+
+	// A request that is still running when shutdown starts
+	started, release := make(chan struct{}), make(chan struct{})
+	server.mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := "http://" + ln.Addr().String()
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(ctx, ln) }()
+
+	slow := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(base + "/slow")
+		if err != nil {
+			slow <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		slow <- string(body)
+	}()
+	<-started
+	cancel()
+
+	// During the delay, requests are still served but readiness fails
+	time.Sleep(10 * time.Millisecond)
+	resp, err := http.Get(base + "/v1/health/ready")
+	if err != nil {
+		t.Fatalf("Expected the server to accept requests during the shutdown delay: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected readiness to fail during shutdown, got %d", resp.StatusCode)
+	}
+
+	close(release)
+	if body := <-slow; body != "done" {
+		t.Errorf("Expected the in-flight request to finish, got %q", body)
+	}
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("Serve returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not return after shutdown")
+	}
+}
+
+func TestRun_TLSNeedsCertAndKey(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.TLSCertFile = "cert.pem"
+	server := New(cfg)
+	defer server.Close()
+
+	if err := server.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "key") {
+		t.Errorf("Expected an error about the missing key, got %v", err)
+	}
+}
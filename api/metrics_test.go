@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aqlanhadi/kwgn/extractor"
+	"github.com/aqlanhadi/kwgn/integrations/metrics"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	m := metrics.New()
+	extractor.OnExtract = m.ObserveExtraction
+	defer func() { extractor.OnExtract = nil }()
+
+	cfg := DefaultConfig()
+	cfg.Metrics = m
+	server := New(cfg)
+	defer server.Close()
+
+	body, contentType := multipartFiles(t, "tng.csv", tngCSV)
+	req := httptest.NewRequest(http.MethodPost, "/v1/extract", body)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	for _, want := range []string{
+		`kwgn_extractions_total{outcome="ok",statement_type="TNG_CSV_EXPORT"} 1`,
+		`kwgn_upload_size_bytes_count 1`,
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("Expected %s in:\n%s", want, w.Body)
+		}
+	}
+}
+
+func TestMetricsEndpoint_Public(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Metrics = metrics.New()
+	cfg.RequireAuth = true
+	server := New(cfg)
+	defer server.Close()
+
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected metrics without an API key, got %d", w.Code)
+	}
+}
+
+func TestMetricsEndpoint_Disabled(t *testing.T) {
+	server := New(DefaultConfig())
+	defer server.Close()
+
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 without metrics, got %d", w.Code)
+	}
+}
@@ -0,0 +1,480 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aqlanhadi/kwgn/integrations/store"
+)
+
+// spec is the decoded OpenAPI document
+type spec map[string]interface{}
+
+func loadSpec(t *testing.T) spec {
+	t.Helper()
+	var s spec
+	if err := json.Unmarshal(openAPISpec, &s); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+	return s
+}
+
+// resolve follows a local $ref such as #/components/schemas/Job
+func (s spec) resolve(ref string) (map[string]interface{}, error) {
+	var node interface{} = map[string]interface{}(s)
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unresolvable $ref %s", ref)
+		}
+		if node, ok = m[part]; !ok {
+			return nil, fmt.Errorf("unresolvable $ref %s", ref)
+		}
+	}
+	m, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("$ref %s is not an object", ref)
+	}
+	return m, nil
+}
+
+// deref returns the object itself, or what its $ref points to
+func (s spec) deref(obj map[string]interface{}) (map[string]interface{}, error) {
+	for {
+		ref, ok := obj["$ref"].(string)
+		if !ok {
+			return obj, nil
+		}
+		var err error
+		if obj, err = s.resolve(ref); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// operation returns the spec of method on path
+func (s spec) operation(path, method string) map[string]interface{} {
+	paths, _ := s["paths"].(map[string]interface{})
+	item, _ := paths[path].(map[string]interface{})
+	op, _ := item[strings.ToLower(method)].(map[string]interface{})
+	return op
+}
+
+// responseSchema returns the JSON schema documented for a response status
+func (s spec) responseSchema(path, method string, status int) (map[string]interface{}, error) {
+	op := s.operation(path, method)
+	if op == nil {
+		return nil, fmt.Errorf("%s %s is not in the spec", method, path)
+	}
+	responses, _ := op["responses"].(map[string]interface{})
+	response, ok := responses[strconv.Itoa(status)].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s %s does not document status %d", method, path, status)
+	}
+	response, err := s.deref(response)
+	if err != nil {
+		return nil, err
+	}
+	content, _ := response["content"].(map[string]interface{})
+	media, ok := content["application/json"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s %s status %d has no JSON content", method, path, status)
+	}
+	schema, _ := media["schema"].(map[string]interface{})
+	return s.deref(schema)
+}
+
+// validate checks a decoded JSON value against a schema. Objects may only hold
+// documented properties, so fields added to a response type must be added to the spec.
+func (s spec) validate(schema map[string]interface{}, value interface{}, at string) error {
+	schema, err := s.deref(schema)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		if schema["nullable"] == true {
+			return nil
+		}
+		return fmt.Errorf("%s: null is not allowed", at)
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			found = found || e == value
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", at, value, enum)
+		}
+	}
+
+	switch schema["type"] {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected an object, got %T", at, value)
+		}
+		required, _ := schema["required"].([]interface{})
+		for _, name := range required {
+			if _, ok := obj[name.(string)]; !ok {
+				return fmt.Errorf("%s: missing required property %s", at, name)
+			}
+		}
+		properties, hasProperties := schema["properties"].(map[string]interface{})
+		for name, v := range obj {
+			prop, ok := properties[name].(map[string]interface{})
+			if !ok {
+				if hasProperties && schema["additionalProperties"] != true {
+					return fmt.Errorf("%s: property %s is not in the spec", at, name)
+				}
+				continue
+			}
+			if err := s.validate(prop, v, at+"."+name); err != nil {
+				return err
+			}
+		}
+	case "array":
+		arr, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected an array, got %T", at, value)
+		}
+		items, _ := schema["items"].(map[string]interface{})
+		for i, v := range arr {
+			if err := s.validate(items, v, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s: expected a string, got %T", at, value)
+		}
+	case "integer":
+		n, ok := value.(float64)
+		if !ok || n != float64(int64(n)) {
+			return fmt.Errorf("%s: expected an integer, got %v", at, value)
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s: expected a number, got %T", at, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected a boolean, got %T", at, value)
+		}
+	}
+	return nil
+}
+
+func TestOpenAPI_Served(t *testing.T) {
+	server := New(DefaultConfig())
+	defer server.Close()
+
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Unexpected response: %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	var s spec
+	if err := json.NewDecoder(w.Body).Decode(&s); err != nil || s["openapi"] == nil {
+		t.Fatalf("Expected an OpenAPI document: %v", err)
+	}
+}
+
+func TestOpenAPI_RoutesMatchSpec(t *testing.T) {
+	s := loadSpec(t)
+	server := New(DefaultConfig())
+	defer server.Close()
+
+	routes := make(map[string]bool)
+	for _, rt := range server.v1Routes() {
+		key := rt.method + " " + rt.path
+		routes[key] = true
+		op := s.operation(rt.path, rt.method)
+		if op == nil {
+			t.Errorf("%s is served but not in openapi.json", key)
+			continue
+		}
+
+		// Public operations opt out of the global security requirement;
+		// the others name the scope they need and document being rejected
+		security, public := op["security"].([]interface{})
+		if rt.scope == "" && (!public || len(security) != 0) {
+			t.Errorf("%s is public but openapi.json requires a key", key)
+		}
+		if rt.scope != "" {
+			if public || op["x-scope"] != rt.scope {
+				t.Errorf("%s requires scope %s, openapi.json has x-scope %v", key, rt.scope, op["x-scope"])
+			}
+			responses, _ := op["responses"].(map[string]interface{})
+			for _, status := range []string{"401", "403", "429"} {
+				if responses[status] == nil {
+					t.Errorf("%s does not document status %s", key, status)
+				}
+			}
+		}
+	}
+
+	paths, _ := s["paths"].(map[string]interface{})
+	var documented []string
+	for path, item := range paths {
+		for method := range item.(map[string]interface{}) {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(documented)
+	for _, key := range documented {
+		if !routes[key] {
+			t.Errorf("%s is in openapi.json but not served", key)
+		}
+	}
+}
+
+func TestOpenAPI_RefsResolve(t *testing.T) {
+	s := loadSpec(t)
+	var walk func(node interface{})
+	walk = func(node interface{}) {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			if ref, ok := n["$ref"].(string); ok {
+				if _, err := s.resolve(ref); err != nil {
+					t.Error(err)
+				}
+			}
+			for _, v := range n {
+				walk(v)
+			}
+		case []interface{}:
+			for _, v := range n {
+				walk(v)
+			}
+		}
+	}
+	walk(map[string]interface{}(s))
+}
+
+// TestOpenAPI_ResponsesMatchSpec sends requests to every endpoint and checks the
+// status is documented and the body matches its schema
+func TestOpenAPI_ResponsesMatchSpec(t *testing.T) {
+	s := loadSpec(t)
+
+	cfg := DefaultConfig()
+	cfg.Store = newTestStore(t)
+	server := New(cfg)
+	defer server.Close()
+	noStore := New(DefaultConfig())
+	defer noStore.Close()
+	small := New(Config{MaxBodyBytes: 1 << 10, JobWorkers: 1, JobQueueSize: 1})
+	defer small.Close()
+	draining := New(DefaultConfig())
+	defer draining.Close()
+	draining.draining.Store(true)
+	large := []string{"big.csv", strings.Repeat("x", 4<<10)}
+
+	// Queue a job up front so its result can be checked
+	body, contentType := multipartFiles(t, "tng.csv", tngCSV, "broken.pdf", "not a valid pdf")
+	req := httptest.NewRequest(http.MethodPost, "/v1/jobs", body)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	var queued Job
+	json.NewDecoder(w.Body).Decode(&queued)
+	waitForJob(t, server, queued.ID)
+
+	accounts, err := cfg.Store.ListAccounts(context.Background(), store.AccountQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactions, err := cfg.Store.QueryTransactions(context.Background(), store.TransactionQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, transaction := "/v1/accounts/"+accounts[0].ID, "/v1/transactions/"+transactions[0].ID
+
+	tests := []struct {
+		name   string
+		server *Server
+		method string
+		path   string // Spec operation ("METHOD /path") the response is checked against
+		url    string
+		files  []string
+		status int
+	}{
+		{"health", server, http.MethodGet, "GET /v1/health", "/v1/health", nil, http.StatusOK},
+		{"liveness", server, http.MethodGet, "GET /v1/health/live", "/v1/health/live", nil, http.StatusOK},
+		{"readiness", server, http.MethodGet, "GET /v1/health/ready", "/v1/health/ready", nil, http.StatusOK},
+		{"readiness draining", draining, http.MethodGet, "GET /v1/health/ready", "/v1/health/ready", nil, http.StatusServiceUnavailable},
+		{"extract", server, http.MethodPost, "POST /v1/extract", "/v1/extract", []string{"tng.csv", tngCSV}, http.StatusOK},
+		{"extract statement only", server, http.MethodPost, "POST /v1/extract", "/v1/extract?statement_only=true", []string{"tng.csv", tngCSV}, http.StatusOK},
+		{"extract transactions only", server, http.MethodPost, "POST /v1/extract", "/v1/extract?transaction_only=true", []string{"tng.csv", tngCSV}, http.StatusOK},
+		{"extract invalid file", server, http.MethodPost, "POST /v1/extract", "/v1/extract", []string{"broken.pdf", "not a valid pdf"}, http.StatusUnprocessableEntity},
+		{"extract no file", server, http.MethodPost, "POST /v1/extract", "/v1/extract", []string{}, http.StatusBadRequest},
+		{"extract too large", small, http.MethodPost, "POST /v1/extract", "/v1/extract", large, http.StatusRequestEntityTooLarge},
+		{"batch too large", small, http.MethodPost, "POST /v1/extract/batch", "/v1/extract/batch", large, http.StatusRequestEntityTooLarge},
+		{"job too large", small, http.MethodPost, "POST /v1/jobs", "/v1/jobs", large, http.StatusRequestEntityTooLarge},
+		{"extract wrong method", server, http.MethodGet, "POST /v1/extract", "/v1/extract", nil, http.StatusMethodNotAllowed},
+		{"batch", server, http.MethodPost, "POST /v1/extract/batch", "/v1/extract/batch", []string{"tng.csv", tngCSV, "broken.zip", "x"}, http.StatusOK},
+		{"batch no file", server, http.MethodPost, "POST /v1/extract/batch", "/v1/extract/batch", []string{}, http.StatusBadRequest},
+		{"job submit", server, http.MethodPost, "POST /v1/jobs", "/v1/jobs", []string{"tng.csv", tngCSV}, http.StatusAccepted},
+		{"job", server, http.MethodGet, "GET /v1/jobs/{id}", "/v1/jobs/" + queued.ID, nil, http.StatusOK},
+		{"job unknown", server, http.MethodGet, "GET /v1/jobs/{id}", "/v1/jobs/unknown", nil, http.StatusNotFound},
+		{"import", server, http.MethodPost, "POST /v1/import", "/v1/import", []string{"tng.csv", tngCSV, "broken.pdf", "x"}, http.StatusOK},
+		{"import no file", server, http.MethodPost, "POST /v1/import", "/v1/import", []string{}, http.StatusBadRequest},
+		{"import no database", noStore, http.MethodPost, "POST /v1/import", "/v1/import", []string{"tng.csv", tngCSV}, http.StatusServiceUnavailable},
+		{"accounts", server, http.MethodGet, "GET /v1/accounts", "/v1/accounts", nil, http.StatusOK},
+		{"accounts bad page", server, http.MethodGet, "GET /v1/accounts", "/v1/accounts?limit=0", nil, http.StatusBadRequest},
+		{"accounts no database", noStore, http.MethodGet, "GET /v1/accounts", "/v1/accounts", nil, http.StatusServiceUnavailable},
+		{"account", server, http.MethodGet, "GET /v1/accounts/{id}", account, nil, http.StatusOK},
+		{"account unknown", server, http.MethodGet, "GET /v1/accounts/{id}", "/v1/accounts/unknown", nil, http.StatusNotFound},
+		{"account statements", server, http.MethodGet, "GET /v1/accounts/{id}/statements", account + "/statements", nil, http.StatusOK},
+		{"account statements unknown", server, http.MethodGet, "GET /v1/accounts/{id}/statements", "/v1/accounts/unknown/statements", nil, http.StatusNotFound},
+		{"transactions", server, http.MethodGet, "GET /v1/transactions", "/v1/transactions?limit=1", nil, http.StatusOK},
+		{"transactions bad query", server, http.MethodGet, "GET /v1/transactions", "/v1/transactions?sort=balance", nil, http.StatusBadRequest},
+		{"transaction", server, http.MethodGet, "GET /v1/transactions/{id}", transaction, nil, http.StatusOK},
+		{"transaction unknown", server, http.MethodGet, "GET /v1/transactions/{id}", "/v1/transactions/unknown", nil, http.StatusNotFound},
+		{"transaction wrong method", server, http.MethodDelete, "GET /v1/transactions/{id}", transaction, nil, http.StatusMethodNotAllowed},
+		{"monthly report", server, http.MethodGet, "GET /v1/reports/monthly", "/v1/reports/monthly?by=tag", nil, http.StatusOK},
+		{"monthly report bad query", server, http.MethodGet, "GET /v1/reports/monthly", "/v1/reports/monthly?by=merchant", nil, http.StatusBadRequest},
+		{"monthly report no database", noStore, http.MethodGet, "GET /v1/reports/monthly", "/v1/reports/monthly", nil, http.StatusServiceUnavailable},
+		{"net worth", server, http.MethodGet, "GET /v1/networth", "/v1/networth?interval=month", nil, http.StatusOK},
+		{"net worth bad query", server, http.MethodGet, "GET /v1/networth", "/v1/networth?from=yesterday", nil, http.StatusBadRequest},
+		{"net worth no database", noStore, http.MethodGet, "GET /v1/networth", "/v1/networth", nil, http.StatusServiceUnavailable},
+		{"openapi", server, http.MethodGet, "GET /openapi.json", "/openapi.json", nil, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, nil)
+			if tt.files != nil {
+				body, contentType := multipartFiles(t, tt.files...)
+				req = httptest.NewRequest(tt.method, tt.url, body)
+				req.Header.Set("Content-Type", contentType)
+			}
+			w := httptest.NewRecorder()
+			tt.server.Handler().ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Fatalf("Expected a JSON response, got %s", ct)
+			}
+			method, path, _ := strings.Cut(tt.path, " ")
+			schema, err := s.responseSchema(path, method, w.Code)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var body interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Invalid JSON: %v", err)
+			}
+			if err := s.validate(schema, body, "body"); err != nil {
+				t.Errorf("Response does not match the spec: %v\n%s", err, w.Body)
+			}
+		})
+	}
+}
+
+// TestOpenAPI_EditsMatchSpec checks the responses of the PATCH endpoints, which
+// take a JSON body
+func TestOpenAPI_EditsMatchSpec(t *testing.T) {
+	s := loadSpec(t)
+
+	cfg := DefaultConfig()
+	cfg.Store = newTestStore(t)
+	server := New(cfg)
+	defer server.Close()
+	small := New(Config{Store: cfg.Store, MaxBodyBytes: 1 << 10, JobWorkers: 1, JobQueueSize: 1})
+	defer small.Close()
+
+	accounts, err := cfg.Store.ListAccounts(context.Background(), store.AccountQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactions, err := cfg.Store.QueryTransactions(context.Background(), store.TransactionQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, transaction := "/v1/accounts/"+accounts[0].ID, "/v1/transactions/"+transactions[0].ID
+	large := `{"data": {"note": "` + strings.Repeat("x", 4<<10) + `"}}`
+
+	tests := []struct {
+		name   string
+		server *Server
+		path   string
+		url    string
+		body   string
+		status int
+	}{
+		{"account", server, "/v1/accounts/{id}", account, `{"account_type": "SAVINGS"}`, http.StatusOK},
+		{"account empty", server, "/v1/accounts/{id}", account, `{}`, http.StatusBadRequest},
+		{"account unknown", server, "/v1/accounts/{id}", "/v1/accounts/unknown", `{"account_type": "SAVINGS"}`, http.StatusNotFound},
+		{"transaction", server, "/v1/transactions/{id}", transaction, `{"tags": ["a"], "data": {"n": 1}}`, http.StatusOK},
+		{"transaction unknown field", server, "/v1/transactions/{id}", transaction, `{"note": "x"}`, http.StatusBadRequest},
+		{"transaction unknown", server, "/v1/transactions/{id}", "/v1/transactions/unknown", `{"tags": []}`, http.StatusNotFound},
+		{"transaction too large", small, "/v1/transactions/{id}", transaction, large, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodPatch, tt.url, strings.NewReader(tt.body)))
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body)
+			}
+			schema, err := s.responseSchema(tt.path, http.MethodPatch, w.Code)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var body interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Invalid JSON: %v", err)
+			}
+			if err := s.validate(schema, body, "body"); err != nil {
+				t.Errorf("Response does not match the spec: %v\n%s", err, w.Body)
+			}
+		})
+	}
+}
+
+func TestV1_ErrorEnvelope(t *testing.T) {
+	server := New(DefaultConfig())
+	defer server.Close()
+
+	tests := []struct {
+		method string
+		url    string
+		status int
+		code   string
+	}{
+		{http.MethodGet, "/v1/unknown", http.StatusNotFound, CodeNotFound},
+		{http.MethodDelete, "/v1/jobs/abc", http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+		{http.MethodGet, "/v1/networth", http.StatusServiceUnavailable, CodeNoDatabase},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, httptest.NewRequest(tt.method, tt.url, nil))
+		if w.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.url, tt.status, w.Code)
+			continue
+		}
+		var resp ErrorResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Errorf("%s %s: expected an error envelope: %v", tt.method, tt.url, err)
+			continue
+		}
+		if resp.Error.Code != tt.code || resp.Error.Message == "" {
+			t.Errorf("%s %s: unexpected error %+v", tt.method, tt.url, resp.Error)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/v1/jobs/abc", nil))
+	if allow := w.Header().Get("Allow"); allow != http.MethodGet {
+		t.Errorf("Expected Allow: GET, got %q", allow)
+	}
+}
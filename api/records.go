@@ -0,0 +1,371 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aqlanhadi/kwgn/integrations/store"
+	"github.com/shopspring/decimal"
+)
+
+// Page sizes of the list endpoints
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+// Page describes the slice of results returned by a list endpoint. NextOffset is
+// the offset of the next page, or null on the last one.
+type Page struct {
+	Limit      int  `json:"limit"`
+	Offset     int  `json:"offset"`
+	NextOffset *int `json:"next_offset"`
+}
+
+// AccountList is returned by GET /v1/accounts
+type AccountList struct {
+	Accounts []store.AccountRecord `json:"accounts"`
+	Page
+}
+
+// StatementList is returned by GET /v1/accounts/{id}/statements
+type StatementList struct {
+	Statements []store.StatementRecord `json:"statements"`
+	Page
+}
+
+// TransactionList is returned by GET /v1/transactions
+type TransactionList struct {
+	Transactions []store.TransactionRecord `json:"transactions"`
+	Page
+}
+
+// AccountPatch is the body of PATCH /v1/accounts/{id}
+type AccountPatch struct {
+	AccountType *string `json:"account_type"`
+}
+
+// TransactionPatch is the body of PATCH /v1/transactions/{id}. Tags and data
+// replace the stored values; fields left out are unchanged.
+type TransactionPatch struct {
+	Tags *[]string      `json:"tags"`
+	Data map[string]any `json:"data"`
+}
+
+// queryPage parses the limit and offset query params
+func queryPage(r *http.Request) (limit, offset int, err error) {
+	q := r.URL.Query()
+	limit = defaultPageLimit
+	if value := q.Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > maxPageLimit {
+			return 0, 0, fmt.Errorf("limit must be a number from 1 to %d, got '%s'", maxPageLimit, value)
+		}
+	}
+	if value := q.Get("offset"); value != "" {
+		if offset, err = strconv.Atoi(value); err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("offset must not be a negative number, got '%s'", value)
+		}
+	}
+	return limit, offset, nil
+}
+
+// newPage trims items, fetched from offset with one more than limit requested,
+// to the page and tells whether another page follows
+func newPage[T any](items []T, limit, offset int) ([]T, Page) {
+	page := Page{Limit: limit, Offset: offset}
+	if len(items) > limit {
+		next := offset + limit
+		page.NextOffset = &next
+		items = items[:limit]
+	}
+	if items == nil {
+		items = []T{} // An empty page is [], not null
+	}
+	return items, page
+}
+
+// queryDecimal parses an optional decimal query parameter
+func queryDecimal(r *http.Request, name string) (*decimal.Decimal, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a number, got '%s'", name, value)
+	}
+	return &d, nil
+}
+
+// decodeJSON decodes a JSON request body into v, writing the error response and
+// returning false when it is invalid or too large
+func (s *Server) decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err == nil {
+		return true
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		s.writeTooLarge(w)
+		return false
+	}
+	writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Could not parse JSON body: "+err.Error())
+	return false
+}
+
+// findAccount returns the account with the id in the request path, writing the
+// error response and returning nil when there is none
+func (s *Server) findAccount(w http.ResponseWriter, r *http.Request) *store.AccountRecord {
+	id := r.PathValue("id")
+	account, err := s.config.Store.GetAccount(r.Context(), id)
+	if err != nil {
+		log.Printf("%sError getting account %s: %v", s.config.LogPrefix, id, err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Could not get account: "+err.Error())
+		return nil
+	}
+	if account == nil {
+		writeError(w, http.StatusNotFound, CodeNotFound, "No account with id "+id)
+	}
+	return account
+}
+
+// findTransaction returns the transaction with the id in the request path, writing
+// the error response and returning nil when there is none
+func (s *Server) findTransaction(w http.ResponseWriter, r *http.Request) *store.TransactionRecord {
+	id := r.PathValue("id")
+	records, err := s.config.Store.QueryTransactions(r.Context(), store.TransactionQuery{ID: id})
+	if err != nil {
+		log.Printf("%sError querying transactions: %v", s.config.LogPrefix, err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Could not query transactions: "+err.Error())
+		return nil
+	}
+	if len(records) == 0 {
+		writeError(w, http.StatusNotFound, CodeNotFound, "No transaction with id "+id)
+		return nil
+	}
+	return &records[0]
+}
+
+// handleAccounts handles GET /v1/accounts. Query params: account (number, or part
+// of the name), type, limit, offset.
+func (s *Server) handleAccounts(w http.ResponseWriter, r *http.Request) {
+	if !s.requireStore(w) {
+		return
+	}
+	limit, offset, err := queryPage(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	// One more row than the page tells whether there is a next page
+	q := r.URL.Query()
+	query := store.AccountQuery{Account: q.Get("account"), Type: q.Get("type"), Limit: limit + 1, Offset: offset}
+	accounts, err := s.config.Store.ListAccounts(r.Context(), query)
+	if err != nil {
+		log.Printf("%sError listing accounts: %v", s.config.LogPrefix, err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Could not list accounts: "+err.Error())
+		return
+	}
+
+	var resp AccountList
+	resp.Accounts, resp.Page = newPage(accounts, limit, offset)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleAccount handles GET /v1/accounts/{id}
+func (s *Server) handleAccount(w http.ResponseWriter, r *http.Request) {
+	if !s.requireStore(w) {
+		return
+	}
+	if account := s.findAccount(w, r); account != nil {
+		writeJSON(w, http.StatusOK, account)
+	}
+}
+
+// handleEditAccount handles PATCH /v1/accounts/{id}
+func (s *Server) handleEditAccount(w http.ResponseWriter, r *http.Request) {
+	if !s.requireStore(w) {
+		return
+	}
+	var patch AccountPatch
+	if !s.decodeJSON(w, r, &patch) {
+		return
+	}
+	if patch.AccountType == nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Nothing to change, set account_type")
+		return
+	}
+
+	id := r.PathValue("id")
+	found, err := s.config.Store.EditAccount(r.Context(), id, store.AccountEdit{AccountType: patch.AccountType})
+	if err != nil {
+		log.Printf("%sError editing account %s: %v", s.config.LogPrefix, id, err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Could not edit account: "+err.Error())
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, CodeNotFound, "No account with id "+id)
+		return
+	}
+	log.Printf("%sEdited account %s from %s", s.config.LogPrefix, id, r.RemoteAddr)
+	s.handleAccount(w, r)
+}
+
+// handleAccountStatements handles GET /v1/accounts/{id}/statements.
+// Query params: from, to (statement dates), desc, limit, offset.
+func (s *Server) handleAccountStatements(w http.ResponseWriter, r *http.Request) {
+	if !s.requireStore(w) {
+		return
+	}
+	limit, offset, err := queryPage(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	from, err := queryDate(r, "from")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	to, err := queryDate(r, "to")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	account := s.findAccount(w, r)
+	if account == nil {
+		return
+	}
+	statements, err := s.config.Store.QueryStatements(r.Context(), store.StatementQuery{
+		AccountID: account.ID,
+		From:      from,
+		To:        to,
+		Desc:      r.URL.Query().Get("desc") == "true",
+		Limit:     limit + 1, // One more row than the page tells whether there is a next page
+		Offset:    offset,
+	})
+	if err != nil {
+		log.Printf("%sError listing statements: %v", s.config.LogPrefix, err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Could not list statements: "+err.Error())
+		return
+	}
+
+	var resp StatementList
+	resp.Statements, resp.Page = newPage(statements, limit, offset)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleTransactions handles GET /v1/transactions, the API form of 'kwgn query'.
+// Query params: account, from, to, type, min_amount, max_amount, description,
+// tag (repeatable), statement_id, sort, desc, limit, offset.
+func (s *Server) handleTransactions(w http.ResponseWriter, r *http.Request) {
+	if !s.requireStore(w) {
+		return
+	}
+
+	q := r.URL.Query()
+	query := store.TransactionQuery{
+		Account:     q.Get("account"),
+		Type:        q.Get("type"),
+		Description: q.Get("description"),
+		Tags:        q["tag"],
+		StatementID: q.Get("statement_id"),
+		Sort:        q.Get("sort"),
+		Desc:        q.Get("desc") == "true",
+	}
+	var err error
+	if query.Limit, query.Offset, err = queryPage(r); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	if query.From, err = queryDate(r, "from"); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	if query.To, err = queryDate(r, "to"); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	if query.MinAmount, err = queryDecimal(r, "min_amount"); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	if query.MaxAmount, err = queryDecimal(r, "max_amount"); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	if err := query.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	// One more row than the page tells whether there is a next page
+	limit := query.Limit
+	query.Limit++
+	records, err := s.config.Store.QueryTransactions(r.Context(), query)
+	if err != nil {
+		log.Printf("%sError querying transactions: %v", s.config.LogPrefix, err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Could not query transactions: "+err.Error())
+		return
+	}
+
+	var resp TransactionList
+	resp.Transactions, resp.Page = newPage(records, limit, query.Offset)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleTransaction handles GET /v1/transactions/{id}
+func (s *Server) handleTransaction(w http.ResponseWriter, r *http.Request) {
+	if !s.requireStore(w) {
+		return
+	}
+	if record := s.findTransaction(w, r); record != nil {
+		writeJSON(w, http.StatusOK, record)
+	}
+}
+
+// handleEditTransaction handles PATCH /v1/transactions/{id}
+func (s *Server) handleEditTransaction(w http.ResponseWriter, r *http.Request) {
+	if !s.requireStore(w) {
+		return
+	}
+	var patch TransactionPatch
+	if !s.decodeJSON(w, r, &patch) {
+		return
+	}
+	if patch.Tags == nil && patch.Data == nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Nothing to change, set tags or data")
+		return
+	}
+	if patch.Tags != nil {
+		for _, tag := range *patch.Tags {
+			if strings.TrimSpace(tag) == "" {
+				writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Tags must not be empty")
+				return
+			}
+		}
+	}
+
+	id := r.PathValue("id")
+	found, err := s.config.Store.EditTransaction(r.Context(), id, store.TransactionEdit{Tags: patch.Tags, Data: patch.Data})
+	if err != nil {
+		log.Printf("%sError editing transaction %s: %v", s.config.LogPrefix, id, err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Could not edit transaction: "+err.Error())
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, CodeNotFound, "No transaction with id "+id)
+		return
+	}
+	log.Printf("%sEdited transaction %s from %s", s.config.LogPrefix, id, r.RemoteAddr)
+	s.handleTransaction(w, r)
+}
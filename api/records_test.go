@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aqlanhadi/kwgn/integrations/store"
+)
+
+// getJSON sends a request with an optional JSON body and decodes the response into v
+func getJSON(t *testing.T, server *Server, method, url, body string, v any) int {
+	t.Helper()
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	if err := json.NewDecoder(w.Body).Decode(v); err != nil {
+		t.Fatalf("%s %s: failed to decode response: %v", method, url, err)
+	}
+	return w.Code
+}
+
+func TestAccounts(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Store = newTestStore(t)
+	server := New(cfg)
+	defer server.Close()
+
+	var list AccountList
+	if code := getJSON(t, server, http.MethodGet, "/v1/accounts?account=savings", "", &list); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if len(list.Accounts) != 1 || list.NextOffset != nil {
+		t.Fatalf("Unexpected accounts: %+v", list)
+	}
+	account := list.Accounts[0]
+	if account.StatementCount != 1 || account.LatestBalance == nil || account.LatestBalance.String() != "4880" {
+		t.Errorf("Expected the latest balance of the account, got %+v", account)
+	}
+
+	getJSON(t, server, http.MethodGet, "/v1/accounts?type=credit_card", "", &list)
+	if len(list.Accounts) != 0 || list.Accounts == nil {
+		t.Errorf("Expected an empty list for an unknown type, got %+v", list.Accounts)
+	}
+
+	var got store.AccountRecord
+	if code := getJSON(t, server, http.MethodGet, "/v1/accounts/"+account.ID, "", &got); code != http.StatusOK || got.ID != account.ID {
+		t.Errorf("Expected the account, got %d %+v", code, got)
+	}
+	var errResp ErrorResponse
+	if code := getJSON(t, server, http.MethodGet, "/v1/accounts/unknown", "", &errResp); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown account, got %d", code)
+	}
+
+	var statements StatementList
+	getJSON(t, server, http.MethodGet, "/v1/accounts/"+account.ID+"/statements?from=2024-01-01", "", &statements)
+	if len(statements.Statements) != 1 || statements.Statements[0].TransactionCount != 2 {
+		t.Errorf("Unexpected statements: %+v", statements)
+	}
+	getJSON(t, server, http.MethodGet, "/v1/accounts/"+account.ID+"/statements?to=2023-12-31", "", &statements)
+	if len(statements.Statements) != 0 {
+		t.Errorf("Expected no statements before 2024, got %+v", statements.Statements)
+	}
+
+	if code := getJSON(t, server, http.MethodPatch, "/v1/accounts/"+account.ID, `{"account_type": "SAVINGS"}`, &got); code != http.StatusOK || got.AccountType != "SAVINGS" {
+		t.Errorf("Expected the edited account, got %d %+v", code, got)
+	}
+	for _, body := range []string{`{}`, `{"tags": ["x"]}`, `not json`} {
+		if code := getJSON(t, server, http.MethodPatch, "/v1/accounts/"+account.ID, body, &errResp); code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", body, code)
+		}
+	}
+}
+
+func TestTransactions(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Store = newTestStore(t)
+	server := New(cfg)
+	defer server.Close()
+
+	tests := []struct {
+		url  string
+		want []string // Descriptions, in order
+		next bool
+	}{
+		{"/v1/transactions", []string{"SALARY", "GROCER"}, false},
+		{"/v1/transactions?limit=1", []string{"SALARY"}, true},
+		{"/v1/transactions?limit=1&offset=1", []string{"GROCER"}, false},
+		{"/v1/transactions?tag=food", []string{"GROCER"}, false},
+		{"/v1/transactions?tag=food&tag=income", nil, false},
+		{"/v1/transactions?min_amount=0", []string{"SALARY"}, false},
+		{"/v1/transactions?description=^gro&account=1234", []string{"GROCER"}, false},
+		{"/v1/transactions?sort=amount&desc=true", []string{"SALARY", "GROCER"}, false},
+		{"/v1/transactions?from=2024-01-06&to=2024-01-31", []string{"GROCER"}, false},
+	}
+	for _, tt := range tests {
+		var list TransactionList
+		if code := getJSON(t, server, http.MethodGet, tt.url, "", &list); code != http.StatusOK {
+			t.Errorf("%s: expected status 200, got %d", tt.url, code)
+			continue
+		}
+		var got []string
+		for _, tx := range list.Transactions {
+			got = append(got, tx.Description)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") || (list.NextOffset != nil) != tt.next {
+			t.Errorf("%s: got %v (next %v), want %v (next %v)", tt.url, got, list.NextOffset, tt.want, tt.next)
+		}
+	}
+
+	for _, url := range []string{
+		"/v1/transactions?limit=0",
+		"/v1/transactions?offset=-1",
+		"/v1/transactions?min_amount=ten",
+		"/v1/transactions?sort=balance",
+		"/v1/transactions?description=(",
+		"/v1/transactions?from=2024-02-01&to=2024-01-01",
+	} {
+		var errResp ErrorResponse
+		if code := getJSON(t, server, http.MethodGet, url, "", &errResp); code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", url, code)
+		}
+	}
+}
+
+func TestEditTransaction(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Store = newTestStore(t)
+	server := New(cfg)
+	defer server.Close()
+
+	records, err := cfg.Store.QueryTransactions(context.Background(), store.TransactionQuery{Tags: []string{"food"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := "/v1/transactions/" + records[0].ID
+
+	var got store.TransactionRecord
+	if code := getJSON(t, server, http.MethodPatch, url, `{"data": {"category": "Groceries"}}`, &got); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if got.Data["category"] != "Groceries" || len(got.Tags) != 1 || got.Tags[0] != "food" {
+		t.Errorf("Expected data replaced and tags kept, got %+v", got)
+	}
+	getJSON(t, server, http.MethodPatch, url, `{"tags": []}`, &got)
+	getJSON(t, server, http.MethodGet, url, "", &got)
+	if len(got.Tags) != 0 || got.Data["category"] != "Groceries" {
+		t.Errorf("Expected tags cleared and data kept, got %+v", got)
+	}
+
+	var errResp ErrorResponse
+	for _, body := range []string{`{}`, `{"tags": [" "]}`, `{"account_type": "x"}`} {
+		if code := getJSON(t, server, http.MethodPatch, url, body, &errResp); code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", body, code)
+		}
+	}
+	if code := getJSON(t, server, http.MethodPatch, "/v1/transactions/unknown", `{"tags": ["x"]}`, &errResp); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown transaction, got %d", code)
+	}
+	if code := getJSON(t, server, http.MethodDelete, url, "", &errResp); code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", code)
+	}
+}
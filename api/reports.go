@@ -0,0 +1,124 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/integrations/store"
+)
+
+// requireStore writes a 503 and returns false when the server has no database
+func (s *Server) requireStore(w http.ResponseWriter) bool {
+	if s.config.Store == nil {
+		writeError(w, http.StatusServiceUnavailable, CodeNoDatabase, "No database configured (start the server with --db-url)")
+		return false
+	}
+	return true
+}
+
+// queryDate parses an optional YYYY-MM-DD query parameter
+func queryDate(r *http.Request, name string) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be a date (YYYY-MM-DD), got '%s'", name, value)
+	}
+	return date, nil
+}
+
+// handleMonthlyReport handles GET /v1/reports/monthly, the API form of 'kwgn report monthly'.
+// Query params: account, from, to, by (tag or category), format (json or csv).
+func (s *Server) handleMonthlyReport(w http.ResponseWriter, r *http.Request) {
+	if !s.requireStore(w) {
+		return
+	}
+
+	q := r.URL.Query()
+	opts := store.ReportOptions{Account: q.Get("account"), By: q.Get("by")}
+	var err error
+	if opts.From, err = queryDate(r, "from"); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	if opts.To, err = queryDate(r, "to"); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	if opts.By != "" && opts.By != store.GroupTag && opts.By != store.GroupCategory {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "by must be 'tag' or 'category'")
+		return
+	}
+	format := coalesce(q.Get("format"), "json")
+	if format != "json" && format != "csv" {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "format must be 'json' or 'csv'")
+		return
+	}
+
+	report, err := store.BuildMonthlyReport(r.Context(), s.config.Store, opts)
+	if err != nil {
+		log.Printf("%sError building monthly report: %v", s.config.LogPrefix, err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Could not build report: "+err.Error())
+		return
+	}
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="monthly-report.csv"`)
+		report.WriteCSV(w)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// handleNetWorth handles GET /v1/networth, the API form of 'kwgn networth'.
+// Query params: account, from, to, interval (day or month), format (json or csv).
+func (s *Server) handleNetWorth(w http.ResponseWriter, r *http.Request) {
+	if !s.requireStore(w) {
+		return
+	}
+
+	q := r.URL.Query()
+	opts := store.NetWorthOptions{Account: q.Get("account"), Interval: q.Get("interval")}
+	var err error
+	if opts.From, err = queryDate(r, "from"); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	if opts.To, err = queryDate(r, "to"); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	if opts.Interval != "" && opts.Interval != store.IntervalDay && opts.Interval != store.IntervalMonth {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "interval must be 'day' or 'month'")
+		return
+	}
+	if !opts.From.IsZero() && !opts.To.IsZero() && opts.To.Before(opts.From) {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "to must not be before from")
+		return
+	}
+	format := coalesce(q.Get("format"), "json")
+	if format != "json" && format != "csv" {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "format must be 'json' or 'csv'")
+		return
+	}
+
+	nw, err := store.BuildNetWorth(r.Context(), s.config.Store, opts)
+	if err != nil {
+		log.Printf("%sError building net worth: %v", s.config.LogPrefix, err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Could not build net worth: "+err.Error())
+		return
+	}
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="networth.csv"`)
+		nw.WriteCSV(w)
+		return
+	}
+	writeJSON(w, http.StatusOK, nw)
+}
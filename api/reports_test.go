@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/extractor/common"
+	"github.com/aqlanhadi/kwgn/integrations/sqlite"
+	"github.com/aqlanhadi/kwgn/integrations/store"
+	"github.com/shopspring/decimal"
+)
+
+// newTestStore returns an in-memory database holding one statement with two transactions
+func newTestStore(t *testing.T) store.Store {
+	t.Helper()
+	ctx := context.Background()
+	db, err := sqlite.Open(ctx, ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	if err := db.EnsureSchema(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	accountID, err := db.GetOrCreateAccount(ctx, common.Account{AccountNumber: "1234", AccountName: "Savings"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	statementID, err := db.CreateStatement(ctx, accountID, common.Statement{StatementDate: &date, EndingBalance: decimal.NewFromInt(4880)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.CreateTransactions(ctx, statementID, []common.Transaction{
+		{Sequence: 1, Date: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), Descriptions: []string{"SALARY"}, Type: "credit",
+			Amount: decimal.NewFromInt(5000), Balance: decimal.NewFromInt(5000), Tags: []string{"income"}},
+		{Sequence: 2, Date: time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC), Descriptions: []string{"GROCER"}, Type: "debit",
+			Amount: decimal.NewFromInt(-120), Balance: decimal.NewFromInt(4880), Tags: []string{"food"}},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestMonthlyReportEndpoint_NoStore(t *testing.T) {
+	server := New(DefaultConfig())
+
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/reports/monthly", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+}
+
+func TestMonthlyReportEndpoint(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Store = newTestStore(t)
+	server := New(cfg)
+
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/reports/monthly?by=tag&from=2024-01-01", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+	}
+
+	var report store.MonthlyReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(report.Accounts) != 1 || len(report.Accounts[0].Groups) != 2 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	if am := report.Accounts[0]; !am.Nett.Equal(decimal.NewFromInt(4880)) || !am.ClosingBalance.Equal(decimal.NewFromInt(4880)) {
+		t.Errorf("Unexpected totals: %+v", am)
+	}
+}
+
+func TestMonthlyReportEndpoint_CSV(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Store = newTestStore(t)
+	server := New(cfg)
+
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/reports/monthly?format=csv", nil))
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv" {
+		t.Errorf("Expected Content-Type 'text/csv', got '%s'", ct)
+	}
+	if !strings.Contains(w.Body.String(), "2024-01,1234,Savings,,2,5000.00,120.00,4880.00,4880.00") {
+		t.Errorf("Unexpected CSV:\n%s", w.Body)
+	}
+}
+
+func TestMonthlyReportEndpoint_BadRequest(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Store = newTestStore(t)
+	server := New(cfg)
+
+	for _, query := range []string{"by=merchant", "from=2024-13-01", "format=xml"} {
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/reports/monthly?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestNetWorthEndpoint(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Store = newTestStore(t)
+	server := New(cfg)
+
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/networth", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+	}
+
+	var nw store.NetWorth
+	if err := json.NewDecoder(w.Body).Decode(&nw); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	// First transaction (Jan 5) to the statement date (Jan 31)
+	if len(nw.Accounts) != 1 || len(nw.Total) != 27 {
+		t.Fatalf("Unexpected net worth: %d accounts, %d points", len(nw.Accounts), len(nw.Total))
+	}
+	if p := nw.Total[0]; p.Date != "2024-01-05" || !p.Value.Equal(decimal.NewFromInt(5000)) {
+		t.Errorf("Unexpected point: %+v", p)
+	}
+	if p := nw.Total[26]; p.Date != "2024-01-31" || !p.Value.Equal(decimal.NewFromInt(4880)) {
+		t.Errorf("Unexpected point: %+v", p)
+	}
+
+	w = httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/networth?interval=week", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
@@ -4,42 +4,94 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"log"
 	"net/http"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/aqlanhadi/kwgn/extractor"
 	"github.com/aqlanhadi/kwgn/extractor/common"
+	"github.com/aqlanhadi/kwgn/integrations/metrics"
+	"github.com/aqlanhadi/kwgn/integrations/store"
+	"github.com/aqlanhadi/kwgn/integrations/webhook"
 )
 
 // Config holds the API server configuration
 type Config struct {
 	Port            string
+	GRPCPort        string // Address of the gRPC service (e.g. ":9090"); empty disables it
 	DefaultTextOnly bool
 	LogPrefix       string
+	Store           store.Store       // Optional; enables the endpoints that read imported data
+	Metrics         *metrics.Metrics  // Optional; served at /metrics and fed the upload sizes
+	Webhooks        *webhook.Notifier // Optional; told about imported statements and failed imports
+	UI              bool              // Serve the web UI at /ui/
+
+	// RequireAuth rejects requests without a valid API key, except to the health
+	// checks, the metrics, the OpenAPI document and the web UI's files. Keys come from Keys, then from Store.
+	RequireAuth bool
+	Keys        []store.APIKey
+
+	JobWorkers   int           // Jobs extracted concurrently
+	JobQueueSize int           // Jobs waiting beyond this are rejected
+	JobTTL       time.Duration // How long finished jobs are kept (0 = forever)
+
+	// TLS is enabled when both files are set
+	TLSCertFile string
+	TLSKeyFile  string
+
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration // Whole request, including the upload
+	WriteTimeout      time.Duration // Until the response is written, including extraction
+	IdleTimeout       time.Duration
+	ShutdownDelay     time.Duration // How long readiness fails before shutdown starts
+	ShutdownTimeout   time.Duration // How long in-flight requests may take to finish on shutdown
+	MaxBodyBytes      int64         // Larger requests are rejected with 413 (0 = no limit)
 }
 
 // DefaultConfig returns the default API configuration
 func DefaultConfig() Config {
 	return Config{
-		Port:      ":8080",
-		LogPrefix: "API: ",
+		Port:         ":8080",
+		LogPrefix:    "API: ",
+		JobWorkers:   2,
+		JobQueueSize: 100,
+		JobTTL:       time.Hour,
+
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       5 * time.Minute,
+		WriteTimeout:      10 * time.Minute,
+		IdleTimeout:       2 * time.Minute,
+		ShutdownTimeout:   30 * time.Second,
+		MaxBodyBytes:      100 << 20,
 	}
 }
 
 // Server represents the HTTP API server
 type Server struct {
-	config Config
-	mux    *http.ServeMux
+	config   Config
+	mux      *http.ServeMux
+	jobs     *jobQueue
+	limiter  *rateLimiter
+	importer *store.Importer // Set with Config.Store; shared so imports of one account are serialized
+	draining atomic.Bool     // Set once shutdown starts; readiness fails from then on
 }
 
 // New creates a new API server with the given configuration
 func New(cfg Config) *Server {
 	s := &Server{
-		config: cfg,
-		mux:    http.NewServeMux(),
+		config:  cfg,
+		mux:     http.NewServeMux(),
+		jobs:    newJobQueue(cfg.JobWorkers, cfg.JobQueueSize, cfg.JobTTL, cfg.LogPrefix),
+		limiter: newRateLimiter(),
+	}
+	if cfg.Store != nil {
+		s.importer = store.NewImporter(cfg.Store)
 	}
 	s.registerRoutes()
 	return s
@@ -47,8 +99,26 @@ func New(cfg Config) *Server {
 
 // registerRoutes sets up the API endpoints
 func (s *Server) registerRoutes() {
-	s.mux.HandleFunc("/extract", s.handleExtract)
+	// Unversioned endpoints, kept for existing clients
+	s.mux.HandleFunc("/extract", s.authorize(store.ScopeExtract, s.limitBody(s.handleExtract)))
 	s.mux.HandleFunc("/health", s.handleHealth)
+	if s.config.Metrics != nil {
+		s.mux.Handle("/metrics", s.config.Metrics.Handler())
+	}
+	if s.config.UI {
+		s.mux.Handle("/ui/", uiHandler())
+		s.mux.Handle("/{$}", http.RedirectHandler("/ui/", http.StatusFound))
+	}
+
+	// Routes sharing a path are served by one handler that dispatches on the method
+	paths := make(map[string][]route)
+	for _, rt := range s.v1Routes() {
+		paths[rt.path] = append(paths[rt.path], rt)
+	}
+	for path, routes := range paths {
+		s.mux.HandleFunc(path, s.allow(routes))
+	}
+	s.mux.HandleFunc("/v1/", s.handleNotFoundV1)
 }
 
 // Handler returns the http.Handler for the server
@@ -57,10 +127,15 @@ func (s *Server) Handler() http.Handler {
 	return s.mux
 }
 
-// Start starts the HTTP server (blocking)
+// Start starts the HTTP server (blocking). Use Run to be able to shut it down.
 func (s *Server) Start() error {
-	log.Printf("%sStarting server on %s", s.config.LogPrefix, s.config.Port)
-	return http.ListenAndServe(s.config.Port, s.mux)
+	return s.Run(context.Background())
+}
+
+// Close stops the job workers after their current job. Jobs still queued are
+// marked failed and new submissions are refused.
+func (s *Server) Close() {
+	s.jobs.close()
 }
 
 // handleHealth handles health check requests
@@ -81,6 +156,11 @@ func (s *Server) handleExtract(w http.ResponseWriter, r *http.Request) {
 	// Parse multipart form with 32MB max memory
 	if err := r.ParseMultipartForm(32 << 20); err != nil {
 		log.Printf("%sError parsing multipart form: %v", s.config.LogPrefix, err)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Request body is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
 		http.Error(w, "Could not parse multipart form: "+err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -101,6 +181,7 @@ func (s *Server) handleExtract(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.config.Metrics.ObserveUpload(int64(len(fileBytes)))
 	fileReader := bytes.NewReader(fileBytes)
 
 	// Extract flags from request
@@ -0,0 +1,323 @@
+// kwgn web UI: a single page over the /v1 API. The view follows the URL fragment:
+// #upload, #accounts, #statements/<account id> or #transactions?<filters>.
+'use strict';
+
+const PAGE_SIZE = 50;
+const KEY_STORAGE = 'kwgn.apiKey';
+
+const $ = (selector) => document.querySelector(selector);
+
+// el builds an element. Children are nodes or text; text is never parsed as HTML.
+function el(tag, attrs = {}, ...children) {
+  const node = document.createElement(tag);
+  for (const [name, value] of Object.entries(attrs)) {
+    if (name.startsWith('on')) {
+      node.addEventListener(name.slice(2), value);
+    } else if (value !== undefined && value !== null && value !== false) {
+      node.setAttribute(name, value === true ? '' : value);
+    }
+  }
+  for (const child of children.flat()) {
+    if (child !== undefined && child !== null) node.append(child);
+  }
+  return node;
+}
+
+function showMessage(text, isError = false) {
+  const message = $('#message');
+  message.textContent = text;
+  message.className = isError ? 'error' : '';
+  message.hidden = !text;
+}
+
+// action runs an event handler, reporting its failure
+function action(fn) {
+  return (event) => {
+    if (event && event.preventDefault) event.preventDefault();
+    Promise.resolve(fn(event)).catch((err) => showMessage(err.message, true));
+  };
+}
+
+function promptKey(reason) {
+  const key = window.prompt(
+    (reason ? reason + '\n\n' : '') +
+    "API key (kwgn_...), created with 'kwgn apikey create'. Leave it empty if the server runs with --no-auth.",
+    localStorage.getItem(KEY_STORAGE) || '');
+  if (key === null) return false;
+  if (key.trim()) {
+    localStorage.setItem(KEY_STORAGE, key.trim());
+  } else {
+    localStorage.removeItem(KEY_STORAGE);
+  }
+  return true;
+}
+
+// api calls an endpoint and returns the decoded response, or throws with the
+// message of the error envelope. A rejected key asks for another and retries once.
+async function api(method, path, body, retried = false) {
+  const headers = {};
+  const key = localStorage.getItem(KEY_STORAGE);
+  if (key) headers.Authorization = 'Bearer ' + key;
+  let payload = body;
+  if (body !== undefined && !(body instanceof FormData)) {
+    headers['Content-Type'] = 'application/json';
+    payload = JSON.stringify(body);
+  }
+
+  const response = await fetch(path, { method, headers, body: payload });
+  const data = await response.json().catch(() => null);
+  if (response.ok) return data;
+
+  const message = data && data.error ? data.error.message : `${response.status} ${response.statusText}`;
+  if (response.status === 401 && !retried && promptKey(message)) {
+    return api(method, path, body, true);
+  }
+  throw new Error(message);
+}
+
+const formatDate = (value) => (value && !value.startsWith('0001-') ? value.slice(0, 10) : '');
+
+function formatMoney(value) {
+  if (value === undefined || value === null || value === '') return '';
+  return Number(value).toLocaleString(undefined, { minimumFractionDigits: 2, maximumFractionDigits: 2 });
+}
+
+const sameAmount = (a, b) => Math.round(Number(a) * 100) === Math.round(Number(b) * 100);
+
+// balanceCheck tells whether a statement's transactions add up to its ending balance
+function balanceCheck(stmt, transactionCount) {
+  if (!transactionCount || stmt.ending_balance == null || stmt.calculated_ending_balance == null) {
+    return el('span', { class: 'badge muted' }, 'n/a');
+  }
+  if (sameAmount(stmt.ending_balance, stmt.calculated_ending_balance)) {
+    return el('span', { class: 'badge ok' }, 'OK');
+  }
+  return el('span', { class: 'badge bad', title: `Transactions add up to ${formatMoney(stmt.calculated_ending_balance)}` }, 'Mismatch');
+}
+
+const num = (value) => el('td', { class: 'num' }, value);
+
+function emptyRow(columns, text) {
+  return el('tr', {}, el('td', { colspan: columns, class: 'muted' }, text));
+}
+
+// pager links to the previous and next pages of a list response
+function pager(container, view, params, page) {
+  const link = (offset, text) => {
+    const next = new URLSearchParams(params);
+    next.set('offset', offset);
+    return el('a', { href: `#${view}?${next}` }, text);
+  };
+  container.replaceChildren(
+    page.offset > 0 ? link(Math.max(0, page.offset - page.limit), '← Previous') : null,
+    page.next_offset !== null ? link(page.next_offset, 'Next →') : null,
+  );
+}
+
+const offsetOf = (params) => Number(params.get('offset')) || 0;
+
+// Upload
+
+let previewed = null; // FormData of the previewed files, sent again on import
+
+async function previewUpload(event) {
+  const form = event.target;
+  previewed = new FormData(form);
+  $('#import-form').hidden = true;
+  $('#import-result').replaceChildren();
+  $('#preview').replaceChildren(el('p', { class: 'muted' }, 'Extracting…'));
+
+  const batch = await api('POST', '/v1/extract/batch', previewed);
+  $('#preview').replaceChildren(...batch.results.map(previewFile));
+  $('#import-form').hidden = batch.failed === batch.files;
+}
+
+function previewFile(result) {
+  if (result.status !== 'ok') {
+    return el('article', { class: 'file' },
+      el('h3', {}, result.filename, ' ', el('span', { class: 'badge bad' }, 'Failed')),
+      el('p', {}, result.error));
+  }
+  return el('article', { class: 'file' },
+    el('h3', {}, result.filename),
+    (result.statements || []).map((stmt) => {
+      const transactions = stmt.transactions || [];
+      const account = stmt.account || {};
+      return el('div', { class: 'statement' },
+        el('p', {},
+          el('strong', {}, [account.account_number, account.account_name].filter(Boolean).join(' · ') || 'Unknown account'),
+          ` — statement of ${formatDate(stmt.statement_date) || 'unknown date'}, ${transactions.length} transactions, ending balance ${formatMoney(stmt.ending_balance)} `,
+          balanceCheck(stmt, transactions.length)),
+        transactions.length === 0 ? null : el('details', {},
+          el('summary', {}, 'Transactions'),
+          el('table', {},
+            el('thead', {}, el('tr', {}, el('th', {}, 'Date'), el('th', {}, 'Description'), el('th', { class: 'num' }, 'Amount'), el('th', { class: 'num' }, 'Balance'))),
+            el('tbody', {}, transactions.map((tx) => el('tr', {},
+              el('td', {}, formatDate(tx.date)),
+              el('td', {}, (tx.descriptions || []).join(' ')),
+              num(formatMoney(tx.amount)),
+              num(formatMoney(tx.balance))))))));
+    }));
+}
+
+async function importUpload(event) {
+  const force = event.target.force.checked;
+  $('#import-result').replaceChildren(el('p', { class: 'muted' }, 'Importing…'));
+  const result = await api('POST', '/v1/import' + (force ? '?force=true' : ''), previewed);
+
+  $('#import-form').hidden = true;
+  $('#preview').replaceChildren();
+  $('#upload-form').reset();
+  $('#import-result').replaceChildren(
+    el('p', {}, `Imported ${result.processed} statements with ${result.transactions_inserted} new transactions; ` +
+      `${result.skipped} already imported, ${result.failed} failed.`),
+    result.errors.length === 0 ? null : el('ul', { class: 'error' }, result.errors.map((err) => el('li', {}, err))),
+    el('p', {}, el('a', { href: '#accounts' }, 'See accounts')));
+}
+
+// Accounts
+
+async function showAccounts(_, params) {
+  const list = await api('GET', `/v1/accounts?limit=${PAGE_SIZE}&offset=${offsetOf(params)}`);
+  const rows = list.accounts.map((account) => {
+    const type = el('input', { value: account.account_type, placeholder: 'e.g. SAVINGS', 'aria-label': 'Account type' });
+    const save = action(async () => {
+      const updated = await api('PATCH', `/v1/accounts/${account.id}`, { account_type: type.value.trim() });
+      type.value = updated.account_type;
+      showMessage(`Saved the type of ${account.account_number}.`);
+    });
+    type.addEventListener('keydown', (event) => { if (event.key === 'Enter') save(event); });
+
+    return el('tr', {},
+      el('td', {}, el('a', { href: `#statements/${account.id}` }, account.account_number)),
+      el('td', {}, account.account_name),
+      el('td', { class: 'edit' }, type, el('button', { type: 'button', onclick: save }, 'Save')),
+      num(account.statement_count),
+      el('td', {}, formatDate(account.latest_statement_date)),
+      num(formatMoney(account.latest_balance)));
+  });
+  $('#account-rows').replaceChildren(...(rows.length ? rows : [emptyRow(6, 'No accounts yet. Upload statements to import them.')]));
+  pager($('#account-pager'), 'accounts', params, list);
+}
+
+async function showStatements(id, params) {
+  const account = await api('GET', `/v1/accounts/${encodeURIComponent(id)}`);
+  $('#statements-title').textContent = `Statements of ${account.account_number} ${account.account_name}`;
+
+  const list = await api('GET', `/v1/accounts/${encodeURIComponent(id)}/statements?desc=true&limit=${PAGE_SIZE}&offset=${offsetOf(params)}`);
+  const rows = list.statements.map((stmt) => el('tr', {},
+    el('td', {}, el('a', { href: `#transactions?statement_id=${stmt.id}` }, formatDate(stmt.statement_date))),
+    el('td', {}, stmt.source),
+    num(formatMoney(stmt.starting_balance)),
+    num(formatMoney(stmt.ending_balance)),
+    num(formatMoney(stmt.calculated_ending_balance)),
+    num(formatMoney(stmt.total_credit)),
+    num(formatMoney(stmt.total_debit)),
+    num(stmt.transaction_count),
+    el('td', {}, balanceCheck(stmt, stmt.transaction_count))));
+  $('#statement-rows').replaceChildren(...(rows.length ? rows : [emptyRow(9, 'No statements.')]));
+  pager($('#statement-pager'), `statements/${id}`, params, list);
+}
+
+// Transactions
+
+async function showTransactions(_, params) {
+  const form = $('#filter-form');
+  for (const input of form.elements) {
+    if (input.name) input.value = params.get(input.name) || '';
+  }
+
+  const query = new URLSearchParams({ limit: PAGE_SIZE, offset: offsetOf(params) });
+  for (const [name, value] of params) {
+    if (!value || name === 'offset') continue;
+    if (name === 'tag') {
+      value.split(',').map((tag) => tag.trim()).filter(Boolean).forEach((tag) => query.append('tag', tag));
+    } else {
+      query.set(name, value);
+    }
+  }
+
+  const list = await api('GET', '/v1/transactions?' + query);
+  const rows = list.transactions.map(transactionRow);
+  $('#transaction-rows').replaceChildren(...(rows.length ? rows : [emptyRow(8, 'No matching transactions.')]));
+  pager($('#transaction-pager'), 'transactions', params, list);
+}
+
+function transactionRow(tx) {
+  const tags = el('input', { value: (tx.tags || []).join(', '), placeholder: 'food, travel', 'aria-label': 'Tags' });
+  const category = el('input', { value: (tx.data && tx.data.category) || '', 'aria-label': 'Category' });
+  const save = action(async () => {
+    // data is replaced as a whole, so the other keys are sent back unchanged
+    const data = { ...(tx.data || {}) };
+    if (category.value.trim()) {
+      data.category = category.value.trim();
+    } else {
+      delete data.category;
+    }
+    const updated = await api('PATCH', `/v1/transactions/${tx.id}`, {
+      tags: tags.value.split(',').map((tag) => tag.trim()).filter(Boolean),
+      data,
+    });
+    Object.assign(tx, updated);
+    tags.value = (tx.tags || []).join(', ');
+    showMessage(`Saved the transaction of ${formatDate(tx.date)}.`);
+  });
+  for (const input of [tags, category]) {
+    input.addEventListener('keydown', (event) => { if (event.key === 'Enter') save(event); });
+  }
+
+  return el('tr', {},
+    el('td', {}, formatDate(tx.date)),
+    el('td', { title: tx.account_name }, tx.account_number),
+    el('td', {}, tx.description),
+    el('td', { class: `num ${tx.type}` }, formatMoney(tx.amount)),
+    num(formatMoney(tx.balance)),
+    el('td', { class: 'edit' }, tags),
+    el('td', { class: 'edit' }, category),
+    el('td', {}, el('button', { type: 'button', onclick: save }, 'Save')));
+}
+
+function searchTransactions(event) {
+  const params = new URLSearchParams();
+  for (const [name, value] of new FormData(event.target)) {
+    if (value.trim()) params.set(name, value.trim());
+  }
+  const hash = '#transactions?' + params;
+  if (location.hash === hash) {
+    route(); // Search again
+  } else {
+    location.hash = hash;
+  }
+}
+
+// Routing
+
+const views = {
+  upload: async () => {},
+  accounts: showAccounts,
+  statements: showStatements,
+  transactions: showTransactions,
+};
+
+function route() {
+  const [path, query] = (location.hash.slice(1) || 'upload').split('?');
+  let [view, id] = path.split('/');
+  if (!views[view]) view = 'upload';
+
+  for (const section of document.querySelectorAll('main > section')) {
+    section.hidden = section.id !== view;
+  }
+  for (const link of document.querySelectorAll('nav a')) {
+    const current = link.getAttribute('href') === '#' + (view === 'statements' ? 'accounts' : view);
+    link.classList.toggle('active', current);
+  }
+  showMessage('');
+  views[view](id, new URLSearchParams(query)).catch((err) => showMessage(err.message, true));
+}
+
+$('#upload-form').addEventListener('submit', action(previewUpload));
+$('#import-form').addEventListener('submit', action(importUpload));
+$('#filter-form').addEventListener('submit', action(searchTransactions));
+$('#key-button').addEventListener('click', () => { if (promptKey()) route(); });
+window.addEventListener('hashchange', route);
+route();
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUI(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.UI = true
+	cfg.RequireAuth = true // The files themselves are public
+	server := New(cfg)
+	defer server.Close()
+
+	tests := []struct {
+		url         string
+		contentType string
+		contains    string
+	}{
+		{"/ui/", "text/html", `<script src="app.js"`},
+		{"/ui/app.js", "javascript", "/v1/transactions"},
+		{"/ui/style.css", "text/css", ":root"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected status 200, got %d", tt.url, w.Code)
+			continue
+		}
+		if !strings.Contains(w.Header().Get("Content-Type"), tt.contentType) {
+			t.Errorf("%s: expected %s, got %s", tt.url, tt.contentType, w.Header().Get("Content-Type"))
+		}
+		if w.Header().Get("Content-Security-Policy") == "" {
+			t.Errorf("%s: expected a Content-Security-Policy", tt.url)
+		}
+		if !strings.Contains(w.Body.String(), tt.contains) {
+			t.Errorf("%s: expected %q in the body", tt.url, tt.contains)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "/ui/" {
+		t.Errorf("Expected / to redirect to /ui/, got %d %s", w.Code, w.Header().Get("Location"))
+	}
+	w = httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected other paths to stay unknown, got %d", w.Code)
+	}
+}
+
+func TestUI_Disabled(t *testing.T) {
+	server := New(DefaultConfig())
+	defer server.Close()
+
+	for _, url := range []string{"/ui/", "/"} {
+		w := httptest.NewRecorder()
+		server.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status 404 without the UI, got %d", url, w.Code)
+		}
+	}
+}
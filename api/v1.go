@@ -0,0 +1,239 @@
+package api
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/extractor/common"
+	"github.com/aqlanhadi/kwgn/integrations/store"
+	"github.com/shopspring/decimal"
+)
+
+// openAPISpec is the OpenAPI 3 description of the /v1 API, served at /openapi.json
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// Machine-readable error codes returned in the error envelope
+const (
+	CodeInvalidRequest   = "invalid_request"
+	CodeNoFile           = "no_file"
+	CodeExtractionFailed = "extraction_failed"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeQueueFull        = "queue_full"
+	CodeShuttingDown     = "shutting_down"
+	CodeNoDatabase       = "database_not_configured"
+	CodeInternal         = "internal_error"
+)
+
+// ErrorResponse is the body of every /v1 error response
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes what went wrong
+type ErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// HealthResponse is returned by GET /v1/health
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+// StatementResponse is an extracted statement. Balances that were not found in
+// the document are omitted, following the same rules as 'kwgn extract'.
+type StatementResponse struct {
+	Source                  string               `json:"source"`
+	Account                 *common.Account      `json:"account,omitempty"`
+	StatementDate           *time.Time           `json:"statement_date,omitempty"`
+	StartingBalance         *decimal.Decimal     `json:"starting_balance,omitempty"`
+	EndingBalance           *decimal.Decimal     `json:"ending_balance,omitempty"`
+	CalculatedEndingBalance *decimal.Decimal     `json:"calculated_ending_balance,omitempty"`
+	TotalCredit             decimal.Decimal      `json:"total_credit"`
+	TotalDebit              decimal.Decimal      `json:"total_debit"`
+	Nett                    decimal.Decimal      `json:"nett"`
+	TransactionStartDate    time.Time            `json:"transaction_start_date"`
+	TransactionEndDate      time.Time            `json:"transaction_end_date"`
+	Transactions            []common.Transaction `json:"transactions,omitempty"`
+}
+
+// ExtractResponse is returned by POST /v1/extract. Depending on the options it holds
+// the statements, only their transactions, or only the document text.
+type ExtractResponse struct {
+	Filename     string               `json:"filename"`
+	Statements   []StatementResponse  `json:"statements,omitempty"`
+	Transactions []common.Transaction `json:"transactions,omitempty"`
+	Text         string               `json:"text,omitempty"`
+}
+
+// route is a /v1 endpoint: one method on one path
+type route struct {
+	method  string
+	path    string
+	scope   string // API key scope required, empty for public endpoints
+	handler http.HandlerFunc
+}
+
+// v1Routes lists the versioned endpoints. Every one must be described in openapi.json.
+func (s *Server) v1Routes() []route {
+	return []route{
+		{http.MethodGet, "/openapi.json", "", s.handleOpenAPI},
+		{http.MethodGet, "/v1/health", "", s.handleHealthV1},
+		{http.MethodGet, "/v1/health/live", "", s.handleLive},
+		{http.MethodGet, "/v1/health/ready", "", s.handleReady},
+		{http.MethodPost, "/v1/extract", store.ScopeExtract, s.handleExtractV1},
+		{http.MethodPost, "/v1/extract/batch", store.ScopeExtract, s.handleBatch},
+		{http.MethodPost, "/v1/jobs", store.ScopeExtract, s.handleJobs},
+		{http.MethodGet, "/v1/jobs/{id}", store.ScopeExtract, s.handleJob},
+		{http.MethodPost, "/v1/import", store.ScopeImport, s.handleImport},
+		{http.MethodGet, "/v1/accounts", store.ScopeRead, s.handleAccounts},
+		{http.MethodGet, "/v1/accounts/{id}", store.ScopeRead, s.handleAccount},
+		{http.MethodPatch, "/v1/accounts/{id}", store.ScopeWrite, s.handleEditAccount},
+		{http.MethodGet, "/v1/accounts/{id}/statements", store.ScopeRead, s.handleAccountStatements},
+		{http.MethodGet, "/v1/transactions", store.ScopeRead, s.handleTransactions},
+		{http.MethodGet, "/v1/transactions/{id}", store.ScopeRead, s.handleTransaction},
+		{http.MethodPatch, "/v1/transactions/{id}", store.ScopeWrite, s.handleEditTransaction},
+		{http.MethodGet, "/v1/reports/monthly", store.ScopeRead, s.handleMonthlyReport},
+		{http.MethodGet, "/v1/networth", store.ScopeRead, s.handleNetWorth},
+	}
+}
+
+// allow dispatches requests to the route of their method. Other methods are
+// rejected once the request is authorized for the path's first route.
+func (s *Server) allow(routes []route) http.HandlerFunc {
+	methods := make(map[string]http.HandlerFunc)
+	var allowed []string
+	for _, rt := range routes {
+		methods[rt.method] = s.authorize(rt.scope, s.limitBody(rt.handler))
+		allowed = append(allowed, rt.method)
+	}
+	notAllowed := s.authorize(routes[0].scope, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed,
+			"Method "+r.Method+" is not allowed, use "+strings.Join(allowed, " or "))
+	})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if next, ok := methods[r.Method]; ok {
+			next(w, r)
+			return
+		}
+		notAllowed(w, r)
+	}
+}
+
+// writeJSON writes v as a JSON response with the given status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+}
+
+// writeError writes the JSON error envelope
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, ErrorResponse{Error: ErrorDetail{Code: code, Message: message}})
+}
+
+// handleNotFoundV1 answers unknown /v1 paths with the error envelope
+func (s *Server) handleNotFoundV1(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusNotFound, CodeNotFound, "No endpoint at "+r.URL.Path)
+}
+
+// handleOpenAPI serves the OpenAPI specification
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
+
+// handleHealthV1 handles GET /v1/health
+func (s *Server) handleHealthV1(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, HealthResponse{Status: "ok"})
+}
+
+// handleExtractV1 handles POST /v1/extract: a single file, extracted synchronously.
+// Unlike /extract, every statement in the file is returned (e.g. one per credit card).
+func (s *Server) handleExtractV1(w http.ResponseWriter, r *http.Request) {
+	log.Printf("%sReceived request from %s", s.config.LogPrefix, r.RemoteAddr)
+
+	if !s.parseMultipart(w, r) {
+		return
+	}
+	uploads, err := s.readUploads(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeNoFile, err.Error())
+		return
+	}
+	if len(uploads) > 1 {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Only one file is accepted, use /v1/extract/batch for several")
+		return
+	}
+	u := uploads[0]
+	opts := s.parseExtractOptions(r)
+
+	if opts.TextOnly {
+		rows, err := common.ExtractRowsFromPDFReader(bytes.NewReader(u.data))
+		if err != nil || len(*rows) < 1 {
+			writeError(w, http.StatusUnprocessableEntity, CodeExtractionFailed, "Could not extract text from file")
+			return
+		}
+		writeJSON(w, http.StatusOK, ExtractResponse{Filename: u.name, Text: strings.Join(*rows, "\n")})
+		return
+	}
+
+	result := extractUpload(u, opts)
+	if result.Status == FileFailed {
+		writeError(w, http.StatusUnprocessableEntity, CodeExtractionFailed, result.Error)
+		return
+	}
+	writeJSON(w, http.StatusOK, ExtractResponse{
+		Filename:     result.Filename,
+		Statements:   result.Statements,
+		Transactions: result.Transactions,
+	})
+}
+
+// newStatementResponse converts an extracted statement, mirroring extractor.CreateFinalOutput
+func newStatementResponse(stmt common.Statement, statementOnly bool) StatementResponse {
+	resp := StatementResponse{
+		Source:               stmt.Source,
+		TotalCredit:          stmt.TotalCredit,
+		TotalDebit:           stmt.TotalDebit,
+		Nett:                 stmt.Nett,
+		TransactionStartDate: stmt.TransactionStartDate,
+		TransactionEndDate:   stmt.TransactionEndDate,
+	}
+	if stmt.Account != (common.Account{}) {
+		account := stmt.Account
+		resp.Account = &account
+	}
+	if stmt.StatementDate != nil && !stmt.StatementDate.IsZero() {
+		resp.StatementDate = stmt.StatementDate
+	}
+
+	// Zero balances are only meaningful when there are transactions to carry them
+	hasTransactions := len(stmt.Transactions) > 0
+	if hasTransactions || !stmt.StartingBalance.IsZero() {
+		resp.StartingBalance = &stmt.StartingBalance
+	}
+	if hasTransactions || !stmt.EndingBalance.IsZero() {
+		resp.EndingBalance = &stmt.EndingBalance
+	}
+	if hasTransactions || !stmt.CalculatedEndingBalance.IsZero() {
+		resp.CalculatedEndingBalance = &stmt.CalculatedEndingBalance
+	}
+
+	if !statementOnly {
+		resp.Transactions = stmt.Transactions
+	}
+	return resp
+}
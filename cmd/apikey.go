@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/integrations/store"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	apikeyDBURL     string
+	apikeyName      string
+	apikeyScopes    []string
+	apikeyRateLimit int
+	apikeyTimeout   int
+)
+
+var apikeyCmd = &cobra.Command{
+	Use:   "apikey",
+	Short: "Manage API keys for 'kwgn serve'",
+	Long: `Creates, lists and revokes the API keys 'kwgn serve' requires.
+
+Keys carry scopes (extract, import, read, write) and an optional rate limit in requests
+per minute. Only a SHA-256 hash of each key is stored; the key itself is shown
+once, when it is created.
+
+Keys are stored in the database given with --db-url (or DATABASE_URL). Without
+a database, 'create' prints an entry to add under api_keys in the config file
+instead; such keys are revoked by removing them from the file.
+
+Examples:
+  kwgn apikey create --name laptop --scopes extract,read --db-url sqlite:kwgn.db
+  kwgn apikey create --name ci --scopes extract --rate-limit 60
+  kwgn apikey list --db-url sqlite:kwgn.db
+  kwgn apikey revoke kwgn_abcd1234 --db-url sqlite:kwgn.db`,
+}
+
+var apikeyCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create an API key and print it",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		token, key, err := store.NewAPIKey(apikeyName, apikeyScopes, apikeyRateLimit)
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+
+		if apikeyDBURL == "" && os.Getenv("DATABASE_URL") == "" {
+			fmt.Printf("API key: %s\n\n", token)
+			fmt.Println("Store it now, it cannot be shown again. Add it to the config file:")
+			fmt.Println()
+			fmt.Println("api_keys:")
+			fmt.Printf("  - name: %s\n", key.Name)
+			fmt.Printf("    prefix: %s\n", key.Prefix)
+			fmt.Printf("    hash: %s\n", key.Hash)
+			fmt.Printf("    scopes: [%s]\n", strings.Join(key.Scopes, ", "))
+			if key.RateLimit > 0 {
+				fmt.Printf("    rate_limit: %d\n", key.RateLimit)
+			}
+			return
+		}
+
+		ctx, cancel := apikeyContext()
+		defer cancel()
+		db := openDatabase(ctx, apikeyDBURL)
+		defer db.Close()
+
+		id, err := db.CreateAPIKey(ctx, key)
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+		fmt.Printf("\nCreated API key %s (%s) with scopes %s\n", key.Name, id, strings.Join(key.Scopes, ", "))
+		fmt.Printf("API key: %s\n", token)
+		fmt.Println("Store it now, it cannot be shown again.")
+	},
+}
+
+var apikeyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List API keys",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		keys, err := configAPIKeys()
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+
+		if apikeyDBURL != "" || os.Getenv("DATABASE_URL") != "" {
+			ctx, cancel := apikeyContext()
+			defer cancel()
+			db := openDatabase(ctx, apikeyDBURL)
+			defer db.Close()
+
+			stored, err := db.ListAPIKeys(ctx)
+			if err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			keys = append(keys, stored...)
+			fmt.Println()
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tPREFIX\tNAME\tSCOPES\tRATE LIMIT\tCREATED\tSTATUS")
+		for _, key := range keys {
+			id, created, status := key.ID, "", "active"
+			if id == "" {
+				id = "(config)"
+			}
+			if !key.CreatedAt.IsZero() {
+				created = key.CreatedAt.Local().Format("2006-01-02 15:04")
+			}
+			if key.Revoked() {
+				status = "revoked " + key.RevokedAt.Local().Format(time.DateOnly)
+			}
+			limit := "unlimited"
+			if key.RateLimit > 0 {
+				limit = fmt.Sprintf("%d/min", key.RateLimit)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", id, key.Prefix, key.Name, strings.Join(key.Scopes, ","), limit, created, status)
+		}
+		w.Flush()
+	},
+}
+
+var apikeyRevokeCmd = &cobra.Command{
+	Use:   "revoke <id|prefix>",
+	Short: "Revoke an API key stored in the database",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, cancel := apikeyContext()
+		defer cancel()
+		db := openDatabase(ctx, apikeyDBURL)
+		defer db.Close()
+
+		revoked, err := db.RevokeAPIKey(ctx, args[0])
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+		if !revoked {
+			log.Fatalf("error: no active API key with id or prefix '%s' (keys in the config file are revoked by removing them)", args[0])
+		}
+		log.Printf("Revoked API key %s", args[0])
+	},
+}
+
+// apikeyContext returns a context bounded by --timeout and configures logging
+func apikeyContext() (context.Context, context.CancelFunc) {
+	log.SetOutput(os.Stdout)
+	log.SetFlags(log.Ltime | log.Lmsgprefix)
+	return context.WithTimeout(context.Background(), time.Duration(apikeyTimeout)*time.Second)
+}
+
+var sha256Hex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// configAPIKeys reads the keys listed under api_keys in the config file
+func configAPIKeys() ([]store.APIKey, error) {
+	var entries []struct {
+		Name      string   `mapstructure:"name"`
+		Prefix    string   `mapstructure:"prefix"`
+		Hash      string   `mapstructure:"hash"`
+		Scopes    []string `mapstructure:"scopes"`
+		RateLimit int      `mapstructure:"rate_limit"`
+	}
+	if err := viper.UnmarshalKey("api_keys", &entries); err != nil {
+		return nil, fmt.Errorf("invalid api_keys in config: %w", err)
+	}
+
+	keys := make([]store.APIKey, 0, len(entries))
+	for i, e := range entries {
+		if !sha256Hex.MatchString(e.Hash) {
+			return nil, fmt.Errorf("api_keys[%d] (%s): hash must be the 64 hex digit SHA-256 printed by 'kwgn apikey create'", i, e.Name)
+		}
+		if err := store.ValidateScopes(e.Scopes); err != nil {
+			return nil, fmt.Errorf("api_keys[%d] (%s): %w", i, e.Name, err)
+		}
+		if e.Prefix == "" {
+			e.Prefix = e.Name // Only used to tell keys apart in logs
+		}
+		if e.RateLimit < 0 {
+			return nil, fmt.Errorf("api_keys[%d] (%s): rate_limit must not be negative", i, e.Name)
+		}
+		keys = append(keys, store.APIKey{
+			Name:      e.Name,
+			Prefix:    e.Prefix,
+			Hash:      e.Hash,
+			Scopes:    e.Scopes,
+			RateLimit: e.RateLimit,
+		})
+	}
+	return keys, nil
+}
+
+func init() {
+	rootCmd.AddCommand(apikeyCmd)
+	apikeyCmd.AddCommand(apikeyCreateCmd, apikeyListCmd, apikeyRevokeCmd)
+
+	apikeyCmd.PersistentFlags().StringVar(&apikeyDBURL, "db-url", "", "PostgreSQL URL or sqlite:<path> to store keys in (or set DATABASE_URL env)")
+	apikeyCmd.PersistentFlags().IntVar(&apikeyTimeout, "timeout", 60, "Operation timeout in seconds")
+
+	apikeyCreateCmd.Flags().StringVar(&apikeyName, "name", "", "Name telling what the key is for (required)")
+	apikeyCreateCmd.Flags().StringSliceVar(&apikeyScopes, "scopes", []string{store.ScopeExtract}, "Scopes granted: extract, import, read, write")
+	apikeyCreateCmd.Flags().IntVar(&apikeyRateLimit, "rate-limit", 0, "Requests per minute allowed (0 = unlimited)")
+	apikeyCreateCmd.MarkFlagRequired("name")
+}
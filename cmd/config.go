@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration file",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the configuration file and list every problem found",
+	Long: `Checks the configuration file (--config, or ./.kwgn-no-acc.yaml, or
+~/.kwgn-no-acc.yaml) and lists every problem with its key path, such as
+'accounts[2].reconciliable' or 'statement.TNG.patterns.transaction'.
+
+Accounts and statement patterns are checked the same way extract, import, watch
+and serve check them before they start: keys must have the right type, regular expressions must
+compile, accounts need a number, regex_identifier and a known
+statement_config, and each statement section needs the patterns its extractor
+cannot work without. The webhooks and api_keys sections are checked too.
+
+Exits with status 1 when the configuration is invalid.
+
+Examples:
+  kwgn config validate
+  kwgn config validate --config ~/finance/.kwgn.yaml`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var errs []error
+		if configErr != nil {
+			errs = append(errs, configErr)
+		}
+		if _, err := configWebhooks(); err != nil {
+			errs = append(errs, err)
+		}
+		if _, err := configAPIKeys(); err != nil {
+			errs = append(errs, err)
+		}
+
+		if len(errs) > 0 {
+			fmt.Fprintf(os.Stderr, "%s is invalid:\n%v\n", configSource(), errors.Join(errs...))
+			os.Exit(1)
+		}
+		fmt.Printf("%s is valid\n", configSource())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configValidateCmd)
+}
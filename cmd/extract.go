@@ -20,6 +20,10 @@ var (
 	statementOnly   bool
 	statementType   string
 	textOnly        bool
+	extractJobs     int
+	scanRecursive   bool
+	scanInclude     []string
+	scanExclude     []string
 )
 
 func handler(cmd *cobra.Command, args []string) {
@@ -27,6 +31,7 @@ func handler(cmd *cobra.Command, args []string) {
 	if transactionOnly && statementOnly {
 		log.Fatal("Error: --transaction-only and --statement-only flags are mutually exclusive")
 	}
+	requireValidConfig()
 
 	// Access the configuration using Viper
 	target := viper.GetString("target")
@@ -34,7 +39,23 @@ func handler(cmd *cobra.Command, args []string) {
 		target = args[0]
 	}
 	log.Println("Scanning", target)
-	extractor.ExecuteAgainstPath(target, transactionOnly, statementOnly, statementType, textOnly)
+	extractor.ExecuteAgainstPath(target, extractor.ExecuteOptions{
+		TransactionOnly: transactionOnly,
+		StatementOnly:   statementOnly,
+		StatementType:   statementType,
+		TextOnly:        textOnly,
+		Jobs:            extractJobs,
+		Scan:            scanOptions(),
+	})
+}
+
+// scanOptions builds the directory scanning options shared by extract and import
+func scanOptions() extractor.ScanOptions {
+	return extractor.ScanOptions{
+		Recursive: scanRecursive,
+		Include:   scanInclude,
+		Exclude:   scanExclude,
+	}
 }
 
 func init() {
@@ -47,6 +68,10 @@ func init() {
 	extractCmd.Flags().BoolVar(&statementOnly, "statement-only", false, "Print only statement details (excluding transactions)")
 	extractCmd.Flags().StringVar(&statementType, "statement-type", "", "Override statement type detection (e.g., MAYBANK_CASA_AND_MAE)")
 	extractCmd.Flags().BoolVarP(&textOnly, "text-only", "t", false, "Extract raw text from PDF without processing (returns JSON with filename and text)")
+	extractCmd.Flags().BoolVarP(&scanRecursive, "recursive", "r", false, "Scan sub-folders recursively")
+	extractCmd.Flags().StringSliceVar(&scanInclude, "include", nil, "Only process files matching these glob patterns (e.g. '*2024*.pdf')")
+	extractCmd.Flags().StringSliceVar(&scanExclude, "exclude", nil, "Skip files and folders matching these glob patterns")
+	extractCmd.Flags().IntVarP(&extractJobs, "jobs", "j", 1, "Number of files to process concurrently in directory mode (0 = one per CPU)")
 
 	// Bind flags to viper
 	viper.BindPFlag("target", extractCmd.Flags().Lookup("folder"))
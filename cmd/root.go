@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 
+	"github.com/aqlanhadi/kwgn/extractor/settings"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -73,9 +74,10 @@ statement:
 `
 
 var (
-	cfgFile string
-	verbose bool
-	rootCmd = &cobra.Command{
+	cfgFile   string
+	verbose   bool
+	configErr error // Problems found in the accounts and statement patterns
+	rootCmd   = &cobra.Command{
 		Use:   "kwgn [filename]",
 		Short: "A brief description of your application",
 		Long:  `kwgn is a utility to extract structured data out of your financial statements`,
@@ -146,4 +148,25 @@ func initConfig() {
 			os.Exit(1)
 		}
 	}
+
+	var cfg *settings.Config
+	cfg, configErr = settings.Load(viper.GetViper())
+	settings.Set(cfg)
+}
+
+// requireValidConfig exits when the accounts or statement patterns are invalid.
+// Commands that extract statements call it before they start; 'kwgn config
+// validate' reports the problems itself.
+func requireValidConfig() {
+	if configErr != nil {
+		log.Fatalf("error: invalid configuration in %s:\n%v", configSource(), configErr)
+	}
+}
+
+// configSource names the config file in use, for messages
+func configSource() string {
+	if f := viper.ConfigFileUsed(); f != "" {
+		return f
+	}
+	return "the embedded default configuration"
 }
@@ -1,42 +1,210 @@
 package cmd
 
 import (
+	"context"
 	"log"
+	"net"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/aqlanhadi/kwgn/api"
+	"github.com/aqlanhadi/kwgn/extractor"
+	"github.com/aqlanhadi/kwgn/integrations/metrics"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/spf13/cobra"
 )
 
 var (
-	servePort string
+	serveHost       string
+	servePort       string
+	serveGRPCPort   string
+	serveDBURL      string
+	serveJobWorkers int
+	serveJobQueue   int
+	serveJobTTL     time.Duration
+	serveNoAuth     bool
+	serveMetrics    bool
+	serveUI         bool
+
+	serveTLSCert           string
+	serveTLSKey            string
+	serveReadHeaderTimeout time.Duration
+	serveReadTimeout       time.Duration
+	serveWriteTimeout      time.Duration
+	serveIdleTimeout       time.Duration
+	serveMaxBodyMB         int64
+	serveShutdownDelay     time.Duration
+	serveShutdownTimeout   time.Duration
 )
 
 var serveCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "Start HTTP API server",
-	Long:  `Starts the HTTP API server that accepts PDF files and returns extracted data as JSON.`,
+	Long: `Starts the HTTP API server that accepts PDF files and returns extracted data as JSON.
+
+With --db-url (or DATABASE_URL) the server also imports uploaded statements,
+serves and edits imported data, and reports on it:
+  POST /v1/import (form field 'file', optional force=true and statement_type)
+  GET /v1/accounts, GET /v1/accounts/{id}/statements
+  GET /v1/transactions?from=2024-01-01&tag=food&limit=50
+  PATCH /v1/transactions/{id} (JSON body with tags and/or data)
+  GET /v1/reports/monthly?from=2024-01-01&to=2024-12-31&by=tag&format=csv
+  GET /v1/networth?interval=month
+
+With --grpc-port, the kwgn.v1.Kwgn gRPC service (proto/kwgn/v1/kwgn.proto) is
+served on a second port: Extract, streaming ExtractBatch and streaming Import,
+with the same API keys (authorization: Bearer <key> metadata), TLS certificate
+and body limit as the HTTP server, and the standard gRPC health service.
+
+The web UI at /ui/ uploads, previews and imports statements, and browses and
+edits imported accounts and transactions; it asks for an API key in the browser.
+
+Requests need an API key (Authorization: Bearer <key>) with the endpoint's
+scope; create keys with 'kwgn apikey create'. Keys are read from api_keys in
+the config file and from the database, and the server refuses to start without
+any. --no-auth turns authentication off and, unless --host is given, only
+listens on localhost.
+
+On SIGINT or SIGTERM the server refuses new jobs and fails GET /v1/health/ready
+for --shutdown-delay. Within --shutdown-timeout it then finishes queued and
+running jobs, still answering requests so their status can be fetched, stops
+accepting connections and waits for in-flight requests.
+
+Prometheus metrics (extractions by statement type and outcome, durations, PDF
+pages, upload sizes, balance mismatches, skipped rows) are served without
+authentication at GET /metrics unless --metrics=false is given.
+
+Webhook endpoints listed under 'webhooks' in the config file are sent an event
+for every statement extracted or imported, every balance mismatch and every
+failed import.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Configure logging for server mode
 		log.SetOutput(os.Stdout)
 		log.SetFlags(log.Ltime | log.Lmsgprefix)
+		requireValidConfig()
 
 		// Create API server with configuration
 		cfg := api.DefaultConfig()
+		host := serveHost
+		if serveNoAuth && !cmd.Flags().Changed("host") {
+			host = "127.0.0.1" // Only expose an unauthenticated server when asked to
+		}
 		if servePort != "" {
-			cfg.Port = ":" + servePort
+			cfg.Port = net.JoinHostPort(host, servePort)
+		}
+		if serveGRPCPort != "" {
+			cfg.GRPCPort = net.JoinHostPort(host, serveGRPCPort)
 		}
 		cfg.LogPrefix = "SERVER: "
+		cfg.JobWorkers = serveJobWorkers
+		cfg.JobQueueSize = serveJobQueue
+		cfg.JobTTL = serveJobTTL
+		cfg.TLSCertFile = serveTLSCert
+		cfg.TLSKeyFile = serveTLSKey
+		cfg.ReadHeaderTimeout = serveReadHeaderTimeout
+		cfg.ReadTimeout = serveReadTimeout
+		cfg.WriteTimeout = serveWriteTimeout
+		cfg.IdleTimeout = serveIdleTimeout
+		cfg.MaxBodyBytes = serveMaxBodyMB << 20
+		cfg.ShutdownDelay = serveShutdownDelay
+		cfg.ShutdownTimeout = serveShutdownTimeout
+
+		keys, err := configAPIKeys()
+		if err != nil {
+			log.Fatalf("error: %v", err)
+		}
+		cfg.Keys = keys
+		cfg.UI = serveUI
+		cfg.RequireAuth = !serveNoAuth
+		if serveNoAuth && host == "127.0.0.1" {
+			log.Printf("%sWARNING: authentication is off, listening on localhost only", cfg.LogPrefix)
+		} else if serveNoAuth {
+			log.Printf("%sWARNING: authentication is off and the server is reachable on %s", cfg.LogPrefix, cfg.Port)
+		}
+
+		// The database is optional; without it only extraction endpoints are available
+		if serveDBURL != "" || os.Getenv("DATABASE_URL") != "" {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			db := connectExistingDatabase(ctx, serveDBURL)
+			cancel()
+			defer db.Close()
+			cfg.Store = db
+		}
+		if cfg.RequireAuth {
+			checkAPIKeys(cfg)
+		}
+		var observers []func(extractor.Extraction)
+		if serveMetrics {
+			cfg.Metrics = metrics.New(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+			observers = append(observers, cfg.Metrics.ObserveExtraction)
+		}
+		cfg.Webhooks = startWebhooks()
+		if cfg.Webhooks != nil {
+			observers = append(observers, cfg.Webhooks.ObserveExtraction)
+		}
+		observeExtractions(observers...)
 
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
 		server := api.New(cfg)
-		if err := server.Start(); err != nil {
-			log.Fatalf("Failed to start server: %v", err)
+		err = server.Run(ctx)
+		stopWebhooks(cfg.Webhooks)
+		if err != nil {
+			log.Fatalf("Server error: %v", err)
 		}
 	},
 }
 
+// checkAPIKeys makes sure keys can be looked up, warning when none is active yet
+func checkAPIKeys(cfg api.Config) {
+	active := len(cfg.Keys)
+	if cfg.Store == nil {
+		if active == 0 {
+			log.Fatal("error: no API keys; create one with 'kwgn apikey create' and add it to the config file (or use --db-url), or pass --no-auth to serve localhost only")
+		}
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	stored, err := cfg.Store.ListAPIKeys(ctx)
+	if err != nil {
+		log.Fatalf("error: %v (run 'kwgn db migrate up' if the schema is behind)", err)
+	}
+	for _, key := range stored {
+		if !key.Revoked() {
+			active++
+		}
+	}
+	if active == 0 {
+		log.Printf("%sWARNING: no active API keys, every request will be rejected until one is created with 'kwgn apikey create'", cfg.LogPrefix)
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(serveCmd)
 
+	defaults := api.DefaultConfig()
+
+	serveCmd.Flags().StringVar(&serveHost, "host", "", "Address to listen on (default: all interfaces, or 127.0.0.1 with --no-auth)")
 	serveCmd.Flags().StringVarP(&servePort, "port", "p", "8080", "Port to run the API server on")
+	serveCmd.Flags().StringVar(&serveGRPCPort, "grpc-port", "", "Also serve the gRPC service (extract, batch extract, import) on this port")
+	serveCmd.Flags().IntVar(&serveJobWorkers, "job-workers", 2, "Number of extraction jobs processed concurrently")
+	serveCmd.Flags().IntVar(&serveJobQueue, "job-queue", 100, "Maximum number of jobs waiting to be processed")
+	serveCmd.Flags().DurationVar(&serveJobTTL, "job-ttl", time.Hour, "How long finished jobs are kept (0 = until restart)")
+	serveCmd.Flags().BoolVar(&serveNoAuth, "no-auth", false, "Serve without API keys, listening on localhost unless --host is given")
+	serveCmd.Flags().BoolVar(&serveMetrics, "metrics", true, "Serve Prometheus metrics at /metrics")
+	serveCmd.Flags().BoolVar(&serveUI, "ui", true, "Serve the web UI at /ui/")
+	serveCmd.Flags().StringVar(&serveTLSCert, "tls-cert", "", "TLS certificate file (PEM); serves HTTPS together with --tls-key")
+	serveCmd.Flags().StringVar(&serveTLSKey, "tls-key", "", "TLS private key file (PEM)")
+	serveCmd.Flags().DurationVar(&serveReadHeaderTimeout, "read-header-timeout", defaults.ReadHeaderTimeout, "Time allowed to read request headers")
+	serveCmd.Flags().DurationVar(&serveReadTimeout, "read-timeout", defaults.ReadTimeout, "Time allowed to read a whole request, upload included (0 = no limit)")
+	serveCmd.Flags().DurationVar(&serveWriteTimeout, "write-timeout", defaults.WriteTimeout, "Time allowed to handle a request and write the response (0 = no limit)")
+	serveCmd.Flags().DurationVar(&serveIdleTimeout, "idle-timeout", defaults.IdleTimeout, "How long idle keep-alive connections are kept open")
+	serveCmd.Flags().Int64Var(&serveMaxBodyMB, "max-body-mb", defaults.MaxBodyBytes>>20, "Largest request body accepted in MB; larger uploads get 413 (0 = no limit)")
+	serveCmd.Flags().DurationVar(&serveShutdownDelay, "shutdown-delay", defaults.ShutdownDelay, "How long readiness fails before shutting down, for load balancers to notice")
+	serveCmd.Flags().DurationVar(&serveShutdownTimeout, "shutdown-timeout", defaults.ShutdownTimeout, "How long in-flight requests get to finish on shutdown")
+	serveCmd.Flags().StringVar(&serveDBURL, "db-url", "", "PostgreSQL URL or sqlite:<path> for the report and net worth endpoints and API keys (or set DATABASE_URL env)")
 }
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/extractor"
+	"github.com/aqlanhadi/kwgn/integrations/webhook"
+	"github.com/spf13/viper"
+)
+
+// webhookFlushTimeout is how long commands wait for queued webhook events on exit
+const webhookFlushTimeout = 30 * time.Second
+
+// configWebhooks reads the 'webhooks' section of the config file
+func configWebhooks() (webhook.Config, error) {
+	cfg := webhook.DefaultConfig()
+	var section struct {
+		Endpoints []struct {
+			URL     string            `mapstructure:"url"`
+			Secret  string            `mapstructure:"secret"`
+			Events  []string          `mapstructure:"events"`
+			Headers map[string]string `mapstructure:"headers"`
+		} `mapstructure:"endpoints"`
+		MaxAttempts    *int           `mapstructure:"max_attempts"`
+		InitialBackoff *time.Duration `mapstructure:"initial_backoff"`
+		MaxBackoff     *time.Duration `mapstructure:"max_backoff"`
+		Timeout        *time.Duration `mapstructure:"timeout"`
+		QueueSize      *int           `mapstructure:"queue_size"`
+		DeadLetterFile string         `mapstructure:"dead_letter_file"`
+	}
+	if err := viper.UnmarshalKey("webhooks", &section); err != nil {
+		return cfg, fmt.Errorf("invalid webhooks in config: %w", err)
+	}
+
+	for _, e := range section.Endpoints {
+		cfg.Endpoints = append(cfg.Endpoints, webhook.Endpoint{URL: e.URL, Secret: e.Secret, Events: e.Events, Headers: e.Headers})
+	}
+	if section.MaxAttempts != nil {
+		cfg.MaxAttempts = *section.MaxAttempts
+	}
+	if section.InitialBackoff != nil {
+		cfg.InitialBackoff = *section.InitialBackoff
+	}
+	if section.MaxBackoff != nil {
+		cfg.MaxBackoff = *section.MaxBackoff
+	}
+	if section.Timeout != nil {
+		cfg.Timeout = *section.Timeout
+	}
+	if section.QueueSize != nil {
+		cfg.QueueSize = *section.QueueSize
+	}
+	cfg.DeadLetterFile = section.DeadLetterFile
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("webhooks: %w", err)
+	}
+	return cfg, nil
+}
+
+// startWebhooks starts delivering events to the endpoints in the config file.
+// It returns nil when none are configured; invalid settings are fatal.
+func startWebhooks() *webhook.Notifier {
+	cfg, err := configWebhooks()
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	if len(cfg.Endpoints) == 0 {
+		return nil
+	}
+	n, err := webhook.New(cfg)
+	if err != nil {
+		log.Fatalf("error: webhooks: %v", err)
+	}
+	log.Printf("%sSending events to %d endpoint(s)", cfg.LogPrefix, len(cfg.Endpoints))
+	return n
+}
+
+// stopWebhooks waits for queued events to be delivered; what is left after
+// webhookFlushTimeout is dead-lettered
+func stopWebhooks(n *webhook.Notifier) {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookFlushTimeout)
+	defer cancel()
+	n.Close(ctx)
+}
+
+// observeExtractions sets extractor.OnExtract to call every observer
+func observeExtractions(observers ...func(extractor.Extraction)) {
+	switch len(observers) {
+	case 0:
+	case 1:
+		extractor.OnExtract = observers[0]
+	default:
+		extractor.OnExtract = func(e extractor.Extraction) {
+			for _, o := range observers {
+				o(e)
+			}
+		}
+	}
+}
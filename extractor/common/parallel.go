@@ -0,0 +1,44 @@
+package common
+
+import (
+	"runtime"
+	"sync"
+)
+
+// ForEach calls fn for every index in [0, n) using at most jobs concurrent workers.
+// A jobs value below 1 uses one worker per CPU. Callers that need deterministic
+// output should write results into a slice at index i rather than appending.
+func ForEach(n int, jobs int, fn func(i int)) {
+	if jobs < 1 {
+		jobs = runtime.NumCPU()
+	}
+	if jobs > n {
+		jobs = n
+	}
+
+	// Run inline when there is nothing to parallelise
+	if jobs <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+}
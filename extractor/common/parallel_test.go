@@ -0,0 +1,66 @@
+package common
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForEach_VisitsEveryIndexOnce(t *testing.T) {
+	const n = 100
+	var counts [n]int32
+
+	ForEach(n, 8, func(i int) {
+		atomic.AddInt32(&counts[i], 1)
+	})
+
+	for i, c := range counts {
+		if c != 1 {
+			t.Errorf("Expected index %d to be visited once, got %d", i, c)
+		}
+	}
+}
+
+func TestForEach_RespectsJobLimit(t *testing.T) {
+	var running, peak int32
+	var mu sync.Mutex
+
+	ForEach(20, 3, func(i int) {
+		cur := atomic.AddInt32(&running, 1)
+		mu.Lock()
+		if cur > peak {
+			peak = cur
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+	})
+
+	if peak > 3 {
+		t.Errorf("Expected at most 3 concurrent workers, got %d", peak)
+	}
+}
+
+func TestForEach_SingleJobKeepsOrder(t *testing.T) {
+	var order []int
+
+	ForEach(5, 1, func(i int) {
+		order = append(order, i)
+	})
+
+	for i, v := range order {
+		if v != i {
+			t.Fatalf("Expected sequential order, got %v", order)
+		}
+	}
+}
+
+func TestForEach_ZeroItems(t *testing.T) {
+	called := false
+	ForEach(0, 4, func(i int) { called = true })
+
+	if called {
+		t.Error("Expected fn not to be called for zero items")
+	}
+}
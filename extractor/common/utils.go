@@ -13,6 +13,12 @@ import (
 
 // ExtractRowsFromPDFReader reads a PDF from an io.Reader and returns text rows
 func ExtractRowsFromPDFReader(reader io.Reader) (*[]string, error) {
+	rows, _, err := ReadPDF(reader)
+	return rows, err
+}
+
+// ReadPDF reads a PDF from an io.Reader and returns its text rows and page count
+func ReadPDF(reader io.Reader) (*[]string, int, error) {
 	var rAt io.ReaderAt
 	var size int64
 
@@ -25,12 +31,12 @@ func ExtractRowsFromPDFReader(reader io.Reader) (*[]string, error) {
 			seeker.Seek(cur, io.SeekStart)
 			size = end
 		} else {
-			return nil, errors.New("reader is io.ReaderAt but not io.Seeker, cannot determine size")
+			return nil, 0, errors.New("reader is io.ReaderAt but not io.Seeker, cannot determine size")
 		}
 	default:
 		buf := new(bytes.Buffer)
 		if _, err := buf.ReadFrom(reader); err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		b := buf.Bytes()
 		rAt = bytes.NewReader(b)
@@ -39,7 +45,7 @@ func ExtractRowsFromPDFReader(reader io.Reader) (*[]string, error) {
 
 	r, err := pdf.NewReader(rAt, size)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	numPages := r.NumPage()
@@ -67,7 +73,7 @@ func ExtractRowsFromPDFReader(reader io.Reader) (*[]string, error) {
 		}
 	}
 
-	return &extractedRows, nil
+	return &extractedRows, numPages, nil
 }
 
 // ExtractRowsFromPDF reads a PDF file and returns text rows
@@ -7,18 +7,22 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
+	"time"
 
 	"github.com/aqlanhadi/kwgn/extractor/common"
 	"github.com/aqlanhadi/kwgn/extractor/mbb_2_cc"
 	"github.com/aqlanhadi/kwgn/extractor/mbb_mae_and_casa"
+	"github.com/aqlanhadi/kwgn/extractor/settings"
 	"github.com/aqlanhadi/kwgn/extractor/tng"
 	"github.com/aqlanhadi/kwgn/extractor/tng_csv_export"
 	"github.com/aqlanhadi/kwgn/extractor/tng_email"
-	"github.com/spf13/viper"
 )
 
+// Version identifies the extractor build that produced a statement.
+// It is recorded alongside imported files and set from the binary version at startup.
+var Version = "dev"
+
 // Removed StatementWithoutTransactions struct as we'll use maps for marshalling
 
 // CreateFinalOutput prepares the data structure for JSON marshalling based on flags.
@@ -81,36 +85,60 @@ func CreateFinalOutput(stmt common.Statement, transactionOnly bool, statementOnl
 	return outputMap
 }
 
-func ExecuteAgainstPath(path string, transactionOnly bool, statementOnly bool, statementType string, textOnly bool) {
-	if info, err := os.Stat(path); err == nil && info.IsDir() {
+// ExecuteOptions configures ExecuteAgainstPath
+type ExecuteOptions struct {
+	TransactionOnly bool
+	StatementOnly   bool
+	StatementType   string
+	TextOnly        bool
+	Jobs            int // Number of files processed concurrently in directory mode (<1 = one per CPU)
+	Scan            ScanOptions
+}
+
+func ExecuteAgainstPath(path string, opts ExecuteOptions) {
+	transactionOnly := opts.TransactionOnly
+	statementOnly := opts.StatementOnly
+	statementType := opts.StatementType
+	textOnly := opts.TextOnly
+
+	// Directories and zip archives are expanded into their statement files
+	if info, err := os.Stat(path); err == nil && (info.IsDir() || IsZipFile(path)) {
+
+		inputs, err := CollectInputs(path, opts.Scan)
+		if err != nil {
+			log.Fatal(err)
+		}
 
 		if textOnly {
 			// For text-only extraction from directory
-			entries, err := os.ReadDir(path)
-			if err != nil {
-				log.Fatal(err)
-			}
-			allTexts := []map[string]string{}
-			for _, e := range entries {
-				filePath := filepath.Join(path, e.Name())
-				f, err := os.Open(filePath)
+			// Results are stored by index so output order matches directory order
+			texts := make([]map[string]string, len(inputs))
+			common.ForEach(len(inputs), opts.Jobs, func(i int) {
+				name := inputs[i].Name()
+				f, err := inputs[i].Open()
 				if err != nil {
-					log.Printf("Failed to open file %s: %v", e.Name(), err)
-					continue
+					log.Printf("Failed to open file %s: %v", name, err)
+					return
 				}
 				defer f.Close()
 
 				rows, err := common.ExtractRowsFromPDFReader(f)
 				if err != nil || len(*rows) < 1 {
-					log.Printf("Error or no text found in %s: %v", e.Name(), err)
-					continue
+					log.Printf("Error or no text found in %s: %v", name, err)
+					return
 				}
 
-				text := strings.Join(*rows, "\n")
-				allTexts = append(allTexts, map[string]string{
-					"filename": e.Name(),
-					"text":     text,
-				})
+				texts[i] = map[string]string{
+					"filename": name,
+					"text":     strings.Join(*rows, "\n"),
+				}
+			})
+
+			allTexts := []map[string]string{}
+			for _, text := range texts {
+				if text != nil {
+					allTexts = append(allTexts, text)
+				}
 			}
 
 			as_json, _ := json.MarshalIndent(allTexts, "", "  ")
@@ -118,23 +146,17 @@ func ExecuteAgainstPath(path string, transactionOnly bool, statementOnly bool, s
 			return
 		}
 
-		processedStatements := []common.Statement{}
+		results := make([][]common.Statement, len(inputs))
+		common.ForEach(len(inputs), opts.Jobs, func(i int) {
+			results[i] = ProcessInput(inputs[i], statementType)
+		})
 
-		entries, err := os.ReadDir(path)
-		if err != nil {
-			log.Fatal(err)
-		}
-		for _, e := range entries {
-			filePath := filepath.Join(path, e.Name())
-			f, err := os.Open(filePath)
-			if err != nil {
-				log.Printf("Failed to open file %s: %v", e.Name(), err)
-				continue
-			}
-			defer f.Close()
-			statement := ProcessReader(f, filePath, statementType)
-			if len(statement.Transactions) > 0 || statement.Account.AccountNumber != "" { // Process if transactions or account found
-				processedStatements = append(processedStatements, statement)
+		processedStatements := []common.Statement{}
+		for _, statements := range results {
+			for _, statement := range statements {
+				if len(statement.Transactions) > 0 || statement.Account.AccountNumber != "" { // Process if transactions or account found
+					processedStatements = append(processedStatements, statement)
+				}
 			}
 		}
 
@@ -294,11 +316,18 @@ func detectCSVType(reader io.Reader) (string, io.Reader, error) {
 
 // ProcessCSVFile processes a CSV file and returns statements
 func ProcessCSVFile(reader io.Reader, filename string, statementType string) ([]common.Statement, error) {
+	statements, _, _, err := processCSVFile(reader, filename, statementType)
+	return statements, err
+}
+
+// processCSVFile processes a CSV file and also returns the (possibly detected) statement
+// type and the number of rows skipped
+func processCSVFile(reader io.Reader, filename string, statementType string) ([]common.Statement, string, int, error) {
 	// If no type specified, try to detect from header
 	if statementType == "" {
 		detectedType, newReader, err := detectCSVType(reader)
 		if err != nil {
-			return nil, fmt.Errorf("failed to detect CSV type: %w", err)
+			return nil, "", 0, fmt.Errorf("failed to detect CSV type: %w", err)
 		}
 		statementType = detectedType
 		reader = newReader
@@ -307,9 +336,10 @@ func ProcessCSVFile(reader io.Reader, filename string, statementType string) ([]
 	// Route to appropriate CSV extractor
 	switch statementType {
 	case "TNG_CSV_EXPORT":
-		return tng_csv_export.ExtractMulti(reader, filename)
+		statements, skipped, err := tng_csv_export.ExtractMultiSkipped(reader, filename)
+		return statements, statementType, skipped, err
 	default:
-		return nil, fmt.Errorf("unsupported CSV statement type: %s", statementType)
+		return nil, statementType, 0, fmt.Errorf("unsupported CSV statement type: %s", statementType)
 	}
 }
 
@@ -356,22 +386,49 @@ func processStatementByType(filename string, rows *[]string, account common.Acco
 
 // ProcessReaderMulti processes a PDF/CSV and returns multiple statements if applicable (e.g., CC with multiple cards, CSV with multiple accounts)
 func ProcessReaderMulti(reader io.Reader, filename string, statementType string) []common.Statement {
+	statements, _ := ProcessReaderDetect(reader, filename, statementType)
+	return statements
+}
+
+// ProcessReaderDetect works like ProcessReaderMulti but also returns the statement type
+// that produced the result (empty when nothing was extracted)
+func ProcessReaderDetect(reader io.Reader, filename string, statementType string) ([]common.Statement, string) {
+	start := time.Now()
+	e := Extraction{Filename: filename}
+	statements, detectedType := processReaderDetect(reader, filename, statementType, &e)
+	e.StatementType = detectedType
+	if e.StatementType == "" {
+		e.StatementType = statementType // Overridden but nothing extracted
+	}
+	report(e, start, statements)
+	return statements, detectedType
+}
+
+// processReaderDetect implements ProcessReaderDetect, recording pages read, rows
+// skipped and read errors in e
+func processReaderDetect(reader io.Reader, filename string, statementType string, e *Extraction) ([]common.Statement, string) {
 	// Handle CSV files
 	if IsCSVFile(filename) {
-		statements, err := ProcessCSVFile(reader, filename, statementType)
+		statements, detectedType, skipped, err := processCSVFile(reader, filename, statementType)
+		e.RowsSkipped = skipped
 		if err != nil {
 			log.Printf("Error processing CSV file %s: %v", filename, err)
-			return []common.Statement{}
+			e.Outcome = OutcomeError
+			return []common.Statement{}, ""
 		}
-		return statements
+		return statements, detectedType
 	}
 
 	// read file contents for PDF
-	rows, err := common.ExtractRowsFromPDFReader(reader)
+	rows, pages, err := common.ReadPDF(reader)
+	e.Pages = pages
 
 	if (err != nil) || (len(*rows) < 1) {
 		log.Printf("Error or no rows found in %s: %v", filename, err)
-		return []common.Statement{}
+		if err != nil {
+			e.Outcome = OutcomeError
+		}
+		return []common.Statement{}, ""
 	}
 
 	// For CC statements, check if multiple cards exist
@@ -380,7 +437,7 @@ func ProcessReaderMulti(reader io.Reader, filename string, statementType string)
 		if mbb_2_cc.HasMultipleCards(rows) || statementType == "MAYBANK_2_CC" {
 			statements := mbb_2_cc.ExtractMulti(filename, rows)
 			if len(statements) > 0 {
-				return statements
+				return statements, "MAYBANK_2_CC"
 			}
 		}
 	}
@@ -390,16 +447,16 @@ func ProcessReaderMulti(reader io.Reader, filename string, statementType string)
 	text := strings.Join(*rows, "\n")
 
 	// Check if accounts configuration exists
-	accountsConfig := viper.Get("accounts")
+	accounts := settings.Current().Accounts
 
-	if accountsConfig == nil || len(accountsConfig.([]interface{})) == 0 {
+	if len(accounts) == 0 {
 		// If statementType is provided, process without account matching
 		if statementType != "" {
 			result := processStatementByType(filename, rows, common.Account{}, statementType)
 			if result.Account.AccountNumber != "" || len(result.Transactions) > 0 {
-				return []common.Statement{result}
+				return []common.Statement{result}, statementType
 			}
-			return []common.Statement{}
+			return []common.Statement{}, ""
 		}
 
 		// No accounts config and no statementType override - try all statement types
@@ -412,113 +469,80 @@ func ProcessReaderMulti(reader io.Reader, filename string, statementType string)
 			}
 			result := processStatementByType(filename, rows, common.Account{}, stmtType)
 			if len(result.Transactions) > 0 || result.Account.AccountNumber != "" {
-				return []common.Statement{result}
+				return []common.Statement{result}, stmtType
 			}
 		}
 
-		return []common.Statement{}
+		return []common.Statement{}, ""
 	}
 
-	accounts := accountsConfig.([]interface{})
-
 	// Check for statementType override first
 	if statementType != "" {
 		for _, acc := range accounts {
-			accountMap := acc.(map[string]interface{})
-			if configName, ok := accountMap["statement_config"].(string); ok && configName == statementType {
-				account := common.Account{
-					AccountNumber: accountMap["number"].(string),
-					AccountType:   accountMap["type"].(string),
-					AccountName:   accountMap["name"].(string),
-					DebitCredit:   accountMap["drcr"].(string),
-					Reconciliable: accountMap["reconciliable"].(bool),
-				}
-				result := processStatementByType(filename, rows, account, statementType)
-				return []common.Statement{result}
+			if acc.StatementConfig == statementType {
+				result := processStatementByType(filename, rows, configuredAccount(acc), statementType)
+				return []common.Statement{result}, statementType
 			}
 		}
 		log.Printf("Warning: Statement type override '%s' provided, but no matching configuration found.", statementType)
 		result := processStatementByType(filename, rows, common.Account{}, statementType)
 		if result.Account.AccountNumber != "" || len(result.Transactions) > 0 {
-			return []common.Statement{result}
+			return []common.Statement{result}, statementType
 		}
-		return []common.Statement{}
+		return []common.Statement{}, ""
 	}
 
 	// Original logic: loop accounts to find match based on regex
 	for _, acc := range accounts {
-		accountMap := acc.(map[string]interface{})
-		accountRegex := regexp.MustCompile(accountMap["regex_identifier"].(string))
-
-		if accountRegex.Match([]byte(text)) {
-			account := common.Account{
-				AccountNumber: accountMap["number"].(string),
-				AccountType:   accountMap["type"].(string),
-				AccountName:   accountMap["name"].(string),
-				DebitCredit:   accountMap["drcr"].(string),
-				Reconciliable: accountMap["reconciliable"].(bool),
-			}
-			result := processStatementByType(filename, rows, account, accountMap["statement_config"].(string))
-			return []common.Statement{result}
+		if acc.Identifier.MatchString(text) {
+			result := processStatementByType(filename, rows, configuredAccount(acc), acc.StatementConfig)
+			return []common.Statement{result}, acc.StatementConfig
 		}
 	}
 
-	return []common.Statement{}
+	return []common.Statement{}, ""
 }
 
 func ProcessReader(reader io.Reader, filename string, statementType string) common.Statement {
+	start := time.Now()
+	e := Extraction{Filename: filename}
+	statement := processReader(reader, filename, statementType, &e)
+	report(e, start, []common.Statement{statement})
+	return statement
+}
+
+// processReader implements ProcessReader, recording the statement type used,
+// pages read and read errors in e
+func processReader(reader io.Reader, filename string, statementType string, e *Extraction) common.Statement {
+	extract := func(rows *[]string, account common.Account, statementConfigName string) common.Statement {
+		e.StatementType = statementConfigName
+		return processStatementByType(filename, rows, account, statementConfigName)
+	}
+
 	// read file contents
-	rows, err := common.ExtractRowsFromPDFReader(reader)
+	rows, pages, err := common.ReadPDF(reader)
+	e.Pages = pages
 
 	if (err != nil) || (len(*rows) < 1) {
 		log.Printf("Error or no rows found in %s: %v", filename, err)
+		if err != nil {
+			e.Outcome = OutcomeError
+		}
 		return common.Statement{}
 	}
 
 	text := strings.Join(*rows, "\n")
 
 	// Check if accounts configuration exists
-	accountsConfig := viper.Get("accounts")
+	accounts := settings.Current().Accounts
 
-	if accountsConfig == nil {
-		// If statementType is provided, process without account matching
-		if statementType != "" {
-			return processStatementByType(filename, rows, common.Account{}, statementType)
-		}
-
-		// No accounts config and no statementType override - try all statement types
-		statementTypes := []string{"MAYBANK_CASA_AND_MAE", "MAYBANK_2_CC", "TNG", "TNG_EMAIL"}
-
-		for _, stmtType := range statementTypes {
-			result := processStatementByType(filename, rows, common.Account{}, stmtType)
-
-			// Check if we got a successful result (has transactions or account info)
-			if len(result.Transactions) > 0 || result.Account.AccountNumber != "" {
-				return result
-			}
-		}
-
-		return common.Statement{}
-	}
-
-	accounts, ok := accountsConfig.([]interface{})
-	if !ok {
-		log.Printf("Invalid accounts configuration format (not a slice)")
-		// If statementType is provided, process without account matching
-		if statementType != "" {
-			return processStatementByType(filename, rows, common.Account{}, statementType)
-		}
-		return common.Statement{}
-	}
-
-	// Check if accounts array is empty
 	if len(accounts) == 0 {
 		// If statementType is provided, process without account matching
 		if statementType != "" {
-			return processStatementByType(filename, rows, common.Account{}, statementType)
+			return extract(rows, common.Account{}, statementType)
 		}
 
-		// Empty accounts config and no statementType override - try all statement types
+		// No accounts config and no statementType override - try all statement types
 		statementTypes := []string{"MAYBANK_CASA_AND_MAE", "MAYBANK_2_CC", "TNG", "TNG_EMAIL"}
 
 		for _, stmtType := range statementTypes {
@@ -526,6 +550,7 @@ func ProcessReader(reader io.Reader, filename string, statementType string) comm
 
 			// Check if we got a successful result (has transactions or account info)
 			if len(result.Transactions) > 0 || result.Account.AccountNumber != "" {
+				e.StatementType = stmtType
 				return result
 			}
 		}
@@ -533,49 +558,36 @@ func ProcessReader(reader io.Reader, filename string, statementType string) comm
 		return common.Statement{}
 	}
 
-	// loop accounts to find match
-
 	// Check for statementType override first
 	if statementType != "" {
-		foundOverride := false
 		for _, acc := range accounts {
-			accountMap := acc.(map[string]interface{})
-			if configName, ok := accountMap["statement_config"].(string); ok && configName == statementType {
-				account := common.Account{
-					AccountNumber: accountMap["number"].(string),
-					AccountType:   accountMap["type"].(string),
-					AccountName:   accountMap["name"].(string),
-					DebitCredit:   accountMap["drcr"].(string),
-					Reconciliable: accountMap["reconciliable"].(bool),
-				}
+			if acc.StatementConfig == statementType {
 				// Directly process based on the overridden statement type
-				return processStatementByType(filename, rows, account, statementType) // Call helper function
+				return extract(rows, configuredAccount(acc), statementType)
 			}
 		}
-		if !foundOverride {
-			log.Printf("Warning: Statement type override '%s' provided, but no matching configuration found. Processing without account details.", statementType)
-			return processStatementByType(filename, rows, common.Account{}, statementType)
-		}
-	} else {
-		// Original logic: loop accounts to find match based on regex
-		for _, acc := range accounts {
-			accountMap := acc.(map[string]interface{})
-			accountRegex := regexp.MustCompile(accountMap["regex_identifier"].(string))
-
-			if accountRegex.Match([]byte(text)) {
-				account := common.Account{
-					AccountNumber: accountMap["number"].(string),
-					AccountType:   accountMap["type"].(string),
-					AccountName:   accountMap["name"].(string),
-					DebitCredit:   accountMap["drcr"].(string),
-					Reconciliable: accountMap["reconciliable"].(bool),
-				}
+		log.Printf("Warning: Statement type override '%s' provided, but no matching configuration found. Processing without account details.", statementType)
+		return extract(rows, common.Account{}, statementType)
+	}
 
-				// process based on statement config from the matched account
-				return processStatementByType(filename, rows, account, accountMap["statement_config"].(string)) // Call helper function
-			}
+	// loop accounts to find match based on regex
+	for _, acc := range accounts {
+		if acc.Identifier.MatchString(text) {
+			// process based on statement config from the matched account
+			return extract(rows, configuredAccount(acc), acc.StatementConfig)
 		}
 	}
 
 	return common.Statement{}
 }
+
+// configuredAccount returns the details of an account from the config file
+func configuredAccount(acc settings.Account) common.Account {
+	return common.Account{
+		AccountNumber: acc.Number,
+		AccountType:   acc.Type,
+		AccountName:   acc.Name,
+		DebitCredit:   acc.DebitCredit,
+		Reconciliable: acc.Reconciliable,
+	}
+}
@@ -0,0 +1,231 @@
+package extractor
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aqlanhadi/kwgn/extractor/common"
+)
+
+// ScanOptions controls how a directory (or zip archive) is scanned for statement files
+type ScanOptions struct {
+	Recursive bool     // Descend into sub-directories
+	Include   []string // Glob patterns; when set, a file must match at least one
+	Exclude   []string // Glob patterns; matching files and directories are skipped
+}
+
+// Input is a statement file found on disk or inside a zip archive, or already in
+// memory (such as an upload)
+type Input struct {
+	Path   string // File on disk (the archive itself for zip members)
+	Member string // Slash-separated path inside the archive, empty for plain files
+	Data   []byte // Contents held in memory; Path and Member then only name the file
+}
+
+// Name returns a short label for logs and error messages
+func (in Input) Name() string {
+	if in.Member == "" {
+		return filepath.Base(in.Path)
+	}
+	return filepath.Base(in.Path) + "/" + in.Member
+}
+
+// Location returns the full path handed to the extractors (used for type and source detection)
+func (in Input) Location() string {
+	if in.Member == "" {
+		return in.Path
+	}
+	return in.Path + "/" + in.Member
+}
+
+// Source returns the statement source recorded for this input.
+// Zip members keep their archive path so statements can be traced back to the bundle.
+func (in Input) Source() string {
+	if in.Member == "" {
+		return strings.TrimSuffix(filepath.Base(in.Path), filepath.Ext(in.Path))
+	}
+	return filepath.Base(in.Path) + "/" + strings.TrimSuffix(in.Member, path.Ext(in.Member))
+}
+
+// Open returns a reader for the input's contents.
+// Plain files are returned as *os.File so PDFs can be read without buffering.
+func (in Input) Open() (io.ReadCloser, error) {
+	if in.Data == nil && in.Member == "" {
+		return os.Open(in.Path)
+	}
+	data, err := in.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// ReadAll returns the full contents of the input
+func (in Input) ReadAll() ([]byte, error) {
+	if in.Data != nil {
+		return in.Data, nil
+	}
+	if in.Member == "" {
+		return os.ReadFile(in.Path)
+	}
+
+	archive, err := zip.OpenReader(in.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer archive.Close()
+
+	f, err := archive.Open(in.Member)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive member: %w", err)
+	}
+	defer f.Close()
+
+	return io.ReadAll(f)
+}
+
+// IsZipFile checks if the file is a zip archive based on extension
+func IsZipFile(filename string) bool {
+	return strings.HasSuffix(strings.ToLower(filename), ".zip")
+}
+
+// IsStatementFile checks if the file has a supported statement extension (PDF or CSV)
+func IsStatementFile(filename string) bool {
+	lower := strings.ToLower(filename)
+	return strings.HasSuffix(lower, ".pdf") || strings.HasSuffix(lower, ".csv")
+}
+
+// CollectInputs lists the statement files under root in a deterministic (lexical) order.
+// root may be a directory, a zip archive or a single file. A single plain file is
+// always returned as-is; directory and archive entries are filtered by extension and
+// by the include/exclude patterns in opts.
+func CollectInputs(root string, opts ScanOptions) ([]Input, error) {
+	for _, pattern := range append(append([]string{}, opts.Include...), opts.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		if IsZipFile(root) {
+			return collectZipInputs(root, opts)
+		}
+		return []Input{{Path: root}}, nil
+	}
+
+	var inputs []Input
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(root, p)
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if !opts.Recursive || matchesAny(opts.Exclude, rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if matchesAny(opts.Exclude, rel) {
+			return nil
+		}
+
+		if IsZipFile(p) {
+			members, err := collectZipInputs(p, opts)
+			if err != nil {
+				return fmt.Errorf("%s: %w", rel, err)
+			}
+			inputs = append(inputs, members...)
+			return nil
+		}
+
+		if IsStatementFile(p) && (len(opts.Include) == 0 || matchesAny(opts.Include, rel)) {
+			inputs = append(inputs, Input{Path: p})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return inputs, nil
+}
+
+// collectZipInputs lists the statement files inside a zip archive.
+// Member directories are always descended into, since bundles are usually foldered by year.
+func collectZipInputs(archivePath string, opts ScanOptions) ([]Input, error) {
+	archive, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer archive.Close()
+
+	var inputs []Input
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() || !IsStatementFile(f.Name) {
+			continue
+		}
+		if matchesAny(opts.Exclude, f.Name) {
+			continue
+		}
+		if len(opts.Include) > 0 && !matchesAny(opts.Include, f.Name) {
+			continue
+		}
+		inputs = append(inputs, Input{Path: archivePath, Member: f.Name})
+	}
+
+	sort.Slice(inputs, func(i, j int) bool { return inputs[i].Member < inputs[j].Member })
+	return inputs, nil
+}
+
+// matchesAny reports whether the slash-separated path, or its base name, matches any pattern
+func matchesAny(patterns []string, name string) bool {
+	base := path.Base(name)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ProcessInput extracts all statements from a single input
+func ProcessInput(in Input, statementType string) []common.Statement {
+	r, err := in.Open()
+	if err != nil {
+		log.Printf("Failed to open file %s: %v", in.Name(), err)
+		return []common.Statement{}
+	}
+	defer r.Close()
+
+	statements := ProcessReaderMulti(r, in.Location(), statementType)
+	if in.Member != "" {
+		for i := range statements {
+			statements[i].Source = in.Source()
+		}
+	}
+	return statements
+}
@@ -0,0 +1,171 @@
+package extractor
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTree creates files (relative path -> contents) under dir
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// writeZip creates a zip archive at p with the given members
+func writeZip(t *testing.T, p string, members map[string]string) {
+	t.Helper()
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for name, content := range members {
+		mw, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mw.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func inputNames(inputs []Input) []string {
+	names := []string{}
+	for _, in := range inputs {
+		names = append(names, in.Name())
+	}
+	return names
+}
+
+func TestCollectInputs_TopLevelOnly(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"jan.pdf":        "a",
+		"feb.csv":        "b",
+		"notes.txt":      "c",
+		"2023/dec.pdf":   "d",
+		"2023/old/x.pdf": "e",
+	})
+
+	inputs, err := CollectInputs(dir, ScanOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	names := inputNames(inputs)
+	if len(names) != 2 || names[0] != "feb.csv" || names[1] != "jan.pdf" {
+		t.Errorf("Expected [feb.csv jan.pdf], got %v", names)
+	}
+}
+
+func TestCollectInputs_Recursive(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"jan.pdf":        "a",
+		"2023/dec.pdf":   "b",
+		"2023/old/x.pdf": "c",
+	})
+
+	inputs, err := CollectInputs(dir, ScanOptions{Recursive: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(inputs) != 3 {
+		t.Errorf("Expected 3 inputs, got %v", inputNames(inputs))
+	}
+}
+
+func TestCollectInputs_IncludeExclude(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"mbb_2024_01.pdf":     "a",
+		"mbb_2023_12.pdf":     "b",
+		"tng_2024.csv":        "c",
+		"drafts/mbb_2024.pdf": "d",
+	})
+
+	inputs, err := CollectInputs(dir, ScanOptions{
+		Recursive: true,
+		Include:   []string{"*2024*"},
+		Exclude:   []string{"drafts", "*.csv"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	names := inputNames(inputs)
+	if len(names) != 1 || names[0] != "mbb_2024_01.pdf" {
+		t.Errorf("Expected [mbb_2024_01.pdf], got %v", names)
+	}
+}
+
+func TestCollectInputs_InvalidPattern(t *testing.T) {
+	_, err := CollectInputs(t.TempDir(), ScanOptions{Include: []string{"[abc"}})
+	if err == nil {
+		t.Error("Expected error for invalid pattern, got nil")
+	}
+}
+
+func TestCollectInputs_ZipArchive(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "statements-2024.zip")
+	writeZip(t, archive, map[string]string{
+		"2024/jan.pdf":   "pdf bytes",
+		"2024/feb.pdf":   "feb bytes",
+		"2024/readme.md": "ignored",
+	})
+
+	inputs, err := CollectInputs(dir, ScanOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(inputs) != 2 {
+		t.Fatalf("Expected 2 zip members, got %v", inputNames(inputs))
+	}
+
+	in := inputs[0]
+	if in.Member != "2024/feb.pdf" {
+		t.Errorf("Expected member '2024/feb.pdf', got '%s'", in.Member)
+	}
+	if in.Source() != "statements-2024.zip/2024/feb" {
+		t.Errorf("Expected source 'statements-2024.zip/2024/feb', got '%s'", in.Source())
+	}
+
+	data, err := in.ReadAll()
+	if err != nil {
+		t.Fatalf("Unexpected error reading member: %v", err)
+	}
+	if string(data) != "feb bytes" {
+		t.Errorf("Expected member contents 'feb bytes', got '%s'", string(data))
+	}
+}
+
+func TestCollectInputs_SingleFile(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"statement.pdf": "a"})
+
+	inputs, err := CollectInputs(filepath.Join(dir, "statement.pdf"), ScanOptions{Include: []string{"*.csv"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// An explicitly named file is never filtered out
+	if len(inputs) != 1 || inputs[0].Source() != "statement" {
+		t.Errorf("Expected single input with source 'statement', got %v", inputNames(inputs))
+	}
+}
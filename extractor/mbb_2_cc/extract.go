@@ -9,36 +9,15 @@ import (
 	"time"
 
 	"github.com/aqlanhadi/kwgn/extractor/common"
+	"github.com/aqlanhadi/kwgn/extractor/settings"
 	"github.com/shopspring/decimal"
-	"github.com/spf13/viper"
 )
 
-type config struct {
-	StartingBalance *regexp.Regexp
-	EndingBalance   *regexp.Regexp
-	StatementDate   *regexp.Regexp
-	Transaction     *regexp.Regexp
-	AccountNumber   *regexp.Regexp
-	AccountName     *regexp.Regexp
-	AccountType     *regexp.Regexp
-	CreditSuffix    string
-	DateFormat      string
-	StatementFormat string
-}
+// config holds the statement.MAYBANK_2_CC.patterns settings
+type config = settings.MaybankCC
 
 func loadConfig() config {
-	return config{
-		StartingBalance: regexp.MustCompile(viper.GetString("statement.MAYBANK_2_CC.patterns.starting_balance")),
-		EndingBalance:   regexp.MustCompile(viper.GetString("statement.MAYBANK_2_CC.patterns.ending_balance")),
-		StatementDate:   regexp.MustCompile(viper.GetString("statement.MAYBANK_2_CC.patterns.statement_date")),
-		Transaction:     regexp.MustCompile(viper.GetString("statement.MAYBANK_2_CC.patterns.transaction")),
-		AccountNumber:   regexp.MustCompile(viper.GetString("statement.MAYBANK_2_CC.patterns.account_number")),
-		AccountName:     regexp.MustCompile(viper.GetString("statement.MAYBANK_2_CC.patterns.account_name")),
-		AccountType:     regexp.MustCompile(viper.GetString("statement.MAYBANK_2_CC.patterns.account_type")),
-		CreditSuffix:    viper.GetString("statement.MAYBANK_2_CC.patterns.credit_suffix"),
-		DateFormat:      viper.GetString("statement.MAYBANK_2_CC.patterns.date_format"),
-		StatementFormat: viper.GetString("statement.MAYBANK_2_CC.patterns.statement_format"),
-	}
+	return settings.Current().MaybankCC
 }
 
 func Extract(path string, rows *[]string) common.Statement {
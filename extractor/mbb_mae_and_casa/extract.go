@@ -3,44 +3,18 @@ package mbb_mae_and_casa
 import (
 	"log"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"time"
 
 	"github.com/aqlanhadi/kwgn/extractor/common"
-	"github.com/spf13/viper"
+	"github.com/aqlanhadi/kwgn/extractor/settings"
 )
 
-type config struct {
-	StartingBalance   *regexp.Regexp
-	EndingBalance     *regexp.Regexp
-	StatementDate     *regexp.Regexp
-	MainTxLine        *regexp.Regexp
-	DescTxLine        *regexp.Regexp
-	AccountNumber     *regexp.Regexp
-	AccountName       *regexp.Regexp
-	AccountType       *regexp.Regexp
-	CreditSuffix      string
-	DebitAmountSuffix string
-	DateFormat        string
-	StatementFormat   string
-}
+// config holds the statement.MAYBANK_CASA_AND_MAE.patterns settings
+type config = settings.MaybankCASA
 
 func loadConfig() config {
-	return config{
-		StartingBalance:   regexp.MustCompile(viper.GetString("statement.MAYBANK_CASA_AND_MAE.patterns.starting_balance")),
-		EndingBalance:     regexp.MustCompile(viper.GetString("statement.MAYBANK_CASA_AND_MAE.patterns.ending_balance")),
-		StatementDate:     regexp.MustCompile(viper.GetString("statement.MAYBANK_CASA_AND_MAE.patterns.statement_date")),
-		MainTxLine:        regexp.MustCompile(viper.GetString("statement.MAYBANK_CASA_AND_MAE.patterns.main_transaction_line")),
-		DescTxLine:        regexp.MustCompile(viper.GetString("statement.MAYBANK_CASA_AND_MAE.patterns.description_transaction_line")),
-		AccountNumber:     regexp.MustCompile(viper.GetString("statement.MAYBANK_CASA_AND_MAE.patterns.account_number")),
-		AccountName:       regexp.MustCompile(viper.GetString("statement.MAYBANK_CASA_AND_MAE.patterns.account_name")),
-		AccountType:       regexp.MustCompile(viper.GetString("statement.MAYBANK_CASA_AND_MAE.patterns.account_type")),
-		CreditSuffix:      viper.GetString("statement.MAYBANK_CASA_AND_MAE.patterns.credit_suffix"),
-		DebitAmountSuffix: viper.GetString("statement.MAYBANK_CASA_AND_MAE.patterns.amount_debit_suffix"),
-		DateFormat:        viper.GetString("statement.MAYBANK_CASA_AND_MAE.patterns.date_format"),
-		StatementFormat:   viper.GetString("statement.MAYBANK_CASA_AND_MAE.patterns.statement_format"),
-	}
+	return settings.Current().MaybankCASA
 }
 
 func Extract(path string, rows *[]string) common.Statement {
@@ -0,0 +1,64 @@
+package extractor
+
+import (
+	"time"
+
+	"github.com/aqlanhadi/kwgn/extractor/common"
+)
+
+// Outcomes of an Extraction
+const (
+	OutcomeOK    = "ok"    // At least one statement was extracted
+	OutcomeEmpty = "empty" // The file was read but no statement was recognised
+	OutcomeError = "error" // The file could not be read (not a PDF, unknown CSV format, ...)
+)
+
+// Extraction describes the extraction of one file, as reported to OnExtract
+type Extraction struct {
+	Filename          string
+	StatementType     string // Statement config used, empty when none was recognised
+	Outcome           string
+	Statements        int
+	Pages             int // PDF pages read, 0 for CSV files
+	Duration          time.Duration
+	BalanceMismatches int // Statements whose calculated ending balance differs from the stated one
+	RowsSkipped       int // Input rows dropped because they could not be parsed
+
+	Results []common.Statement // The recognised statements
+}
+
+// OnExtract, when set, is called after every file processed by ProcessReader,
+// ProcessReaderMulti or ProcessReaderDetect. It may be called concurrently.
+var OnExtract func(Extraction)
+
+// report completes e with the extracted statements and passes it to OnExtract
+func report(e Extraction, start time.Time, statements []common.Statement) {
+	if OnExtract == nil {
+		return
+	}
+	e.Duration = time.Since(start)
+
+	for _, stmt := range statements {
+		if len(stmt.Transactions) == 0 && stmt.Account.AccountNumber == "" {
+			continue // Nothing recognised
+		}
+		e.Statements++
+		e.Results = append(e.Results, stmt)
+		if BalanceMismatch(stmt) {
+			e.BalanceMismatches++
+		}
+	}
+	switch {
+	case e.Statements > 0:
+		e.Outcome = OutcomeOK
+	case e.Outcome == "":
+		e.Outcome = OutcomeEmpty
+	}
+	OnExtract(e)
+}
+
+// BalanceMismatch reports whether a statement's transactions fail to add up to its
+// stated ending balance
+func BalanceMismatch(stmt common.Statement) bool {
+	return len(stmt.Transactions) > 0 && !stmt.CalculatedEndingBalance.Equal(stmt.EndingBalance)
+}
@@ -0,0 +1,34 @@
+package extractor
+
+import (
+	"strings"
+	"testing"
+)
+
+const observeCSV = `MFG Number,Trans. No.,Transaction Date/Time,Posted Date,Trans. Type,Sector,Entry Location,Entry SP,Exit Location,Exit SP,Reload Location,Trans. Amount (RM),Balance (RM),Vehicle Class,Device No.,Transaction ID,Vehicle Number
+2222222222,1,2025-01-01 10:00:00,2025-01-02 00:00:00,Usage,TOLL,TOLL A,SP_A,TOLL A,SP_A,,10.00,90.00,00,,TX001,
+2222222222,2,not a date,2025-01-04 00:00:00,Usage,TOLL,TOLL A,SP_A,TOLL A,SP_A,,5.00,85.00,00,,TX002,
+2222222222,3,2025-01-03 10:00:00,2025-01-04 00:00:00,Reload,INTERNET RELOAD,OTA-TNGD,TD_TNG,OTA-TNGD,TD_TNG,OTA-TNGD,50.00,140.00,00,,TX003,`
+
+func TestOnExtract(t *testing.T) {
+	var got []Extraction
+	OnExtract = func(e Extraction) { got = append(got, e) }
+	defer func() { OnExtract = nil }()
+
+	ProcessReaderDetect(strings.NewReader(observeCSV), "tng.csv", "")
+	ProcessReaderDetect(strings.NewReader("not a pdf"), "statement.pdf", "")
+	ProcessReaderDetect(strings.NewReader("a,b\n1,2"), "other.csv", "")
+
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 extractions, got %d", len(got))
+	}
+	csv := got[0]
+	if csv.Outcome != OutcomeOK || csv.StatementType != "TNG_CSV_EXPORT" || csv.Statements != 1 || csv.RowsSkipped != 1 || csv.Pages != 0 {
+		t.Errorf("Unexpected CSV extraction: %+v", csv)
+	}
+	for _, e := range got[1:] {
+		if e.Outcome != OutcomeError || e.StatementType != "" || e.Statements != 0 {
+			t.Errorf("Expected %s to fail, got %+v", e.Filename, e)
+		}
+	}
+}
@@ -0,0 +1,359 @@
+// Package settings decodes the accounts and statement patterns of the config
+// file into typed structs, reporting every problem with its key path instead
+// of letting an extractor panic on a missing key or a bad pattern.
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/spf13/viper"
+)
+
+// StatementTypes lists the statement configs an account can use
+var StatementTypes = []string{"MAYBANK_CASA_AND_MAE", "MAYBANK_2_CC", "TNG", "TNG_EMAIL", "TNG_CSV_EXPORT"}
+
+// DebitCredit values an account's drcr may take
+var DebitCredit = []string{"debit", "credit"}
+
+// accountKeys are the keys an entry of 'accounts' may have
+var accountKeys = []string{"number", "name", "type", "drcr", "reconciliable", "regex_identifier", "statement_config"}
+
+// neverMatch stands in for required patterns that are unset or failed to compile
+var neverMatch = regexp.MustCompile(`[^\x00-\x{10FFFF}]`)
+
+// Account is an entry of 'accounts': statements whose text matches Identifier
+// are extracted with StatementConfig and given the account's details
+type Account struct {
+	Number          string
+	Name            string
+	Type            string
+	DebitCredit     string
+	Reconciliable   bool
+	Identifier      *regexp.Regexp
+	StatementConfig string
+}
+
+// MaybankCASA holds statement.MAYBANK_CASA_AND_MAE.patterns
+type MaybankCASA struct {
+	StartingBalance   *regexp.Regexp
+	EndingBalance     *regexp.Regexp
+	StatementDate     *regexp.Regexp
+	MainTxLine        *regexp.Regexp
+	DescTxLine        *regexp.Regexp
+	AccountNumber     *regexp.Regexp
+	AccountName       *regexp.Regexp
+	AccountType       *regexp.Regexp
+	CreditSuffix      string
+	DebitAmountSuffix string
+	DateFormat        string
+	StatementFormat   string
+}
+
+// MaybankCC holds statement.MAYBANK_2_CC.patterns
+type MaybankCC struct {
+	StartingBalance *regexp.Regexp
+	EndingBalance   *regexp.Regexp
+	StatementDate   *regexp.Regexp
+	Transaction     *regexp.Regexp
+	AccountNumber   *regexp.Regexp
+	AccountName     *regexp.Regexp
+	AccountType     *regexp.Regexp
+	CreditSuffix    string
+	DateFormat      string
+	StatementFormat string
+}
+
+// TNG holds statement.TNG.patterns
+type TNG struct {
+	Transaction         *regexp.Regexp
+	AmountNumbers       *regexp.Regexp
+	AccountNumber       *regexp.Regexp
+	AccountName         *regexp.Regexp
+	StatementDateRegex  *regexp.Regexp
+	TransactionDate     string
+	DebitSuffix         string
+	AccountType         string
+	StatementDateFormat string
+}
+
+// TNGEmail holds statement.TNG_EMAIL.patterns
+type TNGEmail struct {
+	Transaction            *regexp.Regexp
+	DatetimePattern        *regexp.Regexp
+	AccountNumber          *regexp.Regexp
+	AccountName            *regexp.Regexp
+	DatetimeFormat         string
+	DateFormat             string
+	AccountType            string
+	CreditTransactionTypes []string
+}
+
+// Config is the extractor configuration
+type Config struct {
+	Accounts    []Account
+	MaybankCASA MaybankCASA
+	MaybankCC   MaybankCC
+	TNG         TNG
+	TNGEmail    TNGEmail
+}
+
+// KeyError is a problem with the value at Key, such as "accounts[2].reconciliable"
+type KeyError struct {
+	Key string
+	Err error
+}
+
+func (e *KeyError) Error() string {
+	return e.Key + ": " + e.Err.Error()
+}
+
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// Load decodes and validates the configuration in v. The error joins a
+// *KeyError for every problem found; the Config is usable either way, with
+// unset and invalid patterns matching nothing and invalid accounts left out.
+//
+// Each extractor has patterns it cannot work without. They are required when
+// its statement section is present, and the section is required when an
+// account uses it.
+func Load(v *viper.Viper) (*Config, error) {
+	d := &decoder{v: v}
+	const casa, cc, tng, email = "statement.MAYBANK_CASA_AND_MAE.patterns.", "statement.MAYBANK_2_CC.patterns.",
+		"statement.TNG.patterns.", "statement.TNG_EMAIL.patterns."
+	cfg := &Config{
+		Accounts: d.accounts(),
+		MaybankCASA: MaybankCASA{
+			StartingBalance:   d.required(casa + "starting_balance"),
+			EndingBalance:     d.required(casa + "ending_balance"),
+			StatementDate:     d.required(casa + "statement_date"),
+			MainTxLine:        d.required(casa + "main_transaction_line"),
+			DescTxLine:        d.required(casa + "description_transaction_line"),
+			AccountNumber:     d.pattern(casa + "account_number"),
+			AccountName:       d.pattern(casa + "account_name"),
+			AccountType:       d.pattern(casa + "account_type"),
+			CreditSuffix:      d.str(casa + "credit_suffix"),
+			DebitAmountSuffix: d.str(casa + "amount_debit_suffix"),
+			DateFormat:        d.str(casa + "date_format"),
+			StatementFormat:   d.str(casa + "statement_format"),
+		},
+		MaybankCC: MaybankCC{
+			StartingBalance: d.required(cc + "starting_balance"),
+			EndingBalance:   d.required(cc + "ending_balance"),
+			StatementDate:   d.required(cc + "statement_date"),
+			Transaction:     d.required(cc + "transaction"),
+			AccountNumber:   d.pattern(cc + "account_number"),
+			AccountName:     d.pattern(cc + "account_name"),
+			AccountType:     d.pattern(cc + "account_type"),
+			CreditSuffix:    d.str(cc + "credit_suffix"),
+			DateFormat:      d.str(cc + "date_format"),
+			StatementFormat: d.str(cc + "statement_format"),
+		},
+		TNG: TNG{
+			Transaction:         d.required(tng + "transaction"),
+			AmountNumbers:       d.required(tng + "amount_numbers_pattern"),
+			AccountNumber:       d.pattern(tng + "account_number"),
+			AccountName:         d.pattern(tng + "account_name"),
+			StatementDateRegex:  d.pattern(tng + "statement_date"),
+			TransactionDate:     d.str(tng + "transaction_date"),
+			DebitSuffix:         d.str(tng + "debit_suffix"),
+			AccountType:         d.str(tng + "account_type"),
+			StatementDateFormat: d.str(tng + "statement_date_format"),
+		},
+		TNGEmail: TNGEmail{
+			Transaction:            d.required(email + "transaction"),
+			DatetimePattern:        d.required(email + "datetime_pattern"),
+			AccountNumber:          d.pattern(email + "account_number"),
+			AccountName:            d.pattern(email + "account_name"),
+			DatetimeFormat:         d.str(email + "datetime_format"),
+			DateFormat:             d.str(email + "date_format"),
+			AccountType:            d.str(email + "account_type"),
+			CreditTransactionTypes: strings.Split(d.str(email+"credit_transaction_types"), ","),
+		},
+	}
+	d.statementTypes()
+	return cfg, errors.Join(d.errs...)
+}
+
+var current atomic.Pointer[Config]
+
+// Set makes cfg the configuration used by the extractors
+func Set(cfg *Config) {
+	current.Store(cfg)
+}
+
+// Current returns the configuration passed to Set. Until Set is called it
+// decodes the global viper configuration on every call, logging any problems.
+func Current() *Config {
+	if cfg := current.Load(); cfg != nil {
+		return cfg
+	}
+	cfg, err := Load(viper.GetViper())
+	if err != nil {
+		log.Printf("Invalid configuration: %v", err)
+	}
+	return cfg
+}
+
+// decoder reads typed values out of a viper config, collecting errors
+type decoder struct {
+	v    *viper.Viper
+	errs []error
+}
+
+func (d *decoder) fail(key string, format string, args ...any) {
+	d.errs = append(d.errs, &KeyError{Key: key, Err: fmt.Errorf(format, args...)})
+}
+
+// str returns the string at key, "" when it is unset
+func (d *decoder) str(key string) string {
+	s, _ := d.toString(key, d.v.Get(key))
+	return s
+}
+
+// pattern compiles the optional regular expression at key. When unset it is
+// the empty pattern, which matches everything but captures nothing, so the
+// extractors leave the field it would fill empty.
+func (d *decoder) pattern(key string) *regexp.Regexp {
+	return d.compile(key, d.str(key))
+}
+
+// required compiles the regular expression at key, reporting it when it is
+// unset in a configured statement section
+func (d *decoder) required(key string) *regexp.Regexp {
+	expr := d.str(key)
+	if expr == "" {
+		if section := strings.Join(strings.SplitN(key, ".", 3)[:2], "."); d.v.IsSet(section) {
+			d.fail(key, "required")
+		}
+		return neverMatch
+	}
+	return d.compile(key, expr)
+}
+
+func (d *decoder) compile(key, expr string) *regexp.Regexp {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		d.fail(key, "invalid pattern: %v", err)
+		return neverMatch
+	}
+	return re
+}
+
+func (d *decoder) toString(key string, value any) (string, bool) {
+	switch value := value.(type) {
+	case nil:
+		return "", true
+	case string:
+		return value, true
+	default:
+		d.fail(key, "expected a string, got %s (quote the value)", typeName(value))
+		return "", false
+	}
+}
+
+// accounts decodes the 'accounts' list, leaving out entries with errors
+func (d *decoder) accounts() []Account {
+	raw := d.v.Get("accounts")
+	if raw == nil {
+		return nil
+	}
+	list, ok := raw.([]any)
+	if !ok {
+		d.fail("accounts", "expected a list of accounts, got %s", typeName(raw))
+		return nil
+	}
+
+	var accounts []Account
+	for i, entry := range list {
+		path := fmt.Sprintf("accounts[%d]", i)
+		fields, ok := entry.(map[string]any)
+		if !ok {
+			d.fail(path, "expected an account with number, regex_identifier and statement_config, got %s", typeName(entry))
+			continue
+		}
+		errs := len(d.errs)
+		for _, key := range slices.Sorted(maps.Keys(fields)) {
+			if !slices.Contains(accountKeys, key) {
+				d.fail(path+"."+key, "unknown key (expected one of %s)", strings.Join(accountKeys, ", "))
+			}
+		}
+
+		str := func(key string, required bool) string {
+			s, ok := d.toString(path+"."+key, fields[key])
+			if ok && required && s == "" {
+				d.fail(path+"."+key, "required")
+			}
+			return s
+		}
+		account := Account{
+			Number:          str("number", true),
+			Name:            str("name", false),
+			Type:            str("type", false),
+			DebitCredit:     str("drcr", false),
+			StatementConfig: str("statement_config", true),
+		}
+		if identifier := str("regex_identifier", true); identifier != "" {
+			account.Identifier = d.compile(path+".regex_identifier", identifier)
+		}
+		switch value := fields["reconciliable"].(type) {
+		case nil:
+		case bool:
+			account.Reconciliable = value
+		default:
+			d.fail(path+".reconciliable", "expected true or false, got %s", typeName(value))
+		}
+		if account.DebitCredit != "" && !slices.Contains(DebitCredit, account.DebitCredit) {
+			d.fail(path+".drcr", "expected one of %s, got %q", strings.Join(DebitCredit, ", "), account.DebitCredit)
+		}
+		switch section := "statement." + account.StatementConfig; {
+		case account.StatementConfig == "":
+		case !slices.Contains(StatementTypes, account.StatementConfig):
+			d.fail(path+".statement_config", "unknown statement type %q (expected one of %s)",
+				account.StatementConfig, strings.Join(StatementTypes, ", "))
+		case account.StatementConfig != "TNG_CSV_EXPORT" && !d.v.IsSet(section): // CSV exports need no patterns
+			d.fail(path+".statement_config", "%s has no patterns (add a %s section)", account.StatementConfig, section)
+		}
+
+		if len(d.errs) == errs {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts
+}
+
+// statementTypes reports sections of 'statement' that no extractor reads
+func (d *decoder) statementTypes() {
+	for _, name := range slices.Sorted(maps.Keys(d.v.GetStringMap("statement"))) {
+		known := slices.ContainsFunc(StatementTypes, func(t string) bool { return strings.EqualFold(t, name) })
+		if !known {
+			d.fail("statement."+strings.ToUpper(name), "unknown statement type (expected one of %s)", strings.Join(StatementTypes, ", "))
+		}
+	}
+}
+
+// typeName describes a decoded YAML value for error messages
+func typeName(value any) string {
+	switch value.(type) {
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case int, int64, uint64, float64:
+		return "a number"
+	case []any:
+		return "a list"
+	case map[string]any:
+		return "a map"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,148 @@
+package settings
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func load(t *testing.T, yaml string) (*Config, error) {
+	t.Helper()
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(bytes.NewBufferString(yaml)); err != nil {
+		t.Fatal(err)
+	}
+	return Load(v)
+}
+
+// keys returns the key paths of the problems in err
+func keys(err error) []string {
+	var out []string
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			var ke *KeyError
+			if errors.As(e, &ke) {
+				out = append(out, ke.Key)
+			}
+		}
+	}
+	return out
+}
+
+func TestLoad_Valid(t *testing.T) {
+	cfg, err := load(t, `
+accounts:
+  - number: "111111-111111"
+    name: "Salary Account"
+    type: "cash"
+    drcr: "debit"
+    reconciliable: true
+    regex_identifier: "111111-111111"
+    statement_config: "MAYBANK_CASA_AND_MAE"
+statement:
+  MAYBANK_CASA_AND_MAE:
+    patterns:
+      starting_balance: 'BEGINNING BALANCE\s*([\d,]+\.\d+)'
+      ending_balance: 'ENDING BALANCE\s*:\s*([\d,]+\.\d+)'
+      statement_date: '(\d{2}/\d{2}/\d{2})'
+      main_transaction_line: '(\d{2}/\d{2})(.+?)([\d,]*\.\d+[+-])'
+      description_transaction_line: '(^\s+\S.*)'
+  TNG:
+    patterns:
+      transaction: '(\d{2}/\d{2}/\d{4})'
+      amount_numbers_pattern: '([+-]?)RM(\d+\.\d+)'
+      debit_suffix: '-'
+  TNG_EMAIL:
+    patterns:
+      transaction: '(\d+/\d+/\d{4})\s+(\w+)'
+      datetime_pattern: '\d+/\d+/\d{4} \d{2}:\d{2} (AM|PM)'
+      credit_transaction_types: 'Reload,Balance Top Up'
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Accounts) != 1 {
+		t.Fatalf("Expected 1 account, got %+v", cfg.Accounts)
+	}
+	acc := cfg.Accounts[0]
+	if acc.Number != "111111-111111" || acc.DebitCredit != "debit" || !acc.Reconciliable || !acc.Identifier.MatchString("no 111111-111111") {
+		t.Errorf("Unexpected account %+v", acc)
+	}
+	if !cfg.TNG.Transaction.MatchString("01/02/2025") || cfg.TNG.DebitSuffix != "-" {
+		t.Errorf("Unexpected TNG patterns %+v", cfg.TNG)
+	}
+	if len(cfg.TNGEmail.CreditTransactionTypes) != 2 {
+		t.Errorf("Expected 2 credit transaction types, got %q", cfg.TNGEmail.CreditTransactionTypes)
+	}
+	// Unset optional patterns keep the empty pattern's behaviour; unset required ones match nothing
+	if !cfg.TNG.AccountNumber.MatchString("Wallet ID 1") || cfg.TNG.AccountNumber.NumSubexp() != 0 {
+		t.Error("Expected an unset optional pattern to match everything, capturing nothing")
+	}
+	if cfg.MaybankCC.Transaction.MatchString("") {
+		t.Error("Expected an unset required pattern to match nothing")
+	}
+}
+
+func TestLoad_ReportsEveryProblem(t *testing.T) {
+	cfg, err := load(t, `
+accounts:
+  - number: 1234
+    reconciliable: "yes"
+    regex_identifier: "1234("
+    statement_config: "MAYBANK_CASA_AND_MAE"
+  - number: "5678"
+    drcr: "liability"
+    regex_identifer: "5678"
+    statement_config: "MAYBANK_CC"
+  - number: "9999"
+    regex_identifier: "9999"
+    statement_config: "TNG_CSV_EXPORT"
+  - "not an account"
+statement:
+  MAYBANK_2_CC:
+    patterns:
+      transaction: '([\d'
+      credit_suffix: 42
+  TNG_EMIALS:
+    patterns: {}
+`)
+	want := []string{
+		"accounts[0].number",
+		"accounts[0].regex_identifier",
+		"accounts[0].reconciliable",
+		"accounts[0].statement_config", // No MAYBANK_CASA_AND_MAE section
+		"accounts[1].regex_identifer",
+		"accounts[1].regex_identifier",
+		"accounts[1].drcr",
+		"accounts[1].statement_config",
+		"accounts[3]",
+		"statement.MAYBANK_2_CC.patterns.starting_balance",
+		"statement.MAYBANK_2_CC.patterns.ending_balance",
+		"statement.MAYBANK_2_CC.patterns.statement_date",
+		"statement.MAYBANK_2_CC.patterns.transaction",
+		"statement.MAYBANK_2_CC.patterns.credit_suffix",
+		"statement.TNG_EMIALS",
+	}
+	if got := keys(err); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected problems at\n%s\ngot\n%s\n(%v)", strings.Join(want, "\n"), strings.Join(got, "\n"), err)
+	}
+
+	// The valid parts are still usable
+	if len(cfg.Accounts) != 1 || cfg.Accounts[0].Number != "9999" {
+		t.Errorf("Expected only the valid account, got %+v", cfg.Accounts)
+	}
+	if cfg.MaybankCC.Transaction == nil || cfg.MaybankCC.Transaction.MatchString("12") {
+		t.Error("Expected an invalid pattern to match nothing")
+	}
+}
+
+func TestLoad_AccountsNotAList(t *testing.T) {
+	_, err := load(t, "accounts:\n  number: '1234'\n")
+	if got := keys(err); len(got) != 1 || got[0] != "accounts" {
+		t.Errorf("Expected a problem at accounts, got %v", err)
+	}
+}
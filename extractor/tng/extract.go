@@ -3,39 +3,19 @@ package tng
 import (
 	"log"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"time"
 
 	"github.com/aqlanhadi/kwgn/extractor/common"
+	"github.com/aqlanhadi/kwgn/extractor/settings"
 	"github.com/shopspring/decimal"
-	"github.com/spf13/viper"
 )
 
-type config struct {
-	Transaction         *regexp.Regexp
-	AmountNumbers       *regexp.Regexp
-	AccountNumber       *regexp.Regexp
-	AccountName         *regexp.Regexp
-	StatementDateRegex  *regexp.Regexp
-	TransactionDate     string
-	DebitSuffix         string
-	AccountType         string
-	StatementDateFormat string
-}
+// config holds the statement.TNG.patterns settings
+type config = settings.TNG
 
 func loadConfig() config {
-	return config{
-		Transaction:         regexp.MustCompile(viper.GetString("statement.TNG.patterns.transaction")),
-		AmountNumbers:       regexp.MustCompile(viper.GetString("statement.TNG.patterns.amount_numbers_pattern")),
-		AccountNumber:       regexp.MustCompile(viper.GetString("statement.TNG.patterns.account_number")),
-		AccountName:         regexp.MustCompile(viper.GetString("statement.TNG.patterns.account_name")),
-		StatementDateRegex:  regexp.MustCompile(viper.GetString("statement.TNG.patterns.statement_date")),
-		TransactionDate:     viper.GetString("statement.TNG.patterns.transaction_date"),
-		DebitSuffix:         viper.GetString("statement.TNG.patterns.debit_suffix"),
-		AccountType:         viper.GetString("statement.TNG.patterns.account_type"),
-		StatementDateFormat: viper.GetString("statement.TNG.patterns.statement_date_format"),
-	}
+	return settings.Current().TNG
 }
 
 func Extract(path string, rows *[]string) common.Statement {
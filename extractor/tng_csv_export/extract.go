@@ -58,17 +58,24 @@ type TNGCSVRow struct {
 
 // ExtractMulti parses a TNG CSV export file and returns multiple statements (one per MFG Number)
 func ExtractMulti(reader io.Reader, filename string) ([]common.Statement, error) {
+	statements, _, err := ExtractMultiSkipped(reader, filename)
+	return statements, err
+}
+
+// ExtractMultiSkipped works like ExtractMulti but also returns the number of rows
+// skipped because they could not be read or parsed
+func ExtractMultiSkipped(reader io.Reader, filename string) ([]common.Statement, int, error) {
 	csvReader := csv.NewReader(reader)
 
 	// Read header
 	header, err := csvReader.Read()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+		return nil, 0, fmt.Errorf("failed to read CSV header: %w", err)
 	}
 
 	// Validate header
 	if len(header) < 17 {
-		return nil, fmt.Errorf("invalid CSV format: expected at least 17 columns, got %d", len(header))
+		return nil, 0, fmt.Errorf("invalid CSV format: expected at least 17 columns, got %d", len(header))
 	}
 
 	// Load Asia/Kuala_Lumpur timezone
@@ -80,6 +87,7 @@ func ExtractMulti(reader io.Reader, filename string) ([]common.Statement, error)
 
 	// Parse all rows and group by MFG Number
 	rowsByMFG := make(map[string][]TNGCSVRow)
+	skipped := 0
 
 	for {
 		record, err := csvReader.Read()
@@ -88,17 +96,20 @@ func ExtractMulti(reader io.Reader, filename string) ([]common.Statement, error)
 		}
 		if err != nil {
 			log.Printf("Warning: error reading CSV row: %v", err)
+			skipped++
 			continue
 		}
 
 		if len(record) < 17 {
 			log.Printf("Warning: skipping row with insufficient columns: %d", len(record))
+			skipped++
 			continue
 		}
 
 		row, err := parseRow(record, loc)
 		if err != nil {
 			log.Printf("Warning: error parsing row: %v", err)
+			skipped++
 			continue
 		}
 
@@ -106,7 +117,7 @@ func ExtractMulti(reader io.Reader, filename string) ([]common.Statement, error)
 	}
 
 	if len(rowsByMFG) == 0 {
-		return nil, fmt.Errorf("no valid transactions found in CSV")
+		return nil, skipped, fmt.Errorf("no valid transactions found in CSV")
 	}
 
 	// Create a statement for each MFG Number
@@ -116,7 +127,7 @@ func ExtractMulti(reader io.Reader, filename string) ([]common.Statement, error)
 		statements = append(statements, stmt)
 	}
 
-	return statements, nil
+	return statements, skipped, nil
 }
 
 // parseRow converts a CSV record to TNGCSVRow
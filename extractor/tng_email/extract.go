@@ -8,35 +8,23 @@ import (
 	"time"
 
 	"github.com/aqlanhadi/kwgn/extractor/common"
+	"github.com/aqlanhadi/kwgn/extractor/settings"
 	"github.com/shopspring/decimal"
-	"github.com/spf13/viper"
 )
 
+// config holds the statement.TNG_EMAIL.patterns settings and the fixed
+// patterns splitting transactions
 type config struct {
-	Transaction            *regexp.Regexp
-	NextTransaction        *regexp.Regexp
-	Asterisk               *regexp.Regexp
-	DatetimePattern        *regexp.Regexp
-	AccountNumber          *regexp.Regexp
-	AccountName            *regexp.Regexp
-	DatetimeFormat         string
-	DateFormat             string
-	AccountType            string
-	CreditTransactionTypes []string
+	settings.TNGEmail
+	NextTransaction *regexp.Regexp
+	Asterisk        *regexp.Regexp
 }
 
 func loadConfig() config {
 	return config{
-		Transaction:            regexp.MustCompile(viper.GetString("statement.TNG_EMAIL.patterns.transaction")),
-		NextTransaction:        regexp.MustCompile(`\n\d+/\d+/\d{4}`),
-		Asterisk:               regexp.MustCompile(`\n\*`),
-		DatetimePattern:        regexp.MustCompile(viper.GetString("statement.TNG_EMAIL.patterns.datetime_pattern")),
-		AccountNumber:          regexp.MustCompile(viper.GetString("statement.TNG_EMAIL.patterns.account_number")),
-		AccountName:            regexp.MustCompile(viper.GetString("statement.TNG_EMAIL.patterns.account_name")),
-		DatetimeFormat:         viper.GetString("statement.TNG_EMAIL.patterns.datetime_format"),
-		DateFormat:             viper.GetString("statement.TNG_EMAIL.patterns.date_format"),
-		AccountType:            viper.GetString("statement.TNG_EMAIL.patterns.account_type"),
-		CreditTransactionTypes: strings.Split(viper.GetString("statement.TNG_EMAIL.patterns.credit_transaction_types"), ","),
+		TNGEmail:        settings.Current().TNGEmail,
+		NextTransaction: regexp.MustCompile(`\n\d+/\d+/\d{4}`),
+		Asterisk:        regexp.MustCompile(`\n\*`),
 	}
 }
 
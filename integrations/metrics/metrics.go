@@ -0,0 +1,162 @@
+// Package metrics exposes Prometheus metrics for extraction, uploads and imports.
+// Metrics are kept in their own registry and served by 'kwgn serve' at /metrics,
+// or pushed / written to a textfile after 'kwgn import'.
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/extractor"
+	"github.com/aqlanhadi/kwgn/integrations/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// unknownType labels extractions where no statement type was recognised
+const unknownType = "unknown"
+
+// Metrics holds the kwgn collectors. A nil *Metrics ignores every observation.
+//
+// The upload and import metrics are registered on first use, so a server does not
+// export import metrics until something is imported, and 'kwgn import' does not
+// export upload sizes.
+type Metrics struct {
+	registry   *prometheus.Registry
+	uploadOnce sync.Once
+	importOnce sync.Once
+
+	extractions       *prometheus.CounterVec
+	extractDuration   *prometheus.HistogramVec
+	pdfPages          *prometheus.HistogramVec
+	balanceMismatches *prometheus.CounterVec
+	rowsSkipped       *prometheus.CounterVec
+	uploadSize        prometheus.Histogram
+
+	importStatements   *prometheus.CounterVec
+	importTransactions *prometheus.CounterVec
+	importLastSuccess  prometheus.Gauge
+}
+
+// New registers the kwgn metrics, along with any extra collectors (such as the Go
+// runtime and process collectors for a long-running server)
+func New(extra ...prometheus.Collector) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		extractions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "kwgn_extractions_total",
+			Help: "Files extracted, by statement type and outcome (ok, empty or error).",
+		}, []string{"statement_type", "outcome"}),
+		extractDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "kwgn_extraction_duration_seconds",
+			Help:    "Time taken to extract a file, by statement type.",
+			Buckets: []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		}, []string{"statement_type"}),
+		pdfPages: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "kwgn_extraction_pdf_pages",
+			Help:    "Pages per extracted PDF, by statement type.",
+			Buckets: []float64{1, 2, 3, 5, 10, 20, 50, 100},
+		}, []string{"statement_type"}),
+		balanceMismatches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "kwgn_extraction_balance_mismatches_total",
+			Help: "Statements whose transactions do not add up to the stated ending balance, by statement type.",
+		}, []string{"statement_type"}),
+		rowsSkipped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "kwgn_extraction_rows_skipped_total",
+			Help: "Input rows dropped because they could not be parsed, by statement type.",
+		}, []string{"statement_type"}),
+		uploadSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "kwgn_upload_size_bytes",
+			Help:    "Size of files uploaded to the API.",
+			Buckets: prometheus.ExponentialBuckets(16<<10, 4, 8), // 16 KB to 256 MB
+		}),
+
+		importStatements: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "kwgn_import_statements_total",
+			Help: "Statements imported into the database, by outcome (processed, skipped or failed). A file skipped as already imported counts once.",
+		}, []string{"outcome"}),
+		importTransactions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "kwgn_import_transactions_total",
+			Help: "Transaction rows imported, by result (inserted, or skipped as already stored).",
+		}, []string{"result"}),
+		importLastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "kwgn_import_last_success_timestamp_seconds",
+			Help: "Unix time the last import without failures completed.",
+		}),
+	}
+
+	m.registry.MustRegister(m.extractions, m.extractDuration, m.pdfPages, m.balanceMismatches, m.rowsSkipped)
+	m.registry.MustRegister(extra...)
+	return m
+}
+
+// ObserveExtraction records one extracted file; pass it to extractor.OnExtract
+func (m *Metrics) ObserveExtraction(e extractor.Extraction) {
+	if m == nil {
+		return
+	}
+	statementType := e.StatementType
+	if statementType == "" {
+		statementType = unknownType
+	}
+
+	m.extractions.WithLabelValues(statementType, e.Outcome).Inc()
+	m.extractDuration.WithLabelValues(statementType).Observe(e.Duration.Seconds())
+	if e.Pages > 0 {
+		m.pdfPages.WithLabelValues(statementType).Observe(float64(e.Pages))
+	}
+	m.balanceMismatches.WithLabelValues(statementType).Add(float64(e.BalanceMismatches))
+	m.rowsSkipped.WithLabelValues(statementType).Add(float64(e.RowsSkipped))
+}
+
+// ObserveUpload records the size of an uploaded file
+func (m *Metrics) ObserveUpload(size int64) {
+	if m == nil {
+		return
+	}
+	m.uploadOnce.Do(func() { m.registry.MustRegister(m.uploadSize) })
+	m.uploadSize.Observe(float64(size))
+}
+
+// ObserveImport records the statement and transaction counts of a completed
+// import, and its time when nothing failed
+func (m *Metrics) ObserveImport(result *store.ImportResult) {
+	if m == nil {
+		return
+	}
+	m.importOnce.Do(func() { m.registry.MustRegister(m.importStatements, m.importTransactions, m.importLastSuccess) })
+	m.importStatements.WithLabelValues("processed").Add(float64(result.Processed))
+	m.importStatements.WithLabelValues("skipped").Add(float64(result.Skipped))
+	m.importStatements.WithLabelValues("failed").Add(float64(result.Failed))
+	m.importTransactions.WithLabelValues("inserted").Add(float64(result.TransactionsInserted))
+	m.importTransactions.WithLabelValues("skipped").Add(float64(result.TransactionsSkipped))
+	if result.Failed == 0 {
+		m.importLastSuccess.Set(float64(time.Now().Unix()))
+	}
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Push replaces the metrics of the given job on a Prometheus Pushgateway
+func (m *Metrics) Push(url, job string) error {
+	if err := push.New(url, job).Gatherer(m.registry).Push(); err != nil {
+		return fmt.Errorf("failed to push metrics to %s: %w", url, err)
+	}
+	return nil
+}
+
+// WriteTextfile writes the metrics to path for the node_exporter textfile collector.
+// The file is replaced atomically.
+func (m *Metrics) WriteTextfile(path string) error {
+	if err := prometheus.WriteToTextfile(path, m.registry); err != nil {
+		return fmt.Errorf("failed to write metrics to %s: %w", path, err)
+	}
+	return nil
+}
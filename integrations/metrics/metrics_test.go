@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/extractor"
+	"github.com/aqlanhadi/kwgn/integrations/store"
+)
+
+// scrape returns the metrics served by m
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	return w.Body.String()
+}
+
+func TestMetrics(t *testing.T) {
+	m := New()
+	m.ObserveExtraction(extractor.Extraction{
+		StatementType: "MAYBANK_2_CC", Outcome: extractor.OutcomeOK, Statements: 2,
+		Pages: 4, Duration: 300 * time.Millisecond, BalanceMismatches: 1,
+	})
+	m.ObserveExtraction(extractor.Extraction{Outcome: extractor.OutcomeError})
+	m.ObserveExtraction(extractor.Extraction{StatementType: "TNG_CSV_EXPORT", Outcome: extractor.OutcomeOK, RowsSkipped: 3})
+	m.ObserveUpload(100 << 10)
+
+	body := scrape(t, m)
+	for _, want := range []string{
+		`kwgn_extractions_total{outcome="ok",statement_type="MAYBANK_2_CC"} 1`,
+		`kwgn_extractions_total{outcome="error",statement_type="unknown"} 1`,
+		`kwgn_extraction_duration_seconds_bucket{statement_type="MAYBANK_2_CC",le="0.5"} 1`,
+		`kwgn_extraction_pdf_pages_sum{statement_type="MAYBANK_2_CC"} 4`,
+		`kwgn_extraction_balance_mismatches_total{statement_type="MAYBANK_2_CC"} 1`,
+		`kwgn_extraction_rows_skipped_total{statement_type="TNG_CSV_EXPORT"} 3`,
+		`kwgn_upload_size_bytes_count 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %s in:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "kwgn_import_") {
+		t.Error("Expected no import metrics before an import")
+	}
+	if strings.Contains(body, `kwgn_extraction_pdf_pages_count{statement_type="TNG_CSV_EXPORT"}`) {
+		t.Error("Expected no page count for CSV files")
+	}
+}
+
+func TestMetrics_Import(t *testing.T) {
+	m := New()
+	m.ObserveImport(&store.ImportResult{Processed: 3, Skipped: 2, Failed: 1, TransactionsInserted: 40, TransactionsSkipped: 7})
+
+	path := filepath.Join(t.TempDir(), "kwgn.prom")
+	if err := m.WriteTextfile(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "kwgn_upload_size_bytes") {
+		t.Error("Expected no upload sizes without uploads")
+	}
+	for _, want := range []string{
+		`kwgn_import_statements_total{outcome="processed"} 3`,
+		`kwgn_import_statements_total{outcome="failed"} 1`,
+		`kwgn_import_transactions_total{result="inserted"} 40`,
+		`kwgn_import_transactions_total{result="skipped"} 7`,
+		"kwgn_import_last_success_timestamp_seconds 0\n", // Not a success
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected %s in:\n%s", want, data)
+		}
+	}
+
+	m.ObserveImport(&store.ImportResult{Processed: 1})
+	if err := m.WriteTextfile(path); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), "kwgn_import_last_success_timestamp_seconds 0\n") {
+		t.Errorf("Expected a successful import to set the last success time:\n%s", data)
+	}
+}
+
+func TestMetrics_Push(t *testing.T) {
+	var method, path string
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer gateway.Close()
+
+	m := New()
+	m.ObserveImport(&store.ImportResult{Processed: 1})
+	if err := m.Push(gateway.URL, "kwgn_import"); err != nil {
+		t.Fatal(err)
+	}
+	if method != http.MethodPut || path != "/metrics/job/kwgn_import" {
+		t.Errorf("Expected PUT /metrics/job/kwgn_import, got %s %s", method, path)
+	}
+}
+
+func TestMetrics_Nil(t *testing.T) {
+	var m *Metrics
+	m.ObserveExtraction(extractor.Extraction{})
+	m.ObserveUpload(1)
+	m.ObserveImport(&store.ImportResult{})
+}
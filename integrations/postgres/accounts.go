@@ -2,9 +2,14 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/aqlanhadi/kwgn/extractor/common"
+	"github.com/aqlanhadi/kwgn/integrations/store"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 // GetOrCreateAccount finds an existing account by number or creates a new one
@@ -12,7 +17,7 @@ func (db *DB) GetOrCreateAccount(ctx context.Context, account common.Account) (s
 	var id string
 
 	// Try to find existing account
-	err := db.Pool.QueryRow(ctx, `
+	err := db.conn().QueryRow(ctx, `
 		SELECT id FROM accounts WHERE account_number = $1
 	`, account.AccountNumber).Scan(&id)
 
@@ -21,7 +26,7 @@ func (db *DB) GetOrCreateAccount(ctx context.Context, account common.Account) (s
 		// - Always update account_name (source identifier from extractor)
 		// - Only update account_type if non-empty (preserve user-set values)
 		// - Update debit_credit and reconciliable if non-empty
-		_, err = db.Pool.Exec(ctx, `
+		_, err = db.conn().Exec(ctx, `
 			UPDATE accounts
 			SET account_name = $1,
 			    account_type = CASE WHEN $2::text != '' THEN $2 ELSE account_type END,
@@ -37,7 +42,7 @@ func (db *DB) GetOrCreateAccount(ctx context.Context, account common.Account) (s
 	}
 
 	// Create new account
-	err = db.Pool.QueryRow(ctx, `
+	err = db.conn().QueryRow(ctx, `
 		INSERT INTO accounts (account_number, account_name, account_type, debit_credit, reconciliable)
 		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id
@@ -49,3 +54,123 @@ func (db *DB) GetOrCreateAccount(ctx context.Context, account common.Account) (s
 
 	return id, nil
 }
+
+// FindAccount looks up an account by number without modifying it
+// Returns nil if the account does not exist
+func (db *DB) FindAccount(ctx context.Context, accountNumber string) (*store.StoredAccount, error) {
+	var a store.StoredAccount
+	err := db.conn().QueryRow(ctx, `
+		SELECT id, account_number, account_name, COALESCE(account_type, ''),
+		       COALESCE(debit_credit, ''), COALESCE(reconciliable, false)
+		FROM accounts WHERE account_number = $1
+	`, accountNumber).Scan(&a.ID, &a.AccountNumber, &a.AccountName, &a.AccountType, &a.DebitCredit, &a.Reconciliable)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find account: %w", err)
+	}
+	return &a, nil
+}
+
+// accountSelect selects accounts with their latest statement, to be completed with
+// WHERE and ORDER BY clauses on the accounts alias a
+const accountSelect = `
+	SELECT a.id, a.account_number, a.account_name, COALESCE(a.account_type, ''),
+	       COALESCE(a.debit_credit, ''), COALESCE(a.reconciliable, false),
+	       (SELECT COUNT(*) FROM statements s WHERE s.account_id = a.id),
+	       l.id::text, l.statement_date, l.ending_balance
+	FROM accounts a
+	LEFT JOIN LATERAL (
+		SELECT s.id, s.statement_date, s.ending_balance FROM statements s
+		WHERE s.account_id = a.id ORDER BY s.statement_date DESC LIMIT 1
+	) l ON true
+`
+
+// GetAccount returns the account with the given id and its latest statement; nil if there is none
+func (db *DB) GetAccount(ctx context.Context, id string) (*store.AccountRecord, error) {
+	if !validUUID(id) {
+		return nil, nil
+	}
+	records, err := db.queryAccounts(ctx, accountSelect+" WHERE a.id = $1::uuid", id)
+	if err != nil || len(records) == 0 {
+		return nil, err
+	}
+	return &records[0], nil
+}
+
+// ListAccounts returns the accounts matching q with their latest statement, ordered by account number
+func (db *DB) ListAccounts(ctx context.Context, q store.AccountQuery) ([]store.AccountRecord, error) {
+	var where []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+
+	if q.Account != "" {
+		add("(a.account_number = $%[1]d OR a.account_name ILIKE '%%' || $%[1]d || '%%')", q.Account)
+	}
+	if q.Type != "" {
+		add("LOWER(a.account_type) = LOWER($%d)", q.Type)
+	}
+
+	query := accountSelect
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY a.account_number" + limitOffset(q.Limit, q.Offset)
+	return db.queryAccounts(ctx, query, args...)
+}
+
+// queryAccounts runs a query built on accountSelect
+func (db *DB) queryAccounts(ctx context.Context, query string, args ...any) ([]store.AccountRecord, error) {
+	rows, err := db.conn().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var records []store.AccountRecord
+	for rows.Next() {
+		var r store.AccountRecord
+		var latestDate pgtype.Date
+		var latestBalance pgtype.Numeric
+		if err := rows.Scan(
+			&r.ID, &r.AccountNumber, &r.AccountName, &r.AccountType, &r.DebitCredit, &r.Reconciliable,
+			&r.StatementCount, &r.LatestStatementID, &latestDate, &latestBalance,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		if latestDate.Valid {
+			r.LatestStatementDate = &latestDate.Time
+		}
+		if latestBalance.Valid {
+			balance := fromNumeric(latestBalance)
+			r.LatestBalance = &balance
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// EditAccount applies edit to the account with the given id; false if there is none
+func (db *DB) EditAccount(ctx context.Context, id string, edit store.AccountEdit) (bool, error) {
+	if !validUUID(id) {
+		return false, nil
+	}
+	tag, err := db.conn().Exec(ctx, `
+		UPDATE accounts SET account_type = COALESCE($1, account_type), updated_at = NOW() WHERE id = $2::uuid
+	`, edit.AccountType, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to edit account: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// validUUID reports whether id can be compared with a uuid column; PostgreSQL
+// rejects the whole query when a malformed id is cast
+func validUUID(id string) bool {
+	var u pgtype.UUID
+	return u.Scan(id) == nil
+}
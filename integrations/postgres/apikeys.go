@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aqlanhadi/kwgn/integrations/store"
+	"github.com/jackc/pgx/v5"
+)
+
+const apiKeyColumns = `id::text, name, prefix, key_hash, scopes, rate_limit, created_at, revoked_at`
+
+// scanAPIKey reads an api_keys row selected with apiKeyColumns
+func scanAPIKey(row pgx.Row) (store.APIKey, error) {
+	var key store.APIKey
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.Hash, &key.Scopes, &key.RateLimit, &key.CreatedAt, &key.RevokedAt)
+	return key, err
+}
+
+// CreateAPIKey stores a new API key and returns its id
+func (db *DB) CreateAPIKey(ctx context.Context, key store.APIKey) (string, error) {
+	var id string
+	err := db.conn().QueryRow(ctx, `
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, rate_limit)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id::text
+	`, key.Name, key.Prefix, key.Hash, key.Scopes, key.RateLimit).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("failed to create API key: %w", err)
+	}
+	return id, nil
+}
+
+// FindAPIKey looks up an API key by hash
+// Returns nil if there is no such key
+func (db *DB) FindAPIKey(ctx context.Context, hash string) (*store.APIKey, error) {
+	key, err := scanAPIKey(db.conn().QueryRow(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = $1`, hash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find API key: %w", err)
+	}
+	return &key, nil
+}
+
+// ListAPIKeys returns every API key, revoked ones included, oldest first
+func (db *DB) ListAPIKeys(ctx context.Context) ([]store.APIKey, error) {
+	rows, err := db.conn().Query(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY created_at, name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []store.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey revokes the key with the given id or prefix.
+// Returns false if no active key matches.
+func (db *DB) RevokeAPIKey(ctx context.Context, ref string) (bool, error) {
+	tag, err := db.conn().Exec(ctx, `
+		UPDATE api_keys SET revoked_at = NOW()
+		WHERE (id::text = $1 OR prefix = $1) AND revoked_at IS NULL
+	`, ref)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -4,12 +4,55 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/aqlanhadi/kwgn/integrations/store"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// DB holds the connection pool
+// DB holds the connection pool and implements store.Store for PostgreSQL.
+// The pool is safe for concurrent use, so one DB can be shared by parallel imports.
 type DB struct {
 	Pool *pgxpool.Pool
+
+	tx pgx.Tx // Set on the DB handed to InTx callbacks
+}
+
+var _ store.Store = (*DB)(nil)
+
+// querier is implemented by both *pgxpool.Pool and pgx.Tx
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+// conn returns the transaction when running inside InTx, otherwise the pool
+func (db *DB) conn() querier {
+	if db.tx != nil {
+		return db.tx
+	}
+	return db.Pool
+}
+
+// InTx runs fn inside a single database transaction.
+// All queries made through the DB passed to fn are part of the transaction; it is
+// committed when fn returns nil and rolled back on error, panic or context cancellation.
+func (db *DB) InTx(ctx context.Context, fn func(tx store.Store) error) error {
+	return db.inTx(ctx, func(tx *DB) error { return fn(tx) })
+}
+
+// inTx is InTx for code inside this package that needs the concrete *DB
+func (db *DB) inTx(ctx context.Context, fn func(tx *DB) error) error {
+	if db.tx != nil {
+		// Already in a transaction, join it
+		return fn(db)
+	}
+	return pgx.BeginFunc(ctx, db.Pool, func(tx pgx.Tx) error {
+		return fn(&DB{Pool: db.Pool, tx: tx})
+	})
 }
 
 // Connect creates a new database connection pool
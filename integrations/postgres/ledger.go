@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aqlanhadi/kwgn/integrations/store"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetFileRecord looks up a ledger entry by content hash
+// Returns nil if the file has never been imported
+func (db *DB) GetFileRecord(ctx context.Context, sha string) (*store.FileRecord, error) {
+	var rec store.FileRecord
+	err := db.conn().QueryRow(ctx, `
+		SELECT sha256, path, size, detected_type, extractor_version,
+		       statement_ids::text[], outcome, error, imported_at
+		FROM import_files WHERE sha256 = $1
+	`, sha).Scan(
+		&rec.SHA256, &rec.Path, &rec.Size, &rec.DetectedType, &rec.ExtractorVersion,
+		&rec.StatementIDs, &rec.Outcome, &rec.Error, &rec.ImportedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get file record: %w", err)
+	}
+	return &rec, nil
+}
+
+// RecordFile inserts or replaces the ledger entry for a file
+func (db *DB) RecordFile(ctx context.Context, rec store.FileRecord) error {
+	ids := rec.StatementIDs
+	if ids == nil {
+		ids = []string{}
+	}
+
+	_, err := db.conn().Exec(ctx, `
+		INSERT INTO import_files (
+			sha256, path, size, detected_type, extractor_version,
+			statement_ids, outcome, error, imported_at
+		) VALUES ($1, $2, $3, $4, $5, $6::uuid[], $7, $8, NOW())
+		ON CONFLICT (sha256) DO UPDATE
+		SET path = EXCLUDED.path,
+		    size = EXCLUDED.size,
+		    detected_type = EXCLUDED.detected_type,
+		    extractor_version = EXCLUDED.extractor_version,
+		    statement_ids = EXCLUDED.statement_ids,
+		    outcome = EXCLUDED.outcome,
+		    error = EXCLUDED.error,
+		    imported_at = NOW()
+	`, rec.SHA256, rec.Path, rec.Size, rec.DetectedType, rec.ExtractorVersion,
+		ids, rec.Outcome, rec.Error)
+	if err != nil {
+		return fmt.Errorf("failed to record file: %w", err)
+	}
+
+	// The path now holds a file of its own
+	if _, err := db.conn().Exec(ctx, `DELETE FROM import_duplicates WHERE path = $1`, rec.Path); err != nil {
+		return fmt.Errorf("failed to record file: %w", err)
+	}
+	return nil
+}
+
+// RecordDuplicate records that the file at path has the same content as the ledger entry for sha
+func (db *DB) RecordDuplicate(ctx context.Context, path, sha string) error {
+	_, err := db.conn().Exec(ctx, `
+		INSERT INTO import_duplicates (path, sha256, imported_at) VALUES ($1, $2, NOW())
+		ON CONFLICT (path) DO UPDATE SET sha256 = EXCLUDED.sha256, imported_at = NOW()
+	`, path, sha)
+	if err != nil {
+		return fmt.Errorf("failed to record duplicate: %w", err)
+	}
+	return nil
+}
+
+// ListFileRecords returns ledger entries and duplicates, most recent first.
+// When pathPrefix is set only files under that path are returned.
+func (db *DB) ListFileRecords(ctx context.Context, pathPrefix string) ([]store.FileRecord, error) {
+	rows, err := db.conn().Query(ctx, `
+		SELECT sha256, path, size, detected_type, extractor_version,
+		       statement_ids::text[], outcome, error, '' AS duplicate_of, imported_at
+		FROM import_files
+		WHERE $1 = '' OR starts_with(path, $1)
+		UNION ALL
+		SELECT f.sha256, d.path, f.size, f.detected_type, f.extractor_version,
+		       '{}'::text[], $2, '', f.path, d.imported_at
+		FROM import_duplicates d
+		JOIN import_files f ON f.sha256 = d.sha256
+		WHERE $1 = '' OR starts_with(d.path, $1)
+		ORDER BY imported_at DESC, path
+	`, pathPrefix, store.OutcomeDuplicate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list file records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []store.FileRecord
+	for rows.Next() {
+		var rec store.FileRecord
+		if err := rows.Scan(
+			&rec.SHA256, &rec.Path, &rec.Size, &rec.DetectedType, &rec.ExtractorVersion,
+			&rec.StatementIDs, &rec.Outcome, &rec.Error, &rec.DuplicateOf, &rec.ImportedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan file record: %w", err)
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
@@ -0,0 +1,201 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+
+	"github.com/aqlanhadi/kwgn/integrations/store"
+	"github.com/jackc/pgx/v5"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// lockMigrations takes a transaction-scoped advisory lock so two kwgn
+// processes never migrate the same database at once
+const lockMigrations = `SELECT pg_advisory_xact_lock(hashtext('kwgn_schema_migrations'))`
+
+const migrationsTableDDL = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMPTZ DEFAULT NOW()
+);
+`
+
+// Migrations returns the migrations embedded in the binary, in order
+func Migrations() ([]store.Migration, error) {
+	sub, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return store.LoadMigrations(sub)
+}
+
+// appliedMigrations returns the applied migrations keyed by version.
+// With create set the schema_migrations table is created if missing;
+// otherwise a missing table simply means nothing has been applied.
+func (db *DB) appliedMigrations(ctx context.Context, q querier, create bool) (map[int]store.MigrationStatus, error) {
+	applied := make(map[int]store.MigrationStatus)
+	if create {
+		if _, err := q.Exec(ctx, migrationsTableDDL); err != nil {
+			return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+		}
+	} else {
+		var exists bool
+		if err := q.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("failed to check schema_migrations: %w", err)
+		}
+		if !exists {
+			return applied, nil
+		}
+	}
+
+	rows, err := q.Query(ctx, `SELECT version, name, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		s := store.MigrationStatus{Applied: true}
+		if err := rows.Scan(&s.Version, &s.Name, &s.AppliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations: %w", err)
+		}
+		applied[s.Version] = s
+	}
+	return applied, rows.Err()
+}
+
+// MigrationStatus lists every known migration and whether it has been applied.
+// Versions recorded in the database but unknown to this binary are flagged.
+func (db *DB) MigrationStatus(ctx context.Context) ([]store.MigrationStatus, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := db.appliedMigrations(ctx, db.conn(), false)
+	if err != nil {
+		return nil, err
+	}
+	return store.MergeMigrationStatus(migrations, applied), nil
+}
+
+// SchemaVersion returns the highest applied migration and the latest embedded one
+func (db *DB) SchemaVersion(ctx context.Context) (current int, latest int, err error) {
+	status, err := db.MigrationStatus(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	current, latest = store.Versions(status)
+	return current, latest, nil
+}
+
+// MigrateUp applies pending migrations in order, each in its own transaction.
+// steps limits how many are applied (0 = all). Returns the migrations applied.
+func (db *DB) MigrateUp(ctx context.Context, steps int) ([]store.Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []store.Migration
+	for _, mig := range migrations {
+		if steps > 0 && len(done) >= steps {
+			break
+		}
+
+		applied := false
+		err := pgx.BeginFunc(ctx, db.Pool, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, lockMigrations); err != nil {
+				return fmt.Errorf("failed to acquire migration lock: %w", err)
+			}
+			// Re-read under the lock in case another process migrated meanwhile
+			state, err := db.appliedMigrations(ctx, tx, true)
+			if err != nil {
+				return err
+			}
+			if _, ok := state[mig.Version]; ok {
+				return nil
+			}
+			if err := store.CheckNoGaps(state, mig.Version); err != nil {
+				return err
+			}
+
+			if _, err := tx.Exec(ctx, mig.Up); err != nil {
+				return fmt.Errorf("migration %04d_%s failed: %w", mig.Version, mig.Name, err)
+			}
+			if _, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, mig.Version, mig.Name); err != nil {
+				return fmt.Errorf("failed to record migration %04d: %w", mig.Version, err)
+			}
+			applied = true
+			return nil
+		})
+		if err != nil {
+			return done, err
+		}
+		if applied {
+			done = append(done, mig)
+		}
+	}
+	return done, nil
+}
+
+// MigrateDown reverts the most recently applied migrations, newest first.
+// steps is how many to revert (minimum 1). Returns the migrations reverted.
+func (db *DB) MigrateDown(ctx context.Context, steps int) ([]store.Migration, error) {
+	if steps < 1 {
+		steps = 1
+	}
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []store.Migration
+	for len(done) < steps {
+		var reverted *store.Migration
+		err := pgx.BeginFunc(ctx, db.Pool, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, lockMigrations); err != nil {
+				return fmt.Errorf("failed to acquire migration lock: %w", err)
+			}
+			state, err := db.appliedMigrations(ctx, tx, true)
+			if err != nil {
+				return err
+			}
+
+			current := 0
+			for v := range state {
+				if v > current {
+					current = v
+				}
+			}
+			if current == 0 {
+				return nil
+			}
+			if current > len(migrations) {
+				return fmt.Errorf("cannot revert migration %04d: it is not known to this version of kwgn", current)
+			}
+
+			mig := migrations[current-1]
+			if _, err := tx.Exec(ctx, mig.Down); err != nil {
+				return fmt.Errorf("reverting migration %04d_%s failed: %w", mig.Version, mig.Name, err)
+			}
+			if _, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, mig.Version); err != nil {
+				return fmt.Errorf("failed to unrecord migration %04d: %w", mig.Version, err)
+			}
+			reverted = &mig
+			return nil
+		})
+		if err != nil {
+			return done, err
+		}
+		if reverted == nil {
+			break // Nothing left to revert
+		}
+		done = append(done, *reverted)
+	}
+	return done, nil
+}
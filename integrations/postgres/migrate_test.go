@@ -0,0 +1,13 @@
+package postgres
+
+import "testing"
+
+func TestMigrations_Embedded(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("Embedded migrations are invalid: %v", err)
+	}
+	if len(migrations) == 0 || migrations[0].Name != "initial_schema" {
+		t.Fatalf("Expected 0001_initial_schema first, got %+v", migrations)
+	}
+}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS transactions;
+DROP TABLE IF EXISTS statements;
+DROP TABLE IF EXISTS accounts;
@@ -0,0 +1,12 @@
+-- Ledger of imported files keyed by content hash (skips unchanged files on re-import)
+CREATE TABLE IF NOT EXISTS import_files (
+    sha256 CHAR(64) PRIMARY KEY,
+    path TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    detected_type VARCHAR(50) DEFAULT '',
+    extractor_version VARCHAR(50) DEFAULT '',
+    statement_ids UUID[] DEFAULT '{}',
+    outcome VARCHAR(20) NOT NULL,
+    error TEXT DEFAULT '',
+    imported_at TIMESTAMPTZ DEFAULT NOW()
+);
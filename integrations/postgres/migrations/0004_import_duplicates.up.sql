@@ -0,0 +1,8 @@
+-- Other paths at which an already imported file was found, so the ledger lists every file
+CREATE TABLE IF NOT EXISTS import_duplicates (
+    path TEXT PRIMARY KEY,
+    sha256 CHAR(64) NOT NULL REFERENCES import_files(sha256) ON DELETE CASCADE,
+    imported_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_import_duplicates_sha256 ON import_duplicates(sha256);
@@ -0,0 +1,2 @@
+-- Longer sources are truncated
+ALTER TABLE statements ALTER COLUMN source TYPE VARCHAR(255) USING left(source, 255);
@@ -0,0 +1,2 @@
+-- Statements from archives are sourced as <archive>/<member>, which can exceed 255 characters
+ALTER TABLE statements ALTER COLUMN source TYPE TEXT;
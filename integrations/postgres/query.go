@@ -0,0 +1,210 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aqlanhadi/kwgn/integrations/store"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// sortColumns maps store sort fields to SQL expressions
+var sortColumns = map[string]string{
+	store.SortDate:        "t.date",
+	store.SortAmount:      "t.amount",
+	store.SortAccount:     "a.account_number",
+	store.SortType:        "t.type",
+	store.SortDescription: "t.description",
+}
+
+// QueryTransactions returns the transactions matching q, joined with their account and statement
+func (db *DB) QueryTransactions(ctx context.Context, q store.TransactionQuery) ([]store.TransactionRecord, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	var where []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+
+	if q.ID != "" {
+		if !validUUID(q.ID) {
+			return nil, nil // No transaction has a malformed id
+		}
+		add("t.id = $%d::uuid", q.ID)
+	}
+	if q.Account != "" {
+		add("(a.account_number = $%[1]d OR a.account_name ILIKE '%%' || $%[1]d || '%%')", q.Account)
+	}
+	if !q.From.IsZero() {
+		add("t.date >= $%d", q.From)
+	}
+	if !q.To.IsZero() {
+		add("t.date <= $%d", q.To)
+	}
+	if q.Type != "" {
+		add("t.type = $%d", q.Type)
+	}
+	if q.MinAmount != nil {
+		add("t.amount >= $%d", toNumeric(*q.MinAmount))
+	}
+	if q.MaxAmount != nil {
+		add("t.amount <= $%d", toNumeric(*q.MaxAmount))
+	}
+	if q.Description != "" {
+		add("t.description ~* $%d", q.Description)
+	}
+	if len(q.Tags) > 0 {
+		add("t.tags @> $%d::text[]", q.Tags)
+	}
+	if q.StatementID != "" {
+		add("s.id = $%d::uuid", q.StatementID)
+	}
+	if !q.StatementDate.IsZero() {
+		add("s.statement_date = $%d", q.StatementDate)
+	}
+
+	query := `
+		SELECT t.id, a.account_number, a.account_name, COALESCE(a.account_type, ''), COALESCE(a.debit_credit, ''),
+		       s.id, s.statement_date, t.sequence, t.date, t.descriptions, COALESCE(t.description, ''), t.type,
+		       t.amount, t.balance, COALESCE(t.reference, ''), COALESCE(t.tags, '{}'), COALESCE(t.data, '{}')
+		FROM transactions t
+		JOIN statements s ON s.id = t.statement_id
+		JOIN accounts a ON a.id = s.account_id
+	`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY " + orderBy(sortColumns, q) + limitOffset(q.Limit, q.Offset)
+
+	rows, err := db.conn().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var records []store.TransactionRecord
+	for rows.Next() {
+		var r store.TransactionRecord
+		var amount, balance pgtype.Numeric
+		if err := rows.Scan(
+			&r.ID, &r.AccountNumber, &r.AccountName, &r.AccountType, &r.DebitCredit, &r.StatementID, &r.StatementDate,
+			&r.Sequence, &r.Date, &r.Descriptions, &r.Description, &r.Type,
+			&amount, &balance, &r.Reference, &r.Tags, &r.Data,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		r.Amount, r.Balance = fromNumeric(amount), fromNumeric(balance)
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// limitOffset builds the LIMIT and OFFSET clauses; a zero limit means no limit
+func limitOffset(limit, offset int) string {
+	var clause string
+	if limit > 0 {
+		clause += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	if offset > 0 {
+		clause += fmt.Sprintf(" OFFSET %d", offset)
+	}
+	return clause
+}
+
+// orderBy builds the ORDER BY clause for q, breaking ties by date and statement order
+func orderBy(columns map[string]string, q store.TransactionQuery) string {
+	dir := "ASC"
+	if q.Desc {
+		dir = "DESC"
+	}
+	sort := q.Sort
+	if sort == "" {
+		sort = store.SortDate
+	}
+	return fmt.Sprintf("%s %s, t.date %s, a.account_number, s.statement_date, t.sequence %s", columns[sort], dir, dir, dir)
+}
+
+// statementSelect selects statements with their account, to be completed with
+// WHERE and ORDER BY clauses on the aliases s and a
+const statementSelect = `
+	SELECT s.id, a.account_number, a.account_name, COALESCE(a.account_type, ''), COALESCE(a.debit_credit, ''),
+	       s.source, s.statement_date, s.starting_balance, s.ending_balance, s.calculated_ending_balance,
+	       s.total_credit, s.total_debit, s.nett, s.transaction_start_date, s.transaction_end_date,
+	       (SELECT COUNT(*) FROM transactions t WHERE t.statement_id = s.id)
+	FROM statements s
+	JOIN accounts a ON a.id = s.account_id
+`
+
+// ListStatements returns statements ordered by account and date; account optionally
+// matches an account number, or part of the account name
+func (db *DB) ListStatements(ctx context.Context, account string) ([]store.StatementRecord, error) {
+	return db.queryStatements(ctx, statementSelect+`
+		WHERE $1 = '' OR a.account_number = $1 OR a.account_name ILIKE '%' || $1 || '%'
+		ORDER BY a.account_number, s.statement_date
+	`, account)
+}
+
+// QueryStatements returns the statements of one account matching q
+func (db *DB) QueryStatements(ctx context.Context, q store.StatementQuery) ([]store.StatementRecord, error) {
+	if !validUUID(q.AccountID) {
+		return nil, nil // No account has a malformed id
+	}
+	where := []string{"a.id = $1::uuid"}
+	args := []any{q.AccountID}
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if !q.From.IsZero() {
+		add("s.statement_date >= $%d", q.From)
+	}
+	if !q.To.IsZero() {
+		add("s.statement_date <= $%d", q.To)
+	}
+
+	dir := "ASC"
+	if q.Desc {
+		dir = "DESC"
+	}
+	query := statementSelect + " WHERE " + strings.Join(where, " AND ") +
+		" ORDER BY s.statement_date " + dir + limitOffset(q.Limit, q.Offset)
+	return db.queryStatements(ctx, query, args...)
+}
+
+// queryStatements runs a query built on statementSelect
+func (db *DB) queryStatements(ctx context.Context, query string, args ...any) ([]store.StatementRecord, error) {
+	rows, err := db.conn().Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query statements: %w", err)
+	}
+	defer rows.Close()
+
+	var records []store.StatementRecord
+	for rows.Next() {
+		var r store.StatementRecord
+		var starting, ending, calculated, credit, debit, nett pgtype.Numeric
+		var start, end pgtype.Date
+		if err := rows.Scan(
+			&r.ID, &r.AccountNumber, &r.AccountName, &r.AccountType, &r.DebitCredit,
+			&r.Source, &r.StatementDate, &starting, &ending, &calculated,
+			&credit, &debit, &nett, &start, &end, &r.TransactionCount,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan statement: %w", err)
+		}
+		r.StartingBalance, r.EndingBalance, r.CalculatedEndingBalance = fromNumeric(starting), fromNumeric(ending), fromNumeric(calculated)
+		r.TotalCredit, r.TotalDebit, r.Nett = fromNumeric(credit), fromNumeric(debit), fromNumeric(nett)
+		if start.Valid {
+			r.TransactionStartDate = start.Time
+		}
+		if end.Valid {
+			r.TransactionEndDate = end.Time
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
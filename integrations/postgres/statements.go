@@ -11,7 +11,7 @@ import (
 // StatementExists checks if a statement already exists using natural key
 func (db *DB) StatementExists(ctx context.Context, accountID string, statementDate time.Time) (bool, string, error) {
 	var id string
-	err := db.Pool.QueryRow(ctx, `
+	err := db.conn().QueryRow(ctx, `
 		SELECT id FROM statements 
 		WHERE account_id = $1 AND statement_date = $2
 	`, accountID, statementDate).Scan(&id)
@@ -43,7 +43,7 @@ func (db *DB) CreateStatement(ctx context.Context, accountID string, stmt common
 		txEndDate = &stmt.TransactionEndDate
 	}
 
-	err := db.Pool.QueryRow(ctx, `
+	err := db.conn().QueryRow(ctx, `
 		INSERT INTO statements (
 			account_id, source, statement_date,
 			starting_balance, ending_balance, calculated_ending_balance,
@@ -67,9 +67,44 @@ func (db *DB) CreateStatement(ctx context.Context, accountID string, stmt common
 
 // DeleteStatement removes a statement and its transactions (cascade)
 func (db *DB) DeleteStatement(ctx context.Context, statementID string) error {
-	_, err := db.Pool.Exec(ctx, `DELETE FROM statements WHERE id = $1`, statementID)
+	_, err := db.conn().Exec(ctx, `DELETE FROM statements WHERE id = $1`, statementID)
 	if err != nil {
 		return fmt.Errorf("failed to delete statement: %w", err)
 	}
 	return nil
 }
+
+// UpdateStatement refreshes the extracted fields of an existing statement.
+// Returns false when nothing differed from what is stored.
+func (db *DB) UpdateStatement(ctx context.Context, statementID string, stmt common.Statement) (bool, error) {
+	var txStartDate, txEndDate *time.Time
+	if !stmt.TransactionStartDate.IsZero() {
+		txStartDate = &stmt.TransactionStartDate
+	}
+	if !stmt.TransactionEndDate.IsZero() {
+		txEndDate = &stmt.TransactionEndDate
+	}
+
+	tag, err := db.conn().Exec(ctx, `
+		UPDATE statements
+		SET source = $2,
+		    starting_balance = $3, ending_balance = $4, calculated_ending_balance = $5,
+		    total_credit = $6, total_debit = $7, nett = $8,
+		    transaction_start_date = $9, transaction_end_date = $10
+		WHERE id = $1
+		  AND (source, starting_balance, ending_balance, calculated_ending_balance,
+		       total_credit, total_debit, nett, transaction_start_date, transaction_end_date)
+		      IS DISTINCT FROM
+		      ($2::varchar, $3::numeric, $4::numeric, $5::numeric,
+		       $6::numeric, $7::numeric, $8::numeric, $9::date, $10::date)
+	`,
+		statementID, stmt.Source,
+		stmt.StartingBalance, stmt.EndingBalance, stmt.CalculatedEndingBalance,
+		stmt.TotalCredit, stmt.TotalDebit, stmt.Nett,
+		txStartDate, txEndDate,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to update statement: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
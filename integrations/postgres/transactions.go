@@ -4,95 +4,298 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"regexp"
 	"strings"
 
 	"github.com/aqlanhadi/kwgn/extractor/common"
+	"github.com/aqlanhadi/kwgn/integrations/store"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
 )
 
-// normalizeDescription joins the descriptions array, collapses whitespace, and uppercases
-// Result: "TRANSFER TO A/C AQLAN HADI BIN NOR * MAE CASA"
-func normalizeDescription(descriptions []string) string {
-	joined := strings.Join(descriptions, " ")
-	// Collapse multiple spaces into single space
-	spaceRegex := regexp.MustCompile(`\s+`)
-	normalized := spaceRegex.ReplaceAllString(joined, " ")
-	return strings.ToUpper(strings.TrimSpace(normalized))
-}
+// CopyThreshold is the number of transactions above which inserts go through
+// COPY into a staging table instead of a batch of single-row INSERTs
+var CopyThreshold = 500
 
-// CreateTransactions bulk inserts transactions for a statement
-func (db *DB) CreateTransactions(ctx context.Context, statementID string, transactions []common.Transaction) error {
-	return db.CreateTransactionsIdempotent(ctx, statementID, transactions, false)
+// transactionColumns are the columns written for each extracted transaction
+var transactionColumns = []string{
+	"statement_id", "sequence", "date", "descriptions", "description",
+	"type", "amount", "balance", "reference", "tags", "data",
 }
 
-// CreateTransactionsIdempotent bulk inserts transactions for a statement with optional idempotent handling.
-// When idempotent is true, duplicate transactions (by reference) are silently skipped using ON CONFLICT DO NOTHING.
+// onReferenceConflict skips rows whose non-empty reference is already stored for
+// the statement. Only the idx_transactions_unique_reference clash is ignored, so a
+// row clashing on (statement_id, sequence) still fails instead of being counted as skipped.
+const onReferenceConflict = ` ON CONFLICT (statement_id, reference) WHERE reference != '' DO NOTHING`
+
+// CreateTransactions bulk inserts transactions for a statement with optional idempotent handling.
+// When idempotent is true, duplicate transactions (by reference) are skipped with onReferenceConflict.
+// Returns the number of rows inserted; in idempotent mode the remainder were duplicates.
+// Safe to call inside InTx: no statement is expected to fail.
 // This is useful for TNG CSV imports where the same transactions may appear in multiple export files.
-func (db *DB) CreateTransactionsIdempotent(ctx context.Context, statementID string, transactions []common.Transaction, idempotent bool) error {
+//
+// Up to CopyThreshold rows are inserted with a pgx.Batch; larger sets are
+// streamed with COPY, which is much faster for multi-year exports.
+func (db *DB) CreateTransactions(ctx context.Context, statementID string, transactions []common.Transaction, idempotent bool) (int, error) {
 	if len(transactions) == 0 {
-		return nil
+		return 0, nil
+	}
+	if len(transactions) > CopyThreshold {
+		return db.copyTransactions(ctx, statementID, transactions, idempotent)
+	}
+	return db.batchTransactions(ctx, statementID, transactions, idempotent)
+}
+
+// transactionRow returns the values for transactionColumns
+func transactionRow(statementID string, tx common.Transaction) []any {
+	// Serialize data to JSON, default to empty object
+	dataJSON := []byte("{}")
+	if tx.Data != nil {
+		var err error
+		dataJSON, err = json.Marshal(tx.Data)
+		if err != nil {
+			dataJSON = []byte("{}")
+		}
+	}
+
+	// Default tags to empty array
+	tags := tx.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	// Normalize description for matching (join, collapse spaces, uppercase)
+	description := store.NormalizeDescription(tx.Descriptions)
+
+	return []any{
+		statementID, tx.Sequence, tx.Date, tx.Descriptions, description,
+		tx.Type, toNumeric(tx.Amount), toNumeric(tx.Balance), tx.Reference, tags, dataJSON,
+	}
+}
+
+// toNumeric converts a decimal to a pgtype.Numeric, which encodes in both
+// the text and binary (COPY) protocols
+func toNumeric(d decimal.Decimal) pgtype.Numeric {
+	var n pgtype.Numeric
+	_ = n.Scan(d.String()) // decimal.String always yields a valid numeric literal
+	return n
+}
+
+// batchTransactions inserts transactions one statement per row in a single batch
+func (db *DB) batchTransactions(ctx context.Context, statementID string, transactions []common.Transaction, idempotent bool) (int, error) {
+	sql := `
+		INSERT INTO transactions (
+			statement_id, sequence, date, descriptions, description, type, amount, balance, reference, tags, data
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	if idempotent {
+		sql += onReferenceConflict
 	}
 
 	batch := &pgx.Batch{}
 	for _, tx := range transactions {
-		// Serialize data to JSON, default to empty object
-		dataJSON := []byte("{}")
-		if tx.Data != nil {
-			var err error
-			dataJSON, err = json.Marshal(tx.Data)
-			if err != nil {
-				dataJSON = []byte("{}")
-			}
+		batch.Queue(sql, transactionRow(statementID, tx)...)
+	}
+
+	br := db.conn().SendBatch(ctx, batch)
+	defer br.Close()
+
+	inserted := 0
+	for range transactions {
+		// Duplicate references are skipped in idempotent mode, so any error is real
+		tag, err := br.Exec()
+		if err != nil {
+			return inserted, fmt.Errorf("failed to insert transaction: %w", err)
 		}
+		inserted += int(tag.RowsAffected())
+	}
 
-		// Default tags to empty array
-		tags := tx.Tags
-		if tags == nil {
-			tags = []string{}
+	return inserted, nil
+}
+
+// copyTransactions streams transactions into a temporary staging table with COPY
+// and moves them into transactions with a single INSERT ... SELECT, so duplicate
+// references are still skipped in idempotent mode.
+func (db *DB) copyTransactions(ctx context.Context, statementID string, transactions []common.Transaction, idempotent bool) (int, error) {
+	inserted := 0
+	err := db.inTx(ctx, func(tx *DB) error {
+		// The staging table only lives for this transaction
+		_, err := tx.conn().Exec(ctx, `
+			CREATE TEMP TABLE transactions_staging (LIKE transactions INCLUDING DEFAULTS) ON COMMIT DROP
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to create staging table: %w", err)
 		}
 
-		// Normalize description for matching (join, collapse spaces, uppercase)
-		description := normalizeDescription(tx.Descriptions)
+		rows := make([][]any, len(transactions))
+		for i, t := range transactions {
+			rows[i] = transactionRow(statementID, t)
+		}
+		if _, err := tx.conn().CopyFrom(ctx, pgx.Identifier{"transactions_staging"}, transactionColumns, pgx.CopyFromRows(rows)); err != nil {
+			return fmt.Errorf("failed to copy transactions: %w", err)
+		}
 
-		// Use ON CONFLICT DO NOTHING for idempotent imports
-		// This relies on the unique index idx_transactions_unique_reference (statement_id, reference) WHERE reference != ''
-		var sql string
-		if idempotent && tx.Reference != "" {
-			sql = `
-				INSERT INTO transactions (
-					statement_id, sequence, date, descriptions, description, type, amount, balance, reference, tags, data
-				) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-				ON CONFLICT (statement_id, reference) WHERE reference != '' DO NOTHING
-			`
-		} else {
-			sql = `
-				INSERT INTO transactions (
-					statement_id, sequence, date, descriptions, description, type, amount, balance, reference, tags, data
-				) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-			`
+		columns := strings.Join(transactionColumns, ", ")
+		sql := fmt.Sprintf(`INSERT INTO transactions (%s) SELECT %s FROM transactions_staging ORDER BY sequence`, columns, columns)
+		if idempotent {
+			sql += onReferenceConflict
+		}
+		tag, err := tx.conn().Exec(ctx, sql)
+		if err != nil {
+			return fmt.Errorf("failed to insert transactions: %w", err)
 		}
+		inserted = int(tag.RowsAffected())
 
-		batch.Queue(sql,
-			statementID, tx.Sequence, tx.Date, tx.Descriptions, description,
-			tx.Type, tx.Amount, tx.Balance, tx.Reference, tags, dataJSON,
-		)
+		// Drop now so another copy in the same transaction can recreate it
+		if _, err := tx.conn().Exec(ctx, `DROP TABLE transactions_staging`); err != nil {
+			return fmt.Errorf("failed to drop staging table: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
+	return inserted, nil
+}
 
-	br := db.Pool.SendBatch(ctx, batch)
-	defer br.Close()
+// TransactionKeys returns the sequences and non-empty references already stored for a statement
+func (db *DB) TransactionKeys(ctx context.Context, statementID string) (sequences map[int]bool, references map[string]bool, err error) {
+	rows, err := db.conn().Query(ctx, `
+		SELECT sequence, COALESCE(reference, '') FROM transactions WHERE statement_id = $1
+	`, statementID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query transactions: %w", err)
+	}
+	defer rows.Close()
 
-	for range transactions {
-		_, err := br.Exec()
-		if err != nil {
-			// For idempotent mode, we expect some inserts to be skipped
-			// Check if it's a unique constraint violation and ignore it
-			if idempotent {
-				continue // Skip errors in idempotent mode (likely unique violations)
-			}
-			return fmt.Errorf("failed to insert transaction: %w", err)
+	sequences = make(map[int]bool)
+	references = make(map[string]bool)
+	for rows.Next() {
+		var seq int
+		var ref string
+		if err := rows.Scan(&seq, &ref); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		sequences[seq] = true
+		if ref != "" {
+			references[ref] = true
+		}
+	}
+	return sequences, references, rows.Err()
+}
+
+// StoredTransactions loads the extracted columns of a statement's transactions
+func (db *DB) StoredTransactions(ctx context.Context, statementID string) ([]store.StoredTransaction, error) {
+	rows, err := db.conn().Query(ctx, `
+		SELECT id, sequence, date, descriptions, type, amount, balance, COALESCE(reference, '')
+		FROM transactions WHERE statement_id = $1
+		ORDER BY sequence
+	`, statementID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var stored []store.StoredTransaction
+	for rows.Next() {
+		var s store.StoredTransaction
+		var amount, balance pgtype.Numeric
+		if err := rows.Scan(&s.ID, &s.Sequence, &s.Date, &s.Descriptions, &s.Type, &amount, &balance, &s.Reference); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
 		}
+		s.Amount, s.Balance = fromNumeric(amount), fromNumeric(balance)
+		stored = append(stored, s)
 	}
+	return stored, rows.Err()
+}
+
+// fromNumeric converts a pgtype.Numeric back to a decimal
+func fromNumeric(n pgtype.Numeric) decimal.Decimal {
+	if !n.Valid || n.Int == nil {
+		return decimal.Zero
+	}
+	return decimal.NewFromBigInt(n.Int, n.Exp)
+}
+
+// UpdateTransactions rewrites the extracted fields of existing transactions of a
+// statement; tags and data are left untouched
+func (db *DB) UpdateTransactions(ctx context.Context, statementID string, updates []store.TransactionUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	return db.inTx(ctx, func(tx *DB) error {
+		// Park the updated rows on negative sequences so renumbering cannot
+		// collide with the (statement_id, sequence) unique constraint midway
+		ids := make([]string, len(updates))
+		for i, u := range updates {
+			ids[i] = u.ID
+		}
+		if _, err := tx.conn().Exec(ctx, `
+			UPDATE transactions SET sequence = -1 - sequence
+			WHERE statement_id = $1 AND id = ANY($2::uuid[])
+		`, statementID, ids); err != nil {
+			return fmt.Errorf("failed to renumber transactions: %w", err)
+		}
 
+		batch := &pgx.Batch{}
+		for _, u := range updates {
+			t := u.Transaction
+			batch.Queue(`
+				UPDATE transactions
+				SET sequence = $2, date = $3, descriptions = $4, description = $5,
+				    type = $6, amount = $7, balance = $8, reference = $9
+				WHERE id = $1
+			`, u.ID, t.Sequence, t.Date, t.Descriptions, store.NormalizeDescription(t.Descriptions),
+				t.Type, toNumeric(t.Amount), toNumeric(t.Balance), t.Reference)
+		}
+
+		br := tx.conn().SendBatch(ctx, batch)
+		defer br.Close()
+		for range updates {
+			if _, err := br.Exec(); err != nil {
+				return fmt.Errorf("failed to update transaction: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteTransactions removes transactions by id
+func (db *DB) DeleteTransactions(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if _, err := db.conn().Exec(ctx, `DELETE FROM transactions WHERE id = ANY($1::uuid[])`, ids); err != nil {
+		return fmt.Errorf("failed to delete transactions: %w", err)
+	}
 	return nil
 }
+
+// EditTransaction applies edit to the tags and data of a transaction; false if there is none
+func (db *DB) EditTransaction(ctx context.Context, id string, edit store.TransactionEdit) (bool, error) {
+	if !validUUID(id) {
+		return false, nil
+	}
+	var tags, data any // NULL keeps the current value
+	if edit.Tags != nil {
+		tags = *edit.Tags
+		if *edit.Tags == nil {
+			tags = []string{}
+		}
+	}
+	if edit.Data != nil {
+		encoded, err := json.Marshal(edit.Data)
+		if err != nil {
+			return false, fmt.Errorf("failed to encode transaction data: %w", err)
+		}
+		data = encoded
+	}
+	tag, err := db.conn().Exec(ctx, `
+		UPDATE transactions SET tags = COALESCE($1::text[], tags), data = COALESCE($2::jsonb, data) WHERE id = $3::uuid
+	`, tags, data, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to edit transaction: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/extractor/common"
+	"github.com/shopspring/decimal"
+)
+
+func TestToNumeric_RoundTrip(t *testing.T) {
+	for _, s := range []string{"0", "12.34", "-1500.05", "99999999.99"} {
+		n := toNumeric(decimal.RequireFromString(s))
+		if !n.Valid {
+			t.Fatalf("Expected %s to convert to a valid numeric", s)
+		}
+
+		v, err := n.Value()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !decimal.RequireFromString(v.(string)).Equal(decimal.RequireFromString(s)) {
+			t.Errorf("Expected %s, got %v", s, v)
+		}
+	}
+}
+
+// openTest connects to the database in KWGN_TEST_DATABASE_URL, skipping the test
+// when it is unset, and creates a statement that is deleted with its account afterwards
+func openTest(t *testing.T) (*DB, string) {
+	t.Helper()
+	url := os.Getenv("KWGN_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("KWGN_TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	db, err := Connect(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	if err := db.EnsureSchema(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	number := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	accountID, err := db.GetOrCreateAccount(ctx, common.Account{AccountNumber: number})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Pool.Exec(context.Background(), `DELETE FROM accounts WHERE id = $1`, accountID)
+	})
+	date := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	statementID, err := db.CreateStatement(ctx, accountID, common.Statement{StatementDate: &date})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, statementID
+}
+
+// rows returns transactions with sequences from..to, referenced "R<sequence>"
+func rows(from, to int) []common.Transaction {
+	var transactions []common.Transaction
+	for seq := from; seq <= to; seq++ {
+		transactions = append(transactions, common.Transaction{
+			Sequence:     seq,
+			Date:         time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+			Descriptions: []string{"TOLL"},
+			Type:         "debit",
+			Amount:       decimal.RequireFromString("1.50"),
+			Reference:    fmt.Sprintf("R%d", seq),
+		})
+	}
+	return transactions
+}
+
+func TestCreateTransactions_BatchAndCopy(t *testing.T) {
+	defer func(threshold int) { CopyThreshold = threshold }(CopyThreshold)
+	CopyThreshold = 3
+
+	for _, tc := range []struct {
+		name  string
+		first []common.Transaction // Stored before the idempotent insert
+	}{
+		{"batch", rows(1, 2)},
+		{"copy", rows(1, 4)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			db, id := openTest(t)
+
+			n, err := db.CreateTransactions(ctx, id, tc.first, false)
+			if err != nil || n != len(tc.first) {
+				t.Fatalf("first insert = %d, %v; want %d", n, err, len(tc.first))
+			}
+
+			// Same size as the first insert, so it takes the same path: the stored
+			// references are skipped and only the rows after them are inserted
+			again := rows(2, len(tc.first)+1)
+			n, err = db.CreateTransactions(ctx, id, again, true)
+			if err != nil || n != 1 {
+				t.Fatalf("idempotent insert = %d, %v; want 1", n, err)
+			}
+
+			// A clash on sequence is an error, not a skipped duplicate
+			clash := rows(1, len(tc.first))
+			for i := range clash {
+				clash[i].Reference = fmt.Sprintf("NEW%d", i)
+			}
+			if _, err := db.CreateTransactions(ctx, id, clash, true); err == nil {
+				t.Fatal("expected duplicate sequences to fail")
+			}
+
+			sequences, references, err := db.TransactionKeys(ctx, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := len(tc.first) + 1; len(sequences) != want || len(references) != want {
+				t.Errorf("Expected %d stored transactions, got %v %v", want, sequences, references)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/extractor/common"
+	"github.com/aqlanhadi/kwgn/integrations/store"
+	"github.com/shopspring/decimal"
+)
+
+// GetOrCreateAccount finds an existing account by number or creates a new one
+func (db *DB) GetOrCreateAccount(ctx context.Context, account common.Account) (string, error) {
+	var id string
+
+	// Try to find existing account
+	err := db.conn().QueryRowContext(ctx, `
+		SELECT id FROM accounts WHERE account_number = ?
+	`, account.AccountNumber).Scan(&id)
+
+	if err == nil {
+		// Account exists, update fields
+		// - Always update account_name (source identifier from extractor)
+		// - Only update account_type and debit_credit if non-empty (preserve user-set values)
+		_, err = db.conn().ExecContext(ctx, `
+			UPDATE accounts
+			SET account_name = ?1,
+			    account_type = CASE WHEN ?2 != '' THEN ?2 ELSE account_type END,
+			    debit_credit = CASE WHEN ?3 != '' THEN ?3 ELSE debit_credit END,
+			    reconciliable = ?4,
+			    updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+			WHERE id = ?5
+		`, account.AccountName, account.AccountType, account.DebitCredit, account.Reconciliable, id)
+		if err != nil {
+			return "", fmt.Errorf("failed to update account: %w", err)
+		}
+		return id, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("failed to find account: %w", err)
+	}
+
+	// Create new account
+	id = newID()
+	_, err = db.conn().ExecContext(ctx, `
+		INSERT INTO accounts (id, account_number, account_name, account_type, debit_credit, reconciliable)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, id, account.AccountNumber, account.AccountName, account.AccountType, account.DebitCredit, account.Reconciliable)
+	if err != nil {
+		return "", fmt.Errorf("failed to create account: %w", err)
+	}
+
+	return id, nil
+}
+
+// FindAccount looks up an account by number without modifying it
+// Returns nil if the account does not exist
+func (db *DB) FindAccount(ctx context.Context, accountNumber string) (*store.StoredAccount, error) {
+	var a store.StoredAccount
+	err := db.conn().QueryRowContext(ctx, `
+		SELECT id, account_number, account_name, COALESCE(account_type, ''),
+		       COALESCE(debit_credit, ''), COALESCE(reconciliable, 0)
+		FROM accounts WHERE account_number = ?
+	`, accountNumber).Scan(&a.ID, &a.AccountNumber, &a.AccountName, &a.AccountType, &a.DebitCredit, &a.Reconciliable)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find account: %w", err)
+	}
+	return &a, nil
+}
+
+// accountSelect selects accounts with their latest statement, to be completed with
+// WHERE and ORDER BY clauses on the accounts alias a
+const accountSelect = `
+	SELECT a.id, a.account_number, a.account_name, COALESCE(a.account_type, ''),
+	       COALESCE(a.debit_credit, ''), COALESCE(a.reconciliable, 0),
+	       (SELECT COUNT(*) FROM statements s WHERE s.account_id = a.id),
+	       l.id, l.statement_date, l.ending_balance
+	FROM accounts a
+	LEFT JOIN statements l ON l.id = (
+		SELECT s.id FROM statements s WHERE s.account_id = a.id ORDER BY s.statement_date DESC LIMIT 1
+	)
+`
+
+// GetAccount returns the account with the given id and its latest statement; nil if there is none
+func (db *DB) GetAccount(ctx context.Context, id string) (*store.AccountRecord, error) {
+	records, err := db.queryAccounts(ctx, accountSelect+" WHERE a.id = ?1", id)
+	if err != nil || len(records) == 0 {
+		return nil, err
+	}
+	return &records[0], nil
+}
+
+// ListAccounts returns the accounts matching q with their latest statement, ordered by account number
+func (db *DB) ListAccounts(ctx context.Context, q store.AccountQuery) ([]store.AccountRecord, error) {
+	var where []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+
+	if q.Account != "" {
+		add("(a.account_number = ?%[1]d OR a.account_name LIKE '%%' || ?%[1]d || '%%')", q.Account)
+	}
+	if q.Type != "" {
+		add("a.account_type = ?%d COLLATE NOCASE", q.Type)
+	}
+
+	query := accountSelect
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY a.account_number" + limitOffset(q.Limit, q.Offset)
+	return db.queryAccounts(ctx, query, args...)
+}
+
+// queryAccounts runs a query built on accountSelect
+func (db *DB) queryAccounts(ctx context.Context, query string, args ...any) ([]store.AccountRecord, error) {
+	rows, err := db.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var records []store.AccountRecord
+	for rows.Next() {
+		var r store.AccountRecord
+		var latestID, latestDate, latestBalance sql.NullString
+		if err := rows.Scan(
+			&r.ID, &r.AccountNumber, &r.AccountName, &r.AccountType, &r.DebitCredit, &r.Reconciliable,
+			&r.StatementCount, &latestID, &latestDate, &latestBalance,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		if latestID.Valid {
+			r.LatestStatementID = &latestID.String
+			date, err := time.Parse(time.DateOnly, latestDate.String)
+			if err != nil {
+				return nil, fmt.Errorf("invalid statement date %q: %w", latestDate.String, err)
+			}
+			r.LatestStatementDate = &date
+		}
+		if latestBalance.Valid {
+			balance, err := decimal.NewFromString(latestBalance.String)
+			if err != nil {
+				return nil, fmt.Errorf("invalid statement balance %q: %w", latestBalance.String, err)
+			}
+			r.LatestBalance = &balance
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// EditAccount applies edit to the account with the given id; false if there is none
+func (db *DB) EditAccount(ctx context.Context, id string, edit store.AccountEdit) (bool, error) {
+	var accountType any // NULL keeps the current value
+	if edit.AccountType != nil {
+		accountType = *edit.AccountType
+	}
+	res, err := db.conn().ExecContext(ctx, `
+		UPDATE accounts
+		SET account_type = COALESCE(?1, account_type),
+		    updated_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+		WHERE id = ?2
+	`, accountType, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to edit account: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
@@ -0,0 +1,92 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/integrations/store"
+)
+
+const apiKeyColumns = `id, name, prefix, key_hash, scopes, rate_limit, created_at, COALESCE(revoked_at, '')`
+
+// scanAPIKey reads an api_keys row selected with apiKeyColumns
+func scanAPIKey(row scanner) (store.APIKey, error) {
+	var key store.APIKey
+	var scopes, createdAt, revokedAt string
+	if err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.Hash, &scopes, &key.RateLimit, &createdAt, &revokedAt); err != nil {
+		return key, err
+	}
+	if err := json.Unmarshal([]byte(scopes), &key.Scopes); err != nil {
+		return key, fmt.Errorf("invalid scopes: %w", err)
+	}
+	key.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	if revokedAt != "" {
+		t, _ := time.Parse(time.RFC3339, revokedAt)
+		key.RevokedAt = &t
+	}
+	return key, nil
+}
+
+// CreateAPIKey stores a new API key and returns its id
+func (db *DB) CreateAPIKey(ctx context.Context, key store.APIKey) (string, error) {
+	id := newID()
+	_, err := db.conn().ExecContext(ctx, `
+		INSERT INTO api_keys (id, name, prefix, key_hash, scopes, rate_limit)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, id, key.Name, key.Prefix, key.Hash, jsonText(key.Scopes, "[]"), key.RateLimit)
+	if err != nil {
+		return "", fmt.Errorf("failed to create API key: %w", err)
+	}
+	return id, nil
+}
+
+// FindAPIKey looks up an API key by hash
+// Returns nil if there is no such key
+func (db *DB) FindAPIKey(ctx context.Context, hash string) (*store.APIKey, error) {
+	key, err := scanAPIKey(db.conn().QueryRowContext(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = ?`, hash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find API key: %w", err)
+	}
+	return &key, nil
+}
+
+// ListAPIKeys returns every API key, revoked ones included, oldest first
+func (db *DB) ListAPIKeys(ctx context.Context) ([]store.APIKey, error) {
+	rows, err := db.conn().QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY created_at, name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []store.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey revokes the key with the given id or prefix.
+// Returns false if no active key matches.
+func (db *DB) RevokeAPIKey(ctx context.Context, ref string) (bool, error) {
+	result, err := db.conn().ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+		WHERE (id = ?1 OR prefix = ?1) AND revoked_at IS NULL
+	`, ref)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
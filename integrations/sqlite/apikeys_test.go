@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aqlanhadi/kwgn/integrations/store"
+)
+
+func TestAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	db := openTest(t)
+
+	token, key, err := store.NewAPIKey("laptop", []string{store.ScopeExtract, store.ScopeRead}, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := db.CreateAPIKey(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := db.FindAPIKey(ctx, store.HashAPIKey(token))
+	if err != nil || found == nil {
+		t.Fatalf("FindAPIKey = %v, %v", found, err)
+	}
+	if found.ID != id || found.Name != "laptop" || found.RateLimit != 30 || !found.HasScope(store.ScopeRead) || found.HasScope(store.ScopeImport) {
+		t.Errorf("Unexpected key: %+v", found)
+	}
+	if found.CreatedAt.IsZero() || found.Revoked() {
+		t.Errorf("Expected an active key with a creation time: %+v", found)
+	}
+	if missing, err := db.FindAPIKey(ctx, store.HashAPIKey("kwgn_other")); missing != nil || err != nil {
+		t.Errorf("FindAPIKey(unknown) = %v, %v", missing, err)
+	}
+
+	// Revoked by prefix; revoking again finds no active key
+	if ok, err := db.RevokeAPIKey(ctx, key.Prefix); !ok || err != nil {
+		t.Fatalf("RevokeAPIKey = %v, %v", ok, err)
+	}
+	if ok, _ := db.RevokeAPIKey(ctx, id); ok {
+		t.Error("Expected revoking a revoked key to report false")
+	}
+
+	keys, err := db.ListAPIKeys(ctx)
+	if err != nil || len(keys) != 1 || !keys[0].Revoked() {
+		t.Fatalf("ListAPIKeys = %+v, %v", keys, err)
+	}
+}
@@ -0,0 +1,158 @@
+// Package sqlite stores imported statements in a single-file SQLite database.
+// It implements store.Store with the same deduplication semantics as the
+// PostgreSQL backend, using the pure-Go modernc.org/sqlite driver (no cgo).
+package sqlite
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/aqlanhadi/kwgn/integrations/store"
+	_ "modernc.org/sqlite"
+)
+
+// DB wraps a SQLite database and implements store.Store
+type DB struct {
+	sql *sql.DB
+	tx  *sql.Tx // Set on the DB handed to InTx callbacks
+}
+
+var _ store.Store = (*DB)(nil)
+
+// querier is implemented by both *sql.DB and *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// conn returns the transaction when running inside InTx, otherwise the database
+func (db *DB) conn() querier {
+	if db.tx != nil {
+		return db.tx
+	}
+	return db.sql
+}
+
+// IsURL reports whether a --db-url value selects the SQLite backend
+func IsURL(dbURL string) bool {
+	return strings.HasPrefix(dbURL, "sqlite:")
+}
+
+// PathFromURL extracts the database file from sqlite:<path>, sqlite://<path> or
+// sqlite:///<absolute path>. sqlite::memory: opens an in-memory database.
+func PathFromURL(dbURL string) (string, error) {
+	if !IsURL(dbURL) {
+		return "", fmt.Errorf("not a sqlite URL: %s", dbURL)
+	}
+	path := strings.TrimPrefix(dbURL, "sqlite:")
+	path = strings.TrimPrefix(path, "//")
+	if path == "" {
+		return "", fmt.Errorf("sqlite URL has no database path: %s", dbURL)
+	}
+	return path, nil
+}
+
+// Open opens (creating if needed) the SQLite database at path.
+//
+// Foreign keys are enforced so deleting a statement cascades to its transactions,
+// and transactions take the write lock up front so concurrent imports queue on
+// busy_timeout instead of failing. SQLite allows a single writer, so the pool
+// is limited to one connection.
+func Open(ctx context.Context, path string) (*DB, error) {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(10000)")
+	params.Add("_txlock", "immediate")
+	if path != ":memory:" {
+		params.Add("_pragma", "journal_mode(WAL)")
+	}
+	return open(ctx, path, params)
+}
+
+// OpenReadOnly opens the existing SQLite database at path without writing to
+// it, for dry runs. Unlike Open it never creates the file.
+func OpenReadOnly(ctx context.Context, path string) (*DB, error) {
+	if path != ":memory:" {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("failed to open database: %w", err)
+		}
+	}
+
+	params := url.Values{}
+	params.Add("mode", "ro")
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(10000)")
+	return open(ctx, path, params)
+}
+
+func open(ctx context.Context, path string, params url.Values) (*DB, error) {
+	conn, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	conn.SetMaxOpenConns(1)
+
+	if err := conn.PingContext(ctx); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	return &DB{sql: conn}, nil
+}
+
+// Close closes the database
+func (db *DB) Close() {
+	if db.sql != nil {
+		db.sql.Close()
+	}
+}
+
+// InTx runs fn inside a single database transaction.
+// All queries made through the store passed to fn are part of the transaction; it is
+// committed when fn returns nil and rolled back on error or panic.
+func (db *DB) InTx(ctx context.Context, fn func(tx store.Store) error) error {
+	return db.inTx(ctx, func(tx *DB) error { return fn(tx) })
+}
+
+// inTx is InTx for code inside this package that needs the concrete *DB
+func (db *DB) inTx(ctx context.Context, fn func(tx *DB) error) (err error) {
+	if db.tx != nil {
+		// Already in a transaction, join it
+		return fn(db)
+	}
+
+	tx, err := db.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		if cerr := tx.Commit(); cerr != nil {
+			err = fmt.Errorf("failed to commit transaction: %w", cerr)
+		}
+	}()
+
+	return fn(&DB{sql: db.sql, tx: tx})
+}
+
+// newID returns a random (version 4) UUID string, matching the ids PostgreSQL generates
+func newID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
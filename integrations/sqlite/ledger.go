@@ -0,0 +1,131 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/integrations/store"
+)
+
+// scanner is implemented by *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanFileRecord reads an import_files row selected with fileRecordColumns
+func scanFileRecord(row scanner) (store.FileRecord, error) {
+	var rec store.FileRecord
+	var ids, importedAt string
+	if err := row.Scan(
+		&rec.SHA256, &rec.Path, &rec.Size, &rec.DetectedType, &rec.ExtractorVersion,
+		&ids, &rec.Outcome, &rec.Error, &rec.DuplicateOf, &importedAt,
+	); err != nil {
+		return rec, err
+	}
+	if err := json.Unmarshal([]byte(ids), &rec.StatementIDs); err != nil {
+		return rec, fmt.Errorf("invalid statement ids: %w", err)
+	}
+	rec.ImportedAt, _ = time.Parse(time.RFC3339, importedAt)
+	return rec, nil
+}
+
+const fileRecordColumns = `
+	sha256, path, size, COALESCE(detected_type, ''), COALESCE(extractor_version, ''),
+	COALESCE(statement_ids, '[]'), outcome, COALESCE(error, ''), '' AS duplicate_of, imported_at
+`
+
+// GetFileRecord looks up a ledger entry by content hash
+// Returns nil if the file has never been imported
+func (db *DB) GetFileRecord(ctx context.Context, sha string) (*store.FileRecord, error) {
+	rec, err := scanFileRecord(db.conn().QueryRowContext(ctx,
+		`SELECT `+fileRecordColumns+` FROM import_files WHERE sha256 = ?`, sha))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get file record: %w", err)
+	}
+	return &rec, nil
+}
+
+// RecordFile inserts or replaces the ledger entry for a file
+func (db *DB) RecordFile(ctx context.Context, rec store.FileRecord) error {
+	ids := rec.StatementIDs
+	if ids == nil {
+		ids = []string{}
+	}
+
+	_, err := db.conn().ExecContext(ctx, `
+		INSERT INTO import_files (
+			sha256, path, size, detected_type, extractor_version,
+			statement_ids, outcome, error, imported_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		ON CONFLICT (sha256) DO UPDATE
+		SET path = excluded.path,
+		    size = excluded.size,
+		    detected_type = excluded.detected_type,
+		    extractor_version = excluded.extractor_version,
+		    statement_ids = excluded.statement_ids,
+		    outcome = excluded.outcome,
+		    error = excluded.error,
+		    imported_at = excluded.imported_at
+	`, rec.SHA256, rec.Path, rec.Size, rec.DetectedType, rec.ExtractorVersion,
+		jsonText(ids, "[]"), rec.Outcome, rec.Error)
+	if err != nil {
+		return fmt.Errorf("failed to record file: %w", err)
+	}
+
+	// The path now holds a file of its own
+	if _, err := db.conn().ExecContext(ctx, `DELETE FROM import_duplicates WHERE path = ?`, rec.Path); err != nil {
+		return fmt.Errorf("failed to record file: %w", err)
+	}
+	return nil
+}
+
+// RecordDuplicate records that the file at path has the same content as the ledger entry for sha
+func (db *DB) RecordDuplicate(ctx context.Context, path, sha string) error {
+	_, err := db.conn().ExecContext(ctx, `
+		INSERT INTO import_duplicates (path, sha256, imported_at)
+		VALUES (?, ?, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		ON CONFLICT (path) DO UPDATE SET sha256 = excluded.sha256, imported_at = excluded.imported_at
+	`, path, sha)
+	if err != nil {
+		return fmt.Errorf("failed to record duplicate: %w", err)
+	}
+	return nil
+}
+
+// ListFileRecords returns ledger entries and duplicates, most recent first.
+// When pathPrefix is set only files under that path are returned.
+func (db *DB) ListFileRecords(ctx context.Context, pathPrefix string) ([]store.FileRecord, error) {
+	rows, err := db.conn().QueryContext(ctx, `
+		SELECT `+fileRecordColumns+`
+		FROM import_files
+		WHERE ?1 = '' OR substr(path, 1, length(?1)) = ?1
+		UNION ALL
+		SELECT f.sha256, d.path, f.size, COALESCE(f.detected_type, ''), COALESCE(f.extractor_version, ''),
+		       '[]', ?2, '', f.path, d.imported_at
+		FROM import_duplicates d
+		JOIN import_files f ON f.sha256 = d.sha256
+		WHERE ?1 = '' OR substr(d.path, 1, length(?1)) = ?1
+		ORDER BY imported_at DESC, path
+	`, pathPrefix, store.OutcomeDuplicate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list file records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []store.FileRecord
+	for rows.Next() {
+		rec, err := scanFileRecord(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan file record: %w", err)
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
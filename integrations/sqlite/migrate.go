@@ -0,0 +1,209 @@
+package sqlite
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/integrations/store"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+const migrationsTableDDL = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TEXT DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+);
+`
+
+// Migrations returns the migrations embedded in the binary, in order
+func Migrations() ([]store.Migration, error) {
+	sub, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return store.LoadMigrations(sub)
+}
+
+// appliedMigrations returns the applied migrations keyed by version.
+// With create set the schema_migrations table is created if missing;
+// otherwise a missing table simply means nothing has been applied.
+func (db *DB) appliedMigrations(ctx context.Context, create bool) (map[int]store.MigrationStatus, error) {
+	applied := make(map[int]store.MigrationStatus)
+	if create {
+		if _, err := db.conn().ExecContext(ctx, migrationsTableDDL); err != nil {
+			return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+		}
+	} else {
+		exists, err := db.TableExists(ctx, "schema_migrations")
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return applied, nil
+		}
+	}
+
+	rows, err := db.conn().QueryContext(ctx, `SELECT version, name, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		s := store.MigrationStatus{Applied: true}
+		var appliedAt string
+		if err := rows.Scan(&s.Version, &s.Name, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations: %w", err)
+		}
+		s.AppliedAt, _ = time.Parse(time.RFC3339, appliedAt)
+		applied[s.Version] = s
+	}
+	return applied, rows.Err()
+}
+
+// MigrationStatus lists every known migration and whether it has been applied.
+// Versions recorded in the database but unknown to this binary are flagged.
+func (db *DB) MigrationStatus(ctx context.Context) ([]store.MigrationStatus, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := db.appliedMigrations(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	return store.MergeMigrationStatus(migrations, applied), nil
+}
+
+// SchemaVersion returns the highest applied migration and the latest embedded one
+func (db *DB) SchemaVersion(ctx context.Context) (current int, latest int, err error) {
+	status, err := db.MigrationStatus(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	current, latest = store.Versions(status)
+	return current, latest, nil
+}
+
+// EnsureSchema brings the database up to the latest embedded migration,
+// doing nothing when it is already current
+func (db *DB) EnsureSchema(ctx context.Context) error {
+	return store.EnsureSchema(ctx, db)
+}
+
+// MigrateUp applies pending migrations in order, each in its own transaction.
+// steps limits how many are applied (0 = all). Returns the migrations applied.
+// Transactions take SQLite's write lock immediately, so concurrent migrators queue.
+func (db *DB) MigrateUp(ctx context.Context, steps int) ([]store.Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []store.Migration
+	for _, mig := range migrations {
+		if steps > 0 && len(done) >= steps {
+			break
+		}
+
+		applied := false
+		err := db.inTx(ctx, func(tx *DB) error {
+			state, err := tx.appliedMigrations(ctx, true)
+			if err != nil {
+				return err
+			}
+			if _, ok := state[mig.Version]; ok {
+				return nil
+			}
+			if err := store.CheckNoGaps(state, mig.Version); err != nil {
+				return err
+			}
+
+			if _, err := tx.conn().ExecContext(ctx, mig.Up); err != nil {
+				return fmt.Errorf("migration %04d_%s failed: %w", mig.Version, mig.Name, err)
+			}
+			if _, err := tx.conn().ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, mig.Version, mig.Name); err != nil {
+				return fmt.Errorf("failed to record migration %04d: %w", mig.Version, err)
+			}
+			applied = true
+			return nil
+		})
+		if err != nil {
+			return done, err
+		}
+		if applied {
+			done = append(done, mig)
+		}
+	}
+	return done, nil
+}
+
+// MigrateDown reverts the most recently applied migrations, newest first.
+// steps is how many to revert (minimum 1). Returns the migrations reverted.
+func (db *DB) MigrateDown(ctx context.Context, steps int) ([]store.Migration, error) {
+	if steps < 1 {
+		steps = 1
+	}
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []store.Migration
+	for len(done) < steps {
+		var reverted *store.Migration
+		err := db.inTx(ctx, func(tx *DB) error {
+			state, err := tx.appliedMigrations(ctx, true)
+			if err != nil {
+				return err
+			}
+
+			current := 0
+			for v := range state {
+				if v > current {
+					current = v
+				}
+			}
+			if current == 0 {
+				return nil
+			}
+			if current > len(migrations) {
+				return fmt.Errorf("cannot revert migration %04d: it is not known to this version of kwgn", current)
+			}
+
+			mig := migrations[current-1]
+			if _, err := tx.conn().ExecContext(ctx, mig.Down); err != nil {
+				return fmt.Errorf("reverting migration %04d_%s failed: %w", mig.Version, mig.Name, err)
+			}
+			if _, err := tx.conn().ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?`, mig.Version); err != nil {
+				return fmt.Errorf("failed to unrecord migration %04d: %w", mig.Version, err)
+			}
+			reverted = &mig
+			return nil
+		})
+		if err != nil {
+			return done, err
+		}
+		if reverted == nil {
+			break // Nothing left to revert
+		}
+		done = append(done, *reverted)
+	}
+	return done, nil
+}
+
+// TableExists reports whether a table is present in the database
+func (db *DB) TableExists(ctx context.Context, name string) (bool, error) {
+	var n int
+	err := db.conn().QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("failed to check table %s: %w", name, err)
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,113 @@
+package sqlite
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// openTest opens an in-memory database with the schema applied
+func openTest(t *testing.T) *DB {
+	t.Helper()
+	ctx := context.Background()
+	db, err := Open(ctx, ":memory:")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(db.Close)
+	if err := db.EnsureSchema(ctx); err != nil {
+		t.Fatalf("EnsureSchema: %v", err)
+	}
+	return db
+}
+
+func TestPathFromURL(t *testing.T) {
+	cases := map[string]string{
+		"sqlite:kwgn.db":            "kwgn.db",
+		"sqlite://data/kwgn.db":     "data/kwgn.db",
+		"sqlite:///var/lib/kwgn.db": "/var/lib/kwgn.db",
+		"sqlite::memory:":           ":memory:",
+	}
+	for url, want := range cases {
+		got, err := PathFromURL(url)
+		if err != nil || got != want {
+			t.Errorf("PathFromURL(%q) = %q, %v; want %q", url, got, err, want)
+		}
+	}
+	for _, url := range []string{"sqlite:", "postgresql://localhost/db"} {
+		if _, err := PathFromURL(url); err == nil {
+			t.Errorf("PathFromURL(%q) should fail", url)
+		}
+	}
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "kwgn.db")
+
+	if _, err := OpenReadOnly(ctx, path); err == nil {
+		t.Fatal("OpenReadOnly should fail for a missing file")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("OpenReadOnly created %s", path)
+	}
+
+	db, err := Open(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.EnsureSchema(ctx); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	ro, err := OpenReadOnly(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ro.Close()
+	if current, latest, err := ro.SchemaVersion(ctx); err != nil || current != latest {
+		t.Errorf("SchemaVersion = %d/%d, %v", current, latest, err)
+	}
+	if _, err := ro.MigrateDown(ctx, 1); err == nil {
+		t.Error("Expected writes to a read-only database to fail")
+	}
+}
+
+func TestMigrateUpDown(t *testing.T) {
+	ctx := context.Background()
+	db := openTest(t)
+
+	current, latest, err := db.SchemaVersion(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current != latest || latest == 0 {
+		t.Fatalf("schema version = %d/%d, want latest", current, latest)
+	}
+
+	// Already current: nothing to apply
+	applied, err := db.MigrateUp(ctx, 0)
+	if err != nil || len(applied) != 0 {
+		t.Fatalf("MigrateUp on current schema = %v, %v", applied, err)
+	}
+
+	reverted, err := db.MigrateDown(ctx, latest)
+	if err != nil || len(reverted) != latest {
+		t.Fatalf("MigrateDown = %d reverted, %v", len(reverted), err)
+	}
+	for _, table := range []string{"accounts", "statements", "transactions", "import_files", "api_keys"} {
+		if exists, _ := db.TableExists(ctx, table); exists {
+			t.Errorf("table %s still exists after reverting all migrations", table)
+		}
+	}
+
+	applied, err = db.MigrateUp(ctx, 1)
+	if err != nil || len(applied) != 1 || applied[0].Version != 1 {
+		t.Fatalf("MigrateUp(1) = %v, %v", applied, err)
+	}
+	if current, _, _ := db.SchemaVersion(ctx); current != 1 {
+		t.Errorf("schema version after one step = %d, want 1", current)
+	}
+}
@@ -0,0 +1,65 @@
+-- Initial schema: accounts, statements and transactions.
+-- Ids are UUID strings generated by kwgn, dates are stored as YYYY-MM-DD text,
+-- amounts as decimal text (exact) and arrays/objects as JSON text.
+
+-- Accounts table
+CREATE TABLE IF NOT EXISTS accounts (
+    id TEXT PRIMARY KEY,
+    account_number TEXT NOT NULL UNIQUE,
+    account_name TEXT NOT NULL,
+    account_type TEXT DEFAULT NULL,
+    debit_credit TEXT DEFAULT '',
+    reconciliable INTEGER DEFAULT 0,
+    created_at TEXT DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+    updated_at TEXT DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+);
+
+-- Statements table with natural key (account_id, statement_date)
+CREATE TABLE IF NOT EXISTS statements (
+    id TEXT PRIMARY KEY,
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    source TEXT NOT NULL,
+    statement_date TEXT NOT NULL,
+    starting_balance TEXT,
+    ending_balance TEXT,
+    calculated_ending_balance TEXT,
+    total_credit TEXT NOT NULL,
+    total_debit TEXT NOT NULL,
+    nett TEXT NOT NULL,
+    transaction_start_date TEXT,
+    transaction_end_date TEXT,
+    created_at TEXT DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+
+    -- Natural key for deduplication
+    UNIQUE(account_id, statement_date)
+);
+
+-- Transactions table
+CREATE TABLE IF NOT EXISTS transactions (
+    id TEXT PRIMARY KEY,
+    statement_id TEXT NOT NULL REFERENCES statements(id) ON DELETE CASCADE,
+    sequence INTEGER NOT NULL,
+    date TEXT NOT NULL,
+    descriptions TEXT NOT NULL DEFAULT '[]',
+    description TEXT,
+    type TEXT NOT NULL,
+    amount TEXT NOT NULL,
+    balance TEXT NOT NULL,
+    reference TEXT DEFAULT '',
+    created_at TEXT DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+    tags TEXT DEFAULT '[]',
+    data TEXT DEFAULT '{}',
+
+    -- Prevent duplicate transactions within a statement
+    UNIQUE(statement_id, sequence)
+);
+
+-- Indexes for common queries
+CREATE INDEX IF NOT EXISTS idx_statements_account_id ON statements(account_id);
+CREATE INDEX IF NOT EXISTS idx_statements_date ON statements(statement_date);
+CREATE INDEX IF NOT EXISTS idx_transactions_statement_id ON transactions(statement_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_date ON transactions(date);
+
+-- Unique index for idempotent TNG CSV imports (prevents duplicate Transaction IDs per account)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_unique_reference
+ON transactions(statement_id, reference) WHERE reference != '';
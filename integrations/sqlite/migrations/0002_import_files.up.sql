@@ -0,0 +1,12 @@
+-- Ledger of imported files keyed by content hash (skips unchanged files on re-import)
+CREATE TABLE IF NOT EXISTS import_files (
+    sha256 TEXT PRIMARY KEY,
+    path TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    detected_type TEXT DEFAULT '',
+    extractor_version TEXT DEFAULT '',
+    statement_ids TEXT DEFAULT '[]',
+    outcome TEXT NOT NULL,
+    error TEXT DEFAULT '',
+    imported_at TEXT DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+);
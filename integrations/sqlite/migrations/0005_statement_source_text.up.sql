@@ -0,0 +1,2 @@
+-- statements.source is already TEXT in SQLite; kept so both backends share migration versions
+SELECT 1;
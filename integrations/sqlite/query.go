@@ -0,0 +1,263 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/integrations/store"
+	"github.com/shopspring/decimal"
+	sqlitelib "modernc.org/sqlite"
+)
+
+// patterns caches compiled REGEXP patterns, which are evaluated once per row
+var patterns sync.Map
+
+func init() {
+	// SQLite parses "x REGEXP y" as regexp(y, x) but leaves the function to the application.
+	// Matching is case-insensitive, like the PostgreSQL backend's ~* operator.
+	sqlitelib.MustRegisterDeterministicScalarFunction("regexp", 2, func(_ *sqlitelib.FunctionContext, args []driver.Value) (driver.Value, error) {
+		pattern, _ := args[0].(string)
+		value, ok := args[1].(string)
+		if !ok {
+			return false, nil // NULL never matches
+		}
+
+		re, ok := patterns.Load(pattern)
+		if !ok {
+			compiled, err := regexp.Compile("(?i)" + pattern)
+			if err != nil {
+				return nil, err
+			}
+			re, _ = patterns.LoadOrStore(pattern, compiled)
+		}
+		return re.(*regexp.Regexp).MatchString(value), nil
+	})
+}
+
+// sortColumns maps store sort fields to SQL expressions
+var sortColumns = map[string]string{
+	store.SortDate:        "t.date",
+	store.SortAmount:      "CAST(t.amount AS REAL)",
+	store.SortAccount:     "a.account_number",
+	store.SortType:        "t.type",
+	store.SortDescription: "t.description",
+}
+
+// QueryTransactions returns the transactions matching q, joined with their account and statement
+func (db *DB) QueryTransactions(ctx context.Context, q store.TransactionQuery) ([]store.TransactionRecord, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	var where []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+
+	if q.ID != "" {
+		add("t.id = ?%d", q.ID)
+	}
+	if q.Account != "" {
+		add("(a.account_number = ?%[1]d OR a.account_name LIKE '%%' || ?%[1]d || '%%')", q.Account)
+	}
+	if !q.From.IsZero() {
+		add("t.date >= ?%d", formatDate(q.From))
+	}
+	if !q.To.IsZero() {
+		add("t.date <= ?%d", formatDate(q.To))
+	}
+	if q.Type != "" {
+		add("t.type = ?%d", q.Type)
+	}
+	if q.MinAmount != nil {
+		add("CAST(t.amount AS REAL) >= CAST(?%d AS REAL)", q.MinAmount.String())
+	}
+	if q.MaxAmount != nil {
+		add("CAST(t.amount AS REAL) <= CAST(?%d AS REAL)", q.MaxAmount.String())
+	}
+	if q.Description != "" {
+		add("t.description REGEXP ?%d", q.Description)
+	}
+	for _, tag := range q.Tags {
+		add("EXISTS (SELECT 1 FROM json_each(t.tags) WHERE value = ?%d)", tag)
+	}
+	if q.StatementID != "" {
+		add("s.id = ?%d", q.StatementID)
+	}
+	if !q.StatementDate.IsZero() {
+		add("s.statement_date = ?%d", formatDate(q.StatementDate))
+	}
+
+	query := `
+		SELECT t.id, a.account_number, a.account_name, COALESCE(a.account_type, ''), COALESCE(a.debit_credit, ''),
+		       s.id, s.statement_date, t.sequence, t.date, t.descriptions, COALESCE(t.description, ''), t.type,
+		       t.amount, t.balance, COALESCE(t.reference, ''), COALESCE(t.tags, '[]'), COALESCE(t.data, '{}')
+		FROM transactions t
+		JOIN statements s ON s.id = t.statement_id
+		JOIN accounts a ON a.id = s.account_id
+	`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY " + orderBy(sortColumns, q) + limitOffset(q.Limit, q.Offset)
+
+	rows, err := db.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var records []store.TransactionRecord
+	for rows.Next() {
+		var r store.TransactionRecord
+		var statementDate, date, descriptions, amount, balance, tags, data string
+		if err := rows.Scan(
+			&r.ID, &r.AccountNumber, &r.AccountName, &r.AccountType, &r.DebitCredit, &r.StatementID, &statementDate,
+			&r.Sequence, &date, &descriptions, &r.Description, &r.Type,
+			&amount, &balance, &r.Reference, &tags, &data,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		if r.StatementDate, err = time.Parse(time.DateOnly, statementDate); err != nil {
+			return nil, fmt.Errorf("invalid statement date %q: %w", statementDate, err)
+		}
+		if r.Date, err = time.Parse(time.DateOnly, date); err != nil {
+			return nil, fmt.Errorf("invalid transaction date %q: %w", date, err)
+		}
+		if r.Amount, err = decimal.NewFromString(amount); err != nil {
+			return nil, fmt.Errorf("invalid transaction amount %q: %w", amount, err)
+		}
+		if r.Balance, err = decimal.NewFromString(balance); err != nil {
+			return nil, fmt.Errorf("invalid transaction balance %q: %w", balance, err)
+		}
+		for _, field := range []struct {
+			text string
+			dest any
+		}{{descriptions, &r.Descriptions}, {tags, &r.Tags}, {data, &r.Data}} {
+			if err := json.Unmarshal([]byte(field.text), field.dest); err != nil {
+				return nil, fmt.Errorf("invalid transaction json: %w", err)
+			}
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// limitOffset builds the LIMIT and OFFSET clauses; a zero limit means no limit
+func limitOffset(limit, offset int) string {
+	if limit == 0 && offset == 0 {
+		return ""
+	}
+	// SQLite only accepts OFFSET after a LIMIT; -1 means no limit
+	if limit == 0 {
+		limit = -1
+	}
+	return fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset)
+}
+
+// orderBy builds the ORDER BY clause for q, breaking ties by date and statement order
+func orderBy(columns map[string]string, q store.TransactionQuery) string {
+	dir := "ASC"
+	if q.Desc {
+		dir = "DESC"
+	}
+	sort := q.Sort
+	if sort == "" {
+		sort = store.SortDate
+	}
+	return fmt.Sprintf("%s %s, t.date %s, a.account_number, s.statement_date, t.sequence %s", columns[sort], dir, dir, dir)
+}
+
+// statementSelect selects statements with their account, to be completed with
+// WHERE and ORDER BY clauses on the aliases s and a
+const statementSelect = `
+	SELECT s.id, a.account_number, a.account_name, COALESCE(a.account_type, ''), COALESCE(a.debit_credit, ''),
+	       s.source, s.statement_date, COALESCE(s.starting_balance, '0'), COALESCE(s.ending_balance, '0'),
+	       COALESCE(s.calculated_ending_balance, '0'), s.total_credit, s.total_debit, s.nett,
+	       COALESCE(s.transaction_start_date, ''), COALESCE(s.transaction_end_date, ''),
+	       (SELECT COUNT(*) FROM transactions t WHERE t.statement_id = s.id)
+	FROM statements s
+	JOIN accounts a ON a.id = s.account_id
+`
+
+// ListStatements returns statements ordered by account and date; account optionally
+// matches an account number, or part of the account name
+func (db *DB) ListStatements(ctx context.Context, account string) ([]store.StatementRecord, error) {
+	return db.queryStatements(ctx, statementSelect+`
+		WHERE ?1 = '' OR a.account_number = ?1 OR a.account_name LIKE '%' || ?1 || '%'
+		ORDER BY a.account_number, s.statement_date
+	`, account)
+}
+
+// QueryStatements returns the statements of one account matching q
+func (db *DB) QueryStatements(ctx context.Context, q store.StatementQuery) ([]store.StatementRecord, error) {
+	where := []string{"a.id = ?1"}
+	args := []any{q.AccountID}
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if !q.From.IsZero() {
+		add("s.statement_date >= ?%d", formatDate(q.From))
+	}
+	if !q.To.IsZero() {
+		add("s.statement_date <= ?%d", formatDate(q.To))
+	}
+
+	dir := "ASC"
+	if q.Desc {
+		dir = "DESC"
+	}
+	query := statementSelect + " WHERE " + strings.Join(where, " AND ") +
+		" ORDER BY s.statement_date " + dir + limitOffset(q.Limit, q.Offset)
+	return db.queryStatements(ctx, query, args...)
+}
+
+// queryStatements runs a query built on statementSelect
+func (db *DB) queryStatements(ctx context.Context, query string, args ...any) ([]store.StatementRecord, error) {
+	rows, err := db.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query statements: %w", err)
+	}
+	defer rows.Close()
+
+	var records []store.StatementRecord
+	for rows.Next() {
+		var r store.StatementRecord
+		var statementDate, start, end string
+		var amounts [6]string
+		if err := rows.Scan(
+			&r.ID, &r.AccountNumber, &r.AccountName, &r.AccountType, &r.DebitCredit,
+			&r.Source, &statementDate, &amounts[0], &amounts[1], &amounts[2],
+			&amounts[3], &amounts[4], &amounts[5], &start, &end, &r.TransactionCount,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan statement: %w", err)
+		}
+		if r.StatementDate, err = time.Parse(time.DateOnly, statementDate); err != nil {
+			return nil, fmt.Errorf("invalid statement date %q: %w", statementDate, err)
+		}
+		// Transaction dates are optional; unparseable (empty) values stay zero
+		r.TransactionStartDate, _ = time.Parse(time.DateOnly, start)
+		r.TransactionEndDate, _ = time.Parse(time.DateOnly, end)
+
+		dest := []*decimal.Decimal{
+			&r.StartingBalance, &r.EndingBalance, &r.CalculatedEndingBalance,
+			&r.TotalCredit, &r.TotalDebit, &r.Nett,
+		}
+		for i, text := range amounts {
+			if *dest[i], err = decimal.NewFromString(text); err != nil {
+				return nil, fmt.Errorf("invalid statement amount %q: %w", text, err)
+			}
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
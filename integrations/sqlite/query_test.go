@@ -0,0 +1,253 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/extractor/common"
+	"github.com/aqlanhadi/kwgn/integrations/store"
+	"github.com/shopspring/decimal"
+)
+
+func TestQueryTransactions(t *testing.T) {
+	ctx := context.Background()
+	db := openTest(t)
+	id := createStatement(t, db)
+
+	grab, salary, toll := txn(1, "A", "12.50"), txn(2, "B", "5000.00"), txn(3, "", "2.10")
+	grab.Descriptions, grab.Tags = []string{"Grab  car"}, []string{"travel", "food"}
+	salary.Descriptions, salary.Type = []string{"SALARY"}, "credit"
+	toll.Descriptions, toll.Tags = []string{"Toll plaza"}, []string{"travel"}
+	if _, err := db.CreateTransactions(ctx, id, []common.Transaction{grab, salary, toll}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	min := decimal.RequireFromString("10")
+	cases := []struct {
+		name string
+		q    store.TransactionQuery
+		want []int // sequences, in order
+	}{
+		{"all", store.TransactionQuery{}, []int{1, 2, 3}},
+		{"account name", store.TransactionQuery{Account: "nope"}, nil},
+		{"account number", store.TransactionQuery{Account: "1234"}, []int{1, 2, 3}},
+		{"date range", store.TransactionQuery{From: day(2), To: day(3)}, []int{2, 3}},
+		{"type", store.TransactionQuery{Type: "debit"}, []int{1, 3}},
+		{"min amount", store.TransactionQuery{MinAmount: &min}, []int{1, 2}},
+		{"description regex", store.TransactionQuery{Description: "^grab car|toll"}, []int{1, 3}},
+		{"all tags", store.TransactionQuery{Tags: []string{"travel", "food"}}, []int{1}},
+		{"statement", store.TransactionQuery{StatementID: id, Tags: []string{"travel"}}, []int{1, 3}},
+		{"sort amount desc", store.TransactionQuery{Sort: store.SortAmount, Desc: true}, []int{2, 1, 3}},
+		{"offset without limit", store.TransactionQuery{Offset: 1}, []int{2, 3}},
+		{"limit and offset", store.TransactionQuery{Limit: 1, Offset: 1}, []int{2}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			records, err := db.QueryTransactions(ctx, tc.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []int
+			for _, r := range records {
+				got = append(got, r.Sequence)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("sequences = %v, want %v", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("sequences = %v, want %v", got, tc.want)
+				}
+			}
+		})
+	}
+
+	records, err := db.QueryTransactions(ctx, store.TransactionQuery{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := records[0]
+	if r.AccountNumber != "1234" || r.StatementDate.Year() != 2000 || r.Description != "GRAB CAR" ||
+		!r.Amount.Equal(grab.Amount) || len(r.Tags) != 2 || r.Data == nil {
+		t.Errorf("record = %+v", r)
+	}
+
+	if _, err := db.QueryTransactions(ctx, store.TransactionQuery{Sort: "balance"}); err == nil {
+		t.Error("expected invalid sort field to fail")
+	}
+}
+
+func day(d int) time.Time {
+	return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestListAccounts(t *testing.T) {
+	ctx := context.Background()
+	db := openTest(t)
+	createStatement(t, db)
+
+	accountID, err := db.GetOrCreateAccount(ctx, common.Account{AccountNumber: "1234"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := time.Date(2000, 2, 1, 0, 0, 0, 0, time.UTC)
+	latest, err := db.CreateStatement(ctx, accountID, common.Statement{StatementDate: &date, EndingBalance: decimal.RequireFromString("88.10")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.GetOrCreateAccount(ctx, common.Account{AccountNumber: "0001", AccountName: "empty"}); err != nil {
+		t.Fatal(err)
+	}
+
+	accounts, err := db.ListAccounts(ctx, store.AccountQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != 2 || accounts[0].AccountNumber != "0001" || accounts[1].AccountNumber != "1234" {
+		t.Fatalf("accounts = %+v", accounts)
+	}
+	if empty := accounts[0]; empty.StatementCount != 0 || empty.LatestStatementID != nil || empty.LatestBalance != nil {
+		t.Errorf("account without statements = %+v", empty)
+	}
+	a := accounts[1]
+	if a.ID != accountID || a.StatementCount != 2 || *a.LatestStatementID != latest ||
+		!a.LatestStatementDate.Equal(date) || a.LatestBalance.String() != "88.1" {
+		t.Errorf("account = %+v", a)
+	}
+
+	if got, err := db.GetAccount(ctx, accountID); err != nil || !reflect.DeepEqual(got, &a) {
+		t.Errorf("GetAccount = %+v, %v; want %+v", got, err, a)
+	}
+	if got, err := db.GetAccount(ctx, "missing"); err != nil || got != nil {
+		t.Errorf("GetAccount(missing) = %+v, %v; want nil", got, err)
+	}
+
+	cases := []struct {
+		name string
+		q    store.AccountQuery
+		want []string // account numbers, in order
+	}{
+		{"account name", store.AccountQuery{Account: "EMP"}, []string{"0001"}},
+		{"type", store.AccountQuery{Type: "tng_csv_export"}, []string{"1234"}},
+		{"limit", store.AccountQuery{Limit: 1}, []string{"0001"}},
+		{"offset", store.AccountQuery{Offset: 1}, []string{"1234"}},
+	}
+	for _, tc := range cases {
+		accounts, err := db.ListAccounts(ctx, tc.q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, a := range accounts {
+			got = append(got, a.AccountNumber)
+		}
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("%s: accounts = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestQueryStatements(t *testing.T) {
+	ctx := context.Background()
+	db := openTest(t)
+	createStatement(t, db)
+	other, err := db.GetOrCreateAccount(ctx, common.Account{AccountNumber: "12345"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	month := func(m int) *time.Time {
+		date := time.Date(2000, time.Month(m), 1, 0, 0, 0, 0, time.UTC)
+		return &date
+	}
+	if _, err := db.CreateStatement(ctx, other, common.Statement{StatementDate: month(1)}); err != nil {
+		t.Fatal(err)
+	}
+	account, err := db.FindAccount(ctx, "1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for m := 2; m <= 4; m++ {
+		if _, err := db.CreateStatement(ctx, account.ID, common.Statement{StatementDate: month(m)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		name string
+		q    store.StatementQuery
+		want []time.Month
+	}{
+		{"all", store.StatementQuery{}, []time.Month{1, 2, 3, 4}},
+		{"date range", store.StatementQuery{From: *month(2), To: *month(3)}, []time.Month{2, 3}},
+		{"desc", store.StatementQuery{Desc: true, Limit: 2}, []time.Month{4, 3}},
+		{"offset", store.StatementQuery{Offset: 3}, []time.Month{4}},
+	}
+	for _, tc := range cases {
+		tc.q.AccountID = account.ID
+		statements, err := db.QueryStatements(ctx, tc.q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []time.Month
+		for _, s := range statements {
+			if s.AccountNumber != "1234" {
+				t.Errorf("%s: statement of account %s", tc.name, s.AccountNumber)
+			}
+			got = append(got, s.StatementDate.Month())
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("%s: months = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestEdit(t *testing.T) {
+	ctx := context.Background()
+	db := openTest(t)
+	id := createStatement(t, db)
+	if _, err := db.CreateTransactions(ctx, id, []common.Transaction{txn(1, "A", "1.00")}, false); err != nil {
+		t.Fatal(err)
+	}
+	records, err := db.QueryTransactions(ctx, store.TransactionQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txID := records[0].ID
+
+	tags := []string{"food"}
+	if ok, err := db.EditTransaction(ctx, txID, store.TransactionEdit{Tags: &tags}); err != nil || !ok {
+		t.Fatalf("EditTransaction = %v, %v", ok, err)
+	}
+	if ok, err := db.EditTransaction(ctx, txID, store.TransactionEdit{Data: map[string]any{"category": "Dining"}}); err != nil || !ok {
+		t.Fatalf("EditTransaction = %v, %v", ok, err)
+	}
+	records, err = db.QueryTransactions(ctx, store.TransactionQuery{ID: txID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || len(records[0].Tags) != 1 || records[0].Tags[0] != "food" || records[0].Data["category"] != "Dining" {
+		t.Errorf("edited transaction = %+v", records)
+	}
+	if ok, _ := db.EditTransaction(ctx, "missing", store.TransactionEdit{Tags: &tags}); ok {
+		t.Error("expected editing a missing transaction to report false")
+	}
+
+	account, err := db.FindAccount(ctx, "1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	savings := "SAVINGS"
+	if ok, err := db.EditAccount(ctx, account.ID, store.AccountEdit{AccountType: &savings}); err != nil || !ok {
+		t.Fatalf("EditAccount = %v, %v", ok, err)
+	}
+	if ok, err := db.EditAccount(ctx, account.ID, store.AccountEdit{}); err != nil || !ok {
+		t.Fatalf("EditAccount = %v, %v", ok, err)
+	}
+	if account, _ = db.FindAccount(ctx, "1234"); account.AccountType != "SAVINGS" {
+		t.Errorf("account type = %q, want SAVINGS", account.AccountType)
+	}
+}
@@ -0,0 +1,105 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/extractor/common"
+)
+
+// formatDate stores dates as YYYY-MM-DD text, which sorts and compares correctly
+func formatDate(t time.Time) string {
+	return t.Format(time.DateOnly)
+}
+
+// nullableDate returns nil for zero dates so they are stored as NULL
+func nullableDate(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return formatDate(t)
+}
+
+// StatementExists checks if a statement exists for the given account and date
+func (db *DB) StatementExists(ctx context.Context, accountID string, statementDate time.Time) (bool, string, error) {
+	var id string
+	err := db.conn().QueryRowContext(ctx, `
+		SELECT id FROM statements
+		WHERE account_id = ? AND statement_date = ?
+	`, accountID, formatDate(statementDate)).Scan(&id)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, "", nil
+		}
+		return false, "", fmt.Errorf("failed to check statement: %w", err)
+	}
+
+	return true, id, nil
+}
+
+// CreateStatement inserts a new statement and returns its ID
+func (db *DB) CreateStatement(ctx context.Context, accountID string, stmt common.Statement) (string, error) {
+	if stmt.StatementDate == nil {
+		return "", fmt.Errorf("failed to create statement: no statement date")
+	}
+
+	id := newID()
+	_, err := db.conn().ExecContext(ctx, `
+		INSERT INTO statements (
+			id, account_id, source, statement_date,
+			starting_balance, ending_balance, calculated_ending_balance,
+			total_credit, total_debit, nett,
+			transaction_start_date, transaction_end_date
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		id, accountID, stmt.Source, formatDate(*stmt.StatementDate),
+		stmt.StartingBalance.String(), stmt.EndingBalance.String(), stmt.CalculatedEndingBalance.String(),
+		stmt.TotalCredit.String(), stmt.TotalDebit.String(), stmt.Nett.String(),
+		nullableDate(stmt.TransactionStartDate), nullableDate(stmt.TransactionEndDate),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to create statement: %w", err)
+	}
+
+	return id, nil
+}
+
+// UpdateStatement refreshes the extracted fields of an existing statement.
+// Returns false when nothing differed from what is stored.
+func (db *DB) UpdateStatement(ctx context.Context, statementID string, stmt common.Statement) (bool, error) {
+	res, err := db.conn().ExecContext(ctx, `
+		UPDATE statements
+		SET source = ?2,
+		    starting_balance = ?3, ending_balance = ?4, calculated_ending_balance = ?5,
+		    total_credit = ?6, total_debit = ?7, nett = ?8,
+		    transaction_start_date = ?9, transaction_end_date = ?10
+		WHERE id = ?1
+		  AND (source IS NOT ?2
+		       OR starting_balance IS NOT ?3 OR ending_balance IS NOT ?4 OR calculated_ending_balance IS NOT ?5
+		       OR total_credit IS NOT ?6 OR total_debit IS NOT ?7 OR nett IS NOT ?8
+		       OR transaction_start_date IS NOT ?9 OR transaction_end_date IS NOT ?10)
+	`,
+		statementID, stmt.Source,
+		stmt.StartingBalance.String(), stmt.EndingBalance.String(), stmt.CalculatedEndingBalance.String(),
+		stmt.TotalCredit.String(), stmt.TotalDebit.String(), stmt.Nett.String(),
+		nullableDate(stmt.TransactionStartDate), nullableDate(stmt.TransactionEndDate),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to update statement: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// DeleteStatement removes a statement and its transactions (via ON DELETE CASCADE)
+func (db *DB) DeleteStatement(ctx context.Context, statementID string) error {
+	_, err := db.conn().ExecContext(ctx, `DELETE FROM statements WHERE id = ?`, statementID)
+	if err != nil {
+		return fmt.Errorf("failed to delete statement: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/extractor/common"
+	"github.com/aqlanhadi/kwgn/integrations/store"
+	"github.com/shopspring/decimal"
+)
+
+// jsonText encodes v as JSON text, falling back to def if it cannot be encoded
+func jsonText(v any, def string) string {
+	b, err := json.Marshal(v)
+	if err != nil || string(b) == "null" {
+		return def
+	}
+	return string(b)
+}
+
+// CreateTransactions bulk inserts transactions for a statement with optional idempotent handling.
+// When idempotent is true, transactions whose non-empty reference is already stored are
+// skipped; a clash on sequence still fails. Returns the number of rows inserted.
+func (db *DB) CreateTransactions(ctx context.Context, statementID string, transactions []common.Transaction, idempotent bool) (int, error) {
+	if len(transactions) == 0 {
+		return 0, nil
+	}
+
+	query := `
+		INSERT INTO transactions (
+			id, statement_id, sequence, date, descriptions, description, type, amount, balance, reference, tags, data
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	if idempotent {
+		query += ` ON CONFLICT (statement_id, reference) WHERE reference != '' DO NOTHING`
+	}
+
+	inserted := 0
+	err := db.inTx(ctx, func(tx *DB) error {
+		stmt, err := tx.tx.PrepareContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare insert: %w", err)
+		}
+		defer stmt.Close()
+
+		for _, t := range transactions {
+			res, err := stmt.ExecContext(ctx,
+				newID(), statementID, t.Sequence, formatDate(t.Date),
+				jsonText(t.Descriptions, "[]"), store.NormalizeDescription(t.Descriptions),
+				t.Type, t.Amount.String(), t.Balance.String(), t.Reference,
+				jsonText(t.Tags, "[]"), jsonText(t.Data, "{}"),
+			)
+			if err != nil {
+				return fmt.Errorf("failed to insert transaction: %w", err)
+			}
+			n, _ := res.RowsAffected()
+			inserted += int(n)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return inserted, nil
+}
+
+// TransactionKeys returns the sequences and non-empty references already stored for a statement
+func (db *DB) TransactionKeys(ctx context.Context, statementID string) (sequences map[int]bool, references map[string]bool, err error) {
+	rows, err := db.conn().QueryContext(ctx, `
+		SELECT sequence, COALESCE(reference, '') FROM transactions WHERE statement_id = ?
+	`, statementID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query transactions: %w", err)
+	}
+	defer rows.Close()
+
+	sequences = make(map[int]bool)
+	references = make(map[string]bool)
+	for rows.Next() {
+		var seq int
+		var ref string
+		if err := rows.Scan(&seq, &ref); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		sequences[seq] = true
+		if ref != "" {
+			references[ref] = true
+		}
+	}
+	return sequences, references, rows.Err()
+}
+
+// StoredTransactions loads the extracted columns of a statement's transactions
+func (db *DB) StoredTransactions(ctx context.Context, statementID string) ([]store.StoredTransaction, error) {
+	rows, err := db.conn().QueryContext(ctx, `
+		SELECT id, sequence, date, descriptions, type, amount, balance, COALESCE(reference, '')
+		FROM transactions WHERE statement_id = ?
+		ORDER BY sequence
+	`, statementID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var stored []store.StoredTransaction
+	for rows.Next() {
+		var s store.StoredTransaction
+		var date, descriptions, amount, balance string
+		if err := rows.Scan(&s.ID, &s.Sequence, &date, &descriptions, &s.Type, &amount, &balance, &s.Reference); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		if s.Date, err = time.Parse(time.DateOnly, date); err != nil {
+			return nil, fmt.Errorf("invalid transaction date %q: %w", date, err)
+		}
+		if err := json.Unmarshal([]byte(descriptions), &s.Descriptions); err != nil {
+			return nil, fmt.Errorf("invalid transaction descriptions: %w", err)
+		}
+		if s.Amount, err = decimal.NewFromString(amount); err != nil {
+			return nil, fmt.Errorf("invalid transaction amount %q: %w", amount, err)
+		}
+		if s.Balance, err = decimal.NewFromString(balance); err != nil {
+			return nil, fmt.Errorf("invalid transaction balance %q: %w", balance, err)
+		}
+		stored = append(stored, s)
+	}
+	return stored, rows.Err()
+}
+
+// UpdateTransactions rewrites the extracted fields of existing transactions of a
+// statement; tags and data are left untouched
+func (db *DB) UpdateTransactions(ctx context.Context, statementID string, updates []store.TransactionUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	return db.inTx(ctx, func(tx *DB) error {
+		// Park the updated rows on negative sequences so renumbering cannot
+		// collide with the (statement_id, sequence) unique constraint midway
+		for _, u := range updates {
+			if _, err := tx.conn().ExecContext(ctx, `
+				UPDATE transactions SET sequence = -1 - sequence WHERE statement_id = ? AND id = ?
+			`, statementID, u.ID); err != nil {
+				return fmt.Errorf("failed to renumber transactions: %w", err)
+			}
+		}
+
+		for _, u := range updates {
+			t := u.Transaction
+			_, err := tx.conn().ExecContext(ctx, `
+				UPDATE transactions
+				SET sequence = ?, date = ?, descriptions = ?, description = ?,
+				    type = ?, amount = ?, balance = ?, reference = ?
+				WHERE id = ?
+			`, t.Sequence, formatDate(t.Date), jsonText(t.Descriptions, "[]"), store.NormalizeDescription(t.Descriptions),
+				t.Type, t.Amount.String(), t.Balance.String(), t.Reference, u.ID)
+			if err != nil {
+				return fmt.Errorf("failed to update transaction: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteTransactions removes transactions by id
+func (db *DB) DeleteTransactions(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return db.inTx(ctx, func(tx *DB) error {
+		for _, id := range ids {
+			if _, err := tx.conn().ExecContext(ctx, `DELETE FROM transactions WHERE id = ?`, id); err != nil {
+				return fmt.Errorf("failed to delete transactions: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// EditTransaction applies edit to the tags and data of a transaction; false if there is none
+func (db *DB) EditTransaction(ctx context.Context, id string, edit store.TransactionEdit) (bool, error) {
+	var tags, data any // NULL keeps the current value
+	if edit.Tags != nil {
+		tags = jsonText(*edit.Tags, "[]")
+	}
+	if edit.Data != nil {
+		data = jsonText(edit.Data, "{}")
+	}
+	res, err := db.conn().ExecContext(ctx, `
+		UPDATE transactions SET tags = COALESCE(?1, tags), data = COALESCE(?2, data) WHERE id = ?3
+	`, tags, data, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to edit transaction: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
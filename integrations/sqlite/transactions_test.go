@@ -0,0 +1,175 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/extractor/common"
+	"github.com/aqlanhadi/kwgn/integrations/store"
+	"github.com/shopspring/decimal"
+)
+
+// createStatement stores an account and an empty statement, returning the statement id
+func createStatement(t *testing.T, db *DB) string {
+	t.Helper()
+	ctx := context.Background()
+	accountID, err := db.GetOrCreateAccount(ctx, common.Account{AccountNumber: "1234", AccountType: "TNG_CSV_EXPORT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	id, err := db.CreateStatement(ctx, accountID, common.Statement{StatementDate: &date})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func txn(seq int, ref string, amount string) common.Transaction {
+	return common.Transaction{
+		Sequence:     seq,
+		Date:         day(seq),
+		Descriptions: []string{"TOLL", "  PLAZA  " + ref},
+		Type:         "debit",
+		Amount:       decimal.RequireFromString(amount),
+		Reference:    ref,
+	}
+}
+
+func TestCreateTransactions_Idempotent(t *testing.T) {
+	ctx := context.Background()
+	db := openTest(t)
+	id := createStatement(t, db)
+
+	n, err := db.CreateTransactions(ctx, id, []common.Transaction{txn(1, "A", "1.00"), txn(2, "", "2.00")}, true)
+	if err != nil || n != 2 {
+		t.Fatalf("first insert = %d, %v; want 2", n, err)
+	}
+
+	// Reference A clashes and is skipped; only sequence 3 is new
+	n, err = db.CreateTransactions(ctx, id, []common.Transaction{txn(4, "A", "1.00"), txn(3, "B", "3.00")}, true)
+	if err != nil || n != 1 {
+		t.Fatalf("second insert = %d, %v; want 1", n, err)
+	}
+
+	// A sequence clash is an error even in idempotent mode, not a skipped duplicate
+	if _, err := db.CreateTransactions(ctx, id, []common.Transaction{txn(2, "", "2.00")}, true); err == nil {
+		t.Fatal("expected duplicate sequence to fail")
+	}
+
+	// Without idempotent handling a clash fails and nothing is inserted
+	if _, err := db.CreateTransactions(ctx, id, []common.Transaction{txn(5, "C", "5.00"), txn(1, "", "1.00")}, false); err == nil {
+		t.Fatal("expected duplicate sequence to fail")
+	}
+
+	sequences, references, err := db.TransactionKeys(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sequences) != 3 || !sequences[3] || sequences[5] {
+		t.Errorf("sequences = %v", sequences)
+	}
+	if len(references) != 2 || !references["A"] || !references["B"] {
+		t.Errorf("references = %v", references)
+	}
+}
+
+func TestUpdateTransactions_KeepsUserFields(t *testing.T) {
+	ctx := context.Background()
+	db := openTest(t)
+	id := createStatement(t, db)
+
+	first, second := txn(1, "A", "1.00"), txn(2, "B", "2.00")
+	first.Tags = []string{"travel"}
+	if _, err := db.CreateTransactions(ctx, id, []common.Transaction{first, second}, false); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := db.StoredTransactions(ctx, id)
+	if err != nil || len(stored) != 2 {
+		t.Fatalf("StoredTransactions = %v, %v", stored, err)
+	}
+	if stored[0].Date != first.Date || !stored[0].Amount.Equal(first.Amount) || stored[0].Descriptions[1] != "  PLAZA  A" {
+		t.Errorf("stored transaction did not round-trip: %+v", stored[0])
+	}
+
+	// Swap the sequences and fix the first amount
+	first.Sequence, second.Sequence = 2, 1
+	first.Amount = decimal.RequireFromString("1.50")
+	first.Tags = nil
+	err = db.UpdateTransactions(ctx, id, []store.TransactionUpdate{
+		{ID: stored[0].ID, Transaction: first},
+		{ID: stored[1].ID, Transaction: second},
+	})
+	if err != nil {
+		t.Fatalf("UpdateTransactions: %v", err)
+	}
+
+	var seq int
+	var amount, description, tags string
+	err = db.sql.QueryRowContext(ctx, `SELECT sequence, amount, description, tags FROM transactions WHERE id = ?`, stored[0].ID).
+		Scan(&seq, &amount, &description, &tags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seq != 2 || amount != "1.5" || description != "TOLL PLAZA A" || tags != `["travel"]` {
+		t.Errorf("updated row = %d %s %q %s", seq, amount, description, tags)
+	}
+
+	if err := db.DeleteTransactions(ctx, []string{stored[1].ID}); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := db.StoredTransactions(ctx, id); len(stored) != 1 {
+		t.Errorf("%d transactions left after delete, want 1", len(stored))
+	}
+}
+
+func TestFileRecords(t *testing.T) {
+	ctx := context.Background()
+	db := openTest(t)
+
+	rec := store.FileRecord{SHA256: "abc", Path: "/in/a.pdf", Size: 10, ExtractorVersion: "v1", Outcome: store.OutcomeFailed, Error: "boom"}
+	if err := db.RecordFile(ctx, rec); err != nil {
+		t.Fatal(err)
+	}
+	rec.Outcome, rec.Error, rec.StatementIDs = store.OutcomeImported, "", []string{"s1"}
+	if err := db.RecordFile(ctx, rec); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.RecordFile(ctx, store.FileRecord{SHA256: "def", Path: "/other/b.pdf", Outcome: store.OutcomeSkipped}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := db.GetFileRecord(ctx, "abc")
+	if err != nil || got == nil {
+		t.Fatalf("GetFileRecord = %v, %v", got, err)
+	}
+	if !got.IsSettled("v1") || got.IsSettled("v2") || len(got.StatementIDs) != 1 || got.StatementIDs[0] != "s1" || got.ImportedAt.IsZero() {
+		t.Errorf("record = %+v", got)
+	}
+	if missing, err := db.GetFileRecord(ctx, "nope"); missing != nil || err != nil {
+		t.Errorf("missing record = %v, %v", missing, err)
+	}
+
+	records, err := db.ListFileRecords(ctx, "/in")
+	if err != nil || len(records) != 1 || records[0].Path != "/in/a.pdf" {
+		t.Errorf("ListFileRecords(/in) = %v, %v", records, err)
+	}
+
+	if err := db.RecordDuplicate(ctx, "/in/copy.pdf", "abc"); err != nil {
+		t.Fatal(err)
+	}
+	records, err = db.ListFileRecords(ctx, "/in/copy")
+	if err != nil || len(records) != 1 || records[0].Outcome != store.OutcomeDuplicate || records[0].DuplicateOf != "/in/a.pdf" {
+		t.Errorf("ListFileRecords(/in/copy) = %+v, %v", records, err)
+	}
+
+	// Importing a file of its own at that path replaces the duplicate
+	if err := db.RecordFile(ctx, store.FileRecord{SHA256: "ghi", Path: "/in/copy.pdf", Outcome: store.OutcomeImported}); err != nil {
+		t.Fatal(err)
+	}
+	records, err = db.ListFileRecords(ctx, "/in/copy")
+	if err != nil || len(records) != 1 || records[0].SHA256 != "ghi" || records[0].DuplicateOf != "" {
+		t.Errorf("ListFileRecords(/in/copy) after import = %+v, %v", records, err)
+	}
+}
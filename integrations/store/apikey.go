@@ -0,0 +1,92 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// API key scopes
+const (
+	ScopeExtract = "extract" // Extract uploaded statements
+	ScopeImport  = "import"  // Write imported statements to the database
+	ScopeRead    = "read"    // Read imported data (accounts, transactions, reports, net worth)
+	ScopeWrite   = "write"   // Edit stored accounts and transactions
+)
+
+// Scopes lists every API key scope
+var Scopes = []string{ScopeExtract, ScopeImport, ScopeRead, ScopeWrite}
+
+// apiKeyTokenPrefix marks kwgn API keys, so leaked keys are easy to recognise
+const apiKeyTokenPrefix = "kwgn_"
+
+// APIKey is an API key. Only the SHA-256 of the key is stored; the key itself
+// is shown once, when it is created.
+type APIKey struct {
+	ID        string
+	Name      string
+	Prefix    string // Start of the key, shown to tell keys apart
+	Hash      string
+	Scopes    []string
+	RateLimit int // Requests per minute, 0 = unlimited
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+// HasScope reports whether the key grants scope
+func (k APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+// Revoked reports whether the key can no longer be used
+func (k APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// NewAPIKey generates a key and returns it with its record, ready to be stored
+func NewAPIKey(name string, scopes []string, rateLimit int) (string, APIKey, error) {
+	if strings.TrimSpace(name) == "" {
+		return "", APIKey{}, fmt.Errorf("API key name is required")
+	}
+	if err := ValidateScopes(scopes); err != nil {
+		return "", APIKey{}, err
+	}
+	if rateLimit < 0 {
+		return "", APIKey{}, fmt.Errorf("rate limit must not be negative, got %d", rateLimit)
+	}
+
+	var b [20]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", APIKey{}, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	token := apiKeyTokenPrefix + strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b[:]))
+
+	return token, APIKey{
+		Name:      name,
+		Prefix:    token[:len(apiKeyTokenPrefix)+8],
+		Hash:      HashContent([]byte(token)),
+		Scopes:    scopes,
+		RateLimit: rateLimit,
+	}, nil
+}
+
+// HashAPIKey returns the hash an API key is stored and looked up by
+func HashAPIKey(token string) string {
+	return HashContent([]byte(token))
+}
+
+// ValidateScopes checks that scopes is a non-empty list of known scopes
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope is required (%s)", strings.Join(Scopes, ", "))
+	}
+	for _, s := range scopes {
+		if !slices.Contains(Scopes, s) {
+			return fmt.Errorf("unknown scope '%s' (valid: %s)", s, strings.Join(Scopes, ", "))
+		}
+	}
+	return nil
+}
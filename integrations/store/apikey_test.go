@@ -0,0 +1,35 @@
+package store
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewAPIKey(t *testing.T) {
+	token, key, err := NewAPIKey("ci", []string{ScopeExtract}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(token, "kwgn_") || !strings.HasPrefix(token, key.Prefix) || len(key.Prefix) != 13 {
+		t.Errorf("Unexpected token %s with prefix %s", token, key.Prefix)
+	}
+	if key.Hash != HashAPIKey(token) || strings.Contains(key.Hash, token) {
+		t.Errorf("Expected the key to hold the token's hash only")
+	}
+
+	other, _, _ := NewAPIKey("ci", []string{ScopeExtract}, 10)
+	if other == token {
+		t.Error("Expected every key to be different")
+	}
+
+	for name, call := range map[string]func() error{
+		"no name":        func() error { _, _, err := NewAPIKey(" ", []string{ScopeRead}, 0); return err },
+		"no scopes":      func() error { _, _, err := NewAPIKey("x", nil, 0); return err },
+		"unknown scope":  func() error { _, _, err := NewAPIKey("x", []string{"admin"}, 0); return err },
+		"negative limit": func() error { _, _, err := NewAPIKey("x", []string{ScopeRead}, -1); return err },
+	} {
+		if call() == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package store
+
+import (
+	"context"
+
+	extractor_common "github.com/aqlanhadi/kwgn/extractor/common"
+)
+
+// ImportStatement exposes importStatement to the tests in package store_test,
+// which use a real backend. It returns the inserted and skipped transaction counts.
+func (im *Importer) ImportStatement(ctx context.Context, statement extractor_common.Statement, opts ImportOptions) (int, int, error) {
+	st, err := im.importStatement(ctx, "test", statement, opts)
+	return st.txInserted, st.txSkipped, err
+}
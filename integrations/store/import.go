@@ -0,0 +1,481 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/extractor"
+	extractor_common "github.com/aqlanhadi/kwgn/extractor/common"
+)
+
+// Importer imports extracted statements into a Store.
+// It is safe for concurrent use, so one Importer can be shared by parallel imports.
+type Importer struct {
+	store        Store
+	accountLocks sync.Map // account number -> *sync.Mutex
+}
+
+// NewImporter creates an importer writing to s
+func NewImporter(s Store) *Importer {
+	return &Importer{store: s}
+}
+
+// lockAccount acquires a per-account mutex and returns its unlock function.
+// Different accounts proceed in parallel; the same account is serialized.
+func (im *Importer) lockAccount(accountNumber string) func() {
+	mu, _ := im.accountLocks.LoadOrStore(accountNumber, &sync.Mutex{})
+	m := mu.(*sync.Mutex)
+	m.Lock()
+	return m.Unlock
+}
+
+// ImportResult tracks the outcome of an import operation
+type ImportResult struct {
+	Processed int
+	Skipped   int
+	Failed    int
+	Errors    []string
+
+	TransactionsInserted int // Transaction rows written
+	TransactionsSkipped  int // Transaction rows already stored (duplicates or skipped statements)
+
+	Merges []MergeReport // Changes made to existing statements with ImportOptions.Merge
+}
+
+// add accumulates the outcome of a single file
+func (r *ImportResult) add(o fileOutcome) {
+	r.Processed += o.processed
+	r.Skipped += o.skipped
+	r.Failed += o.failed
+	r.Errors = append(r.Errors, o.errors...)
+	r.TransactionsInserted += o.txInserted
+	r.TransactionsSkipped += o.txSkipped
+	r.Merges = append(r.Merges, o.merges...)
+}
+
+// fileOutcome is the result of importing one file
+type fileOutcome struct {
+	processed, skipped, failed int
+	errors                     []string
+	txInserted, txSkipped      int
+	merges                     []MergeReport
+}
+
+// statementOutcome is the result of importing one statement
+type statementOutcome struct {
+	id         string
+	skipped    bool // Statement already existed and was left untouched
+	txInserted int
+	txSkipped  int
+	merge      *MergeReport // Set when an existing statement was merged
+}
+
+// ImportOptions configures the import behavior
+type ImportOptions struct {
+	Force         bool                  // Force reprocessing of existing statements
+	Merge         bool                  // Merge into existing statements, keeping user edits (takes precedence over Force)
+	StatementType string                // Override auto-detection
+	Verbose       bool                  // Enable verbose logging
+	Jobs          int                   // Number of files imported concurrently (<1 = one per CPU)
+	Scan          extractor.ScanOptions // Directory scanning (recursion, include/exclude patterns)
+
+	// OnEvent, when set, is called for every statement stored, skipped or failed, and
+	// for files that fail before any statement is read. It may be called concurrently.
+	OnEvent func(ImportEvent)
+}
+
+// ImportEvent describes what happened to one statement during an import, or to a
+// file that could not be imported at all
+type ImportEvent struct {
+	File                 string
+	Outcome              string                      // OutcomeImported, OutcomeSkipped or OutcomeFailed
+	Statement            *extractor_common.Statement // Nil when the file itself failed
+	StatementID          string                      // Set once the statement is stored
+	Merged               bool                        // An existing statement was updated with ImportOptions.Merge
+	TransactionsInserted int
+	Error                string
+}
+
+// notify passes ev to opts.OnEvent, if set
+func (opts ImportOptions) notify(ev ImportEvent) {
+	if opts.OnEvent != nil {
+		opts.OnEvent(ev)
+	}
+}
+
+// sentinelDate is used for TNG CSV imports to ensure all transactions go to the same statement
+// This enables proper deduplication via the unique index on (statement_id, reference)
+var sentinelDate = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// isTNGCSVAccount checks if an account is a TNG CSV export account (not reconciliable)
+func isTNGCSVAccount(account extractor_common.Account) bool {
+	return account.AccountType == "TNG_CSV_EXPORT" && !account.Reconciliable
+}
+
+// newTransactions returns the transactions whose reference is not stored yet,
+// numbered after the highest stored sequence. TNG CSV rows are numbered per file,
+// so rows from a later export would otherwise clash on sequence with the ones
+// already stored in the shared statement.
+func newTransactions(transactions []extractor_common.Transaction, sequences map[int]bool, references map[string]bool) []extractor_common.Transaction {
+	last := 0
+	for seq := range sequences {
+		last = max(last, seq)
+	}
+	var fresh []extractor_common.Transaction
+	for _, tx := range transactions {
+		if tx.Reference != "" && references[tx.Reference] {
+			continue
+		}
+		last++
+		tx.Sequence = last
+		fresh = append(fresh, tx)
+	}
+	return fresh
+}
+
+// ImportFile processes a single PDF/CSV file and stores it in the database
+// Returns: processed count, skipped count, failed count, error messages
+func (im *Importer) ImportFile(ctx context.Context, filePath string, opts ImportOptions) (processed int, skipped int, failed int, errors []string) {
+	return im.ImportInput(ctx, extractor.Input{Path: filePath}, opts)
+}
+
+// ImportInput processes a single PDF/CSV file, either on disk or inside a zip archive
+// Returns: processed count, skipped count, failed count, error messages
+//
+// Files are hashed first; a file whose content was already fully imported (under
+// any name) is skipped without extraction unless opts.Force or opts.Merge is set.
+func (im *Importer) ImportInput(ctx context.Context, in extractor.Input, opts ImportOptions) (processed int, skipped int, failed int, errors []string) {
+	o := im.importInput(ctx, in, opts)
+	return o.processed, o.skipped, o.failed, o.errors
+}
+
+// importInput implements ImportInput, also counting transaction rows
+func (im *Importer) importInput(ctx context.Context, in extractor.Input, opts ImportOptions) (o fileOutcome) {
+	fileName := in.Name()
+
+	// Read the whole file so it can be hashed before extraction
+	data, err := in.ReadAll()
+	if err != nil {
+		msg := fmt.Sprintf("%s: failed to open file: %v", fileName, err)
+		opts.notify(ImportEvent{File: fileName, Outcome: OutcomeFailed, Error: msg})
+		return fileOutcome{failed: 1, errors: []string{msg}}
+	}
+
+	rec := FileRecord{
+		SHA256:           HashContent(data),
+		Path:             ledgerPath(in),
+		Size:             int64(len(data)),
+		ExtractorVersion: extractor.Version,
+	}
+
+	if !opts.Force && !opts.Merge {
+		prev, err := im.store.GetFileRecord(ctx, rec.SHA256)
+		if err != nil {
+			log.Printf("WARN %s: %v", fileName, err)
+		} else if prev != nil && prev.IsSettled(extractor.Version) {
+			if prev.Path != rec.Path {
+				// Listed in the ledger so the copy's fate is visible too
+				if err := im.store.RecordDuplicate(ctx, rec.Path, rec.SHA256); err != nil {
+					log.Printf("WARN %s: %v", fileName, err)
+				}
+				if opts.Verbose {
+					log.Printf("SKIP %s (duplicate of %s)", fileName, prev.Path)
+				}
+			} else if opts.Verbose {
+				log.Printf("SKIP %s (unchanged since %s)", fileName, prev.ImportedAt.Format(time.RFC3339))
+			}
+			return fileOutcome{skipped: 1}
+		}
+	}
+
+	// Extract statements from PDF/CSV (may return multiple for CC with multiple cards or CSV with multiple MFG numbers)
+	statements, detectedType := extractor.ProcessReaderDetect(bytes.NewReader(data), in.Location(), opts.StatementType)
+	if in.Member != "" {
+		for i := range statements {
+			statements[i].Source = in.Source()
+		}
+	}
+	rec.DetectedType = detectedType
+
+	if len(statements) == 0 {
+		msg := fmt.Sprintf("%s: no statements extracted", fileName)
+		opts.notify(ImportEvent{File: fileName, Outcome: OutcomeFailed, Error: msg})
+		o = fileOutcome{failed: 1, errors: []string{msg}}
+		im.recordOutcome(ctx, rec, o)
+		return o
+	}
+
+	// Process each statement
+	for _, statement := range statements {
+		fail := func(msg string) {
+			o.failed++
+			o.errors = append(o.errors, msg)
+			opts.notify(ImportEvent{File: fileName, Outcome: OutcomeFailed, Statement: &statement, Error: msg})
+		}
+
+		// Validate extraction
+		if statement.Account.AccountNumber == "" {
+			fail(fmt.Sprintf("%s: no account number extracted", fileName))
+			continue
+		}
+		if statement.StatementDate == nil {
+			fail(fmt.Sprintf("%s [%s]: no statement date extracted", fileName, statement.Account.AccountNumber))
+			continue
+		}
+
+		st, err := im.importStatement(ctx, fileName, statement, opts)
+		if err != nil {
+			fail(fmt.Sprintf("%s [%s]: %v", fileName, statement.Account.AccountNumber, err))
+			continue
+		}
+		ev := ImportEvent{File: fileName, Outcome: OutcomeImported, Statement: &statement, StatementID: st.id,
+			Merged: st.merge != nil, TransactionsInserted: st.txInserted}
+		if st.skipped {
+			ev.Outcome = OutcomeSkipped
+		}
+		opts.notify(ev)
+
+		rec.StatementIDs = append(rec.StatementIDs, st.id)
+		o.txInserted += st.txInserted
+		o.txSkipped += st.txSkipped
+		if st.merge != nil {
+			st.merge.File = fileName
+			o.merges = append(o.merges, *st.merge)
+		}
+		if st.skipped {
+			o.skipped++
+			continue
+		}
+		o.processed++
+	}
+
+	im.recordOutcome(ctx, rec, o)
+	return o
+}
+
+// ledgerPath returns the absolute location of an input for the import_files ledger.
+// Inputs held in memory have no location and are recorded as upload:<name>.
+func ledgerPath(in extractor.Input) string {
+	if in.Data != nil {
+		return "upload:" + in.Location()
+	}
+	abs, err := filepath.Abs(in.Path)
+	if err != nil {
+		abs = in.Path
+	}
+	if in.Member == "" {
+		return abs
+	}
+	return abs + "/" + in.Member
+}
+
+// recordOutcome stores the result of importing a file in the ledger.
+// Ledger failures are logged but never fail the import itself.
+func (im *Importer) recordOutcome(ctx context.Context, rec FileRecord, o fileOutcome) {
+	switch {
+	case o.failed == 0 && o.processed == 0:
+		rec.Outcome = OutcomeSkipped
+	case o.failed == 0:
+		rec.Outcome = OutcomeImported
+	case o.processed > 0 || o.skipped > 0:
+		rec.Outcome = OutcomePartial
+	default:
+		rec.Outcome = OutcomeFailed
+	}
+	rec.Error = strings.Join(o.errors, "\n")
+
+	if err := im.store.RecordFile(ctx, rec); err != nil {
+		log.Printf("WARN %s: %v", filepath.Base(rec.Path), err)
+	}
+}
+
+// importStatement stores a single validated statement and reports its id and
+// how many transaction rows were inserted or already present. The outcome is
+// marked skipped when the statement already exists and was left untouched.
+//
+// The account upsert, forced replacement and transaction inserts all run in one
+// database transaction, so a failure or timeout never leaves a statement half-imported
+// or an existing statement deleted without its replacement.
+func (im *Importer) importStatement(ctx context.Context, fileName string, statement extractor_common.Statement, opts ImportOptions) (st statementOutcome, err error) {
+	// Check if this is a TNG CSV account (requires special idempotent handling)
+	isTNGCSV := isTNGCSVAccount(statement.Account)
+
+	// For TNG CSV accounts, use a sentinel date to ensure all transactions go to the same statement
+	// This enables proper deduplication across multiple CSV imports
+	effectiveStatementDate := *statement.StatementDate
+	if isTNGCSV {
+		effectiveStatementDate = sentinelDate
+	}
+
+	// Serialize writes per account so concurrent imports of the same
+	// account cannot race between the existence checks and the inserts
+	unlock := im.lockAccount(statement.Account.AccountNumber)
+	defer unlock()
+
+	err = im.store.InTx(ctx, func(tx Store) error {
+		// Get or create account
+		accountID, err := tx.GetOrCreateAccount(ctx, statement.Account)
+		if err != nil {
+			return fmt.Errorf("account error: %w", err)
+		}
+
+		// Check if statement exists (natural key: account_id + statement_date)
+		exists, existingID, err := tx.StatementExists(ctx, accountID, effectiveStatementDate)
+		if err != nil {
+			return fmt.Errorf("check error: %w", err)
+		}
+
+		if isTNGCSV {
+			// For TNG CSV: reuse existing statement or create new one
+			// Transactions are inserted idempotently (duplicates skipped by reference)
+			if exists {
+				st.id = existingID
+			} else {
+				// Create statement with sentinel date
+				stmtCopy := statement
+				stmtCopy.StatementDate = &effectiveStatementDate
+				st.id, err = tx.CreateStatement(ctx, accountID, stmtCopy)
+				if err != nil {
+					return fmt.Errorf("statement error: %w", err)
+				}
+			}
+
+			// Insert transactions idempotently (duplicates by reference are skipped)
+			sequences, references, err := tx.TransactionKeys(ctx, st.id)
+			if err != nil {
+				return fmt.Errorf("transactions error: %w", err)
+			}
+			inserted, err := tx.CreateTransactions(ctx, st.id, newTransactions(statement.Transactions, sequences, references), true)
+			if err != nil {
+				return fmt.Errorf("transactions error: %w", err)
+			}
+			st.txInserted = inserted
+			st.txSkipped = len(statement.Transactions) - inserted
+			return nil
+		}
+
+		// Standard flow for non-TNG accounts
+		if exists && opts.Merge {
+			report, err := mergeStatement(ctx, tx, existingID, statement)
+			if err != nil {
+				return fmt.Errorf("merge error: %w", err)
+			}
+			st.id = existingID
+			st.merge = report
+			st.txInserted = report.Count(ChangeInsert)
+			st.txSkipped = len(statement.Transactions) - st.txInserted
+			return nil
+		}
+
+		if exists && !opts.Force {
+			st.id = existingID
+			st.skipped = true
+			st.txSkipped = len(statement.Transactions)
+			return nil
+		}
+
+		// If forcing, delete existing statement first (only takes effect if the replacement commits)
+		if exists && opts.Force {
+			if err := tx.DeleteStatement(ctx, existingID); err != nil {
+				return fmt.Errorf("delete error: %w", err)
+			}
+		}
+
+		// Create statement
+		st.id, err = tx.CreateStatement(ctx, accountID, statement)
+		if err != nil {
+			return fmt.Errorf("statement error: %w", err)
+		}
+
+		// Create transactions
+		if _, err := tx.CreateTransactions(ctx, st.id, statement.Transactions, false); err != nil {
+			return fmt.Errorf("transactions error: %w", err)
+		}
+		st.txInserted = len(statement.Transactions)
+		return nil
+	})
+	if err != nil {
+		return statementOutcome{}, err
+	}
+
+	if opts.Verbose {
+		switch {
+		case st.skipped:
+			log.Printf("SKIP %s [%s] (already exists)", fileName, statement.Account.AccountNumber)
+		case st.merge != nil:
+			log.Printf("MERGE %s [%s] (%d updated, %d inserted, %d deleted, %d unchanged, %d conflicting)", fileName, statement.Account.AccountNumber,
+				st.merge.Count(ChangeUpdate), st.merge.Count(ChangeInsert), st.merge.Count(ChangeDelete), st.merge.Unchanged,
+				st.merge.Count(ChangeConflict))
+		case isTNGCSV:
+			log.Printf("OK   %s [%s] (%d transactions, %d new, idempotent)", fileName, statement.Account.AccountNumber, len(statement.Transactions), st.txInserted)
+		default:
+			log.Printf("OK   %s [%s] (%d transactions)", fileName, statement.Account.AccountNumber, len(statement.Transactions))
+		}
+	}
+	return st, nil
+}
+
+// ImportDirectory processes all PDF and CSV files in a directory or zip archive
+func (im *Importer) ImportDirectory(ctx context.Context, dirPath string, opts ImportOptions) (*ImportResult, error) {
+	inputs, err := extractor.CollectInputs(dirPath, opts.Scan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	log.Printf("Scanning: %s", dirPath)
+	log.Printf("Found %d files (PDF/CSV)\n", len(inputs))
+
+	return im.ImportInputs(ctx, inputs, opts), nil
+}
+
+// ImportInputs imports several files, opts.Jobs at a time
+func (im *Importer) ImportInputs(ctx context.Context, inputs []extractor.Input, opts ImportOptions) *ImportResult {
+	result := &ImportResult{}
+
+	// Files are imported concurrently, but outcomes are collected by index
+	// so counts and error messages come out in directory order
+	outcomes := make([]fileOutcome, len(inputs))
+
+	extractor_common.ForEach(len(inputs), opts.Jobs, func(i int) {
+		o := im.importInput(ctx, inputs[i], opts)
+		outcomes[i] = o
+
+		// Log failures if verbose
+		if opts.Verbose && o.failed > 0 {
+			for _, errMsg := range o.errors {
+				log.Printf("FAIL %s", errMsg)
+			}
+		}
+	})
+
+	for _, o := range outcomes {
+		result.add(o)
+	}
+
+	return result
+}
+
+// Import handles both file and directory imports
+func (im *Importer) Import(ctx context.Context, path string, opts ImportOptions) (*ImportResult, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat path: %w", err)
+	}
+
+	if info.IsDir() || extractor.IsZipFile(path) {
+		return im.ImportDirectory(ctx, path, opts)
+	}
+
+	// Single file
+	result := &ImportResult{}
+	result.add(im.importInput(ctx, extractor.Input{Path: path}, opts))
+
+	return result, nil
+}
@@ -0,0 +1,99 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	extractor_common "github.com/aqlanhadi/kwgn/extractor/common"
+	"github.com/aqlanhadi/kwgn/integrations/sqlite"
+	"github.com/aqlanhadi/kwgn/integrations/store"
+	"github.com/shopspring/decimal"
+)
+
+// tngExport builds a TNG CSV export statement whose rows are numbered from 1, as
+// the extractor numbers them per file
+func tngExport(references ...string) extractor_common.Statement {
+	date := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	statement := extractor_common.Statement{
+		StatementDate: &date,
+		Account:       extractor_common.Account{AccountNumber: "2222222222", AccountType: "TNG_CSV_EXPORT"},
+	}
+	for i, ref := range references {
+		statement.Transactions = append(statement.Transactions, extractor_common.Transaction{
+			Sequence:     i + 1,
+			Date:         date.AddDate(0, 0, i),
+			Descriptions: []string{"TOLL"},
+			Type:         "debit",
+			Amount:       decimal.RequireFromString("1.00"),
+			Reference:    ref,
+		})
+	}
+	return statement
+}
+
+func TestImportStatement_OverlappingTNGExports(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.Open(ctx, ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.EnsureSchema(ctx); err != nil {
+		t.Fatal(err)
+	}
+	im := store.NewImporter(db)
+
+	if inserted, skipped, err := im.ImportStatement(ctx, tngExport("TX001", "TX002"), store.ImportOptions{}); err != nil || inserted != 2 || skipped != 0 {
+		t.Fatalf("first export = %d inserted, %d skipped, %v", inserted, skipped, err)
+	}
+
+	// The later export repeats TX002; TX003 takes sequence 2 in the file but is new
+	inserted, skipped, err := im.ImportStatement(ctx, tngExport("TX002", "TX003"), store.ImportOptions{})
+	if err != nil || inserted != 1 || skipped != 1 {
+		t.Fatalf("second export = %d inserted, %d skipped, %v; want 1 and 1", inserted, skipped, err)
+	}
+
+	records, err := db.QueryTransactions(ctx, store.TransactionQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[2].Reference != "TX003" || records[2].Sequence != 3 {
+		t.Errorf("Expected TX003 stored after the first export, got %+v", records)
+	}
+}
+
+func TestImportStatement_MergeRepeatedReference(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.Open(ctx, ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.EnsureSchema(ctx); err != nil {
+		t.Fatal(err)
+	}
+	im := store.NewImporter(db)
+
+	statement := tngExport("TX001")
+	statement.Account.AccountType = "SAVINGS"
+	if _, _, err := im.ImportStatement(ctx, statement, store.ImportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The re-extraction repeats TX001 on a second row, which must not abort the merge
+	statement = tngExport("TX001", "TX001")
+	statement.Account.AccountType = "SAVINGS"
+	inserted, skipped, err := im.ImportStatement(ctx, statement, store.ImportOptions{Merge: true})
+	if err != nil || inserted != 0 || skipped != 2 {
+		t.Fatalf("merge = %d inserted, %d skipped, %v; want 0 and 2", inserted, skipped, err)
+	}
+
+	records, err := db.QueryTransactions(ctx, store.TransactionQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Errorf("Expected the single stored transaction to remain, got %+v", records)
+	}
+}
@@ -0,0 +1,251 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/extractor/common"
+	"github.com/shopspring/decimal"
+)
+
+// Transaction changes reported by a merge
+const (
+	ChangeInsert = "insert"
+	ChangeUpdate = "update"
+	ChangeDelete = "delete"
+	// ChangeConflict is a re-extracted transaction left out because its
+	// reference repeats an earlier one in the same statement
+	ChangeConflict = "conflict"
+)
+
+// MergeReport lists what a merge re-import changed in one statement
+type MergeReport struct {
+	File             string              `json:"file"`
+	AccountNumber    string              `json:"account_number"`
+	StatementDate    string              `json:"statement_date"`
+	StatementID      string              `json:"statement_id"`
+	StatementUpdated bool                `json:"statement_updated"` // Totals, balances or source changed
+	Unchanged        int                 `json:"unchanged"`
+	Changes          []TransactionChange `json:"changes"`
+}
+
+// Count returns how many changes of the given kind the report holds
+func (r MergeReport) Count(action string) int {
+	n := 0
+	for _, c := range r.Changes {
+		if c.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// TransactionChange is a single inserted, updated or deleted transaction
+type TransactionChange struct {
+	Action      string   `json:"action"`
+	Sequence    int      `json:"sequence"`
+	Date        string   `json:"date"`
+	Description string   `json:"description"`
+	Amount      string   `json:"amount"`
+	Fields      []string `json:"fields,omitempty"` // "field: old -> new" for updates, the clash for conflicts
+}
+
+// transactionMatches pairs stored transactions with re-extracted ones
+type transactionMatches struct {
+	matched  [][2]int // {stored index, incoming index}
+	inserted []int    // incoming indexes with no stored counterpart
+	deleted  []int    // stored indexes that vanished from the new extraction
+	conflict [][2]int // {earlier incoming index, incoming index} sharing a reference
+}
+
+// fingerprint identifies a transaction independently of its position in the statement
+func fingerprint(date time.Time, txType string, amount decimal.Decimal, descriptions []string) string {
+	return strings.Join([]string{
+		date.Format(time.DateOnly), txType, amount.StringFixed(2), NormalizeDescription(descriptions),
+	}, "|")
+}
+
+// matchTransactions pairs re-extracted transactions with stored ones, trying the
+// reference first, then the fingerprint (date, type, amount, description), then
+// the sequence number. Each stored transaction is matched at most once.
+// References are unique within a statement, so an incoming transaction repeating
+// an earlier one's reference is neither matched nor inserted but reported as a conflict.
+func matchTransactions(stored []StoredTransaction, incoming []common.Transaction) transactionMatches {
+	storedUsed := make([]bool, len(stored))
+	incomingUsed := make([]bool, len(incoming))
+	var m transactionMatches
+
+	seen := make(map[string]int)
+	for j, t := range incoming {
+		if t.Reference == "" {
+			continue
+		}
+		if first, ok := seen[t.Reference]; ok {
+			incomingUsed[j] = true
+			m.conflict = append(m.conflict, [2]int{first, j})
+			continue
+		}
+		seen[t.Reference] = j
+	}
+
+	match := func(key func(s StoredTransaction) string, incomingKey func(t common.Transaction) string) {
+		// Queue stored transactions per key in sequence order so
+		// identical fingerprints pair up in their original order
+		queues := make(map[string][]int)
+		for i, s := range stored {
+			if k := key(s); !storedUsed[i] && k != "" {
+				queues[k] = append(queues[k], i)
+			}
+		}
+		for j, t := range incoming {
+			k := incomingKey(t)
+			if incomingUsed[j] || k == "" || len(queues[k]) == 0 {
+				continue
+			}
+			i := queues[k][0]
+			queues[k] = queues[k][1:]
+			storedUsed[i], incomingUsed[j] = true, true
+			m.matched = append(m.matched, [2]int{i, j})
+		}
+	}
+
+	match(
+		func(s StoredTransaction) string { return s.Reference },
+		func(t common.Transaction) string { return t.Reference },
+	)
+	match(
+		func(s StoredTransaction) string { return fingerprint(s.Date, s.Type, s.Amount, s.Descriptions) },
+		func(t common.Transaction) string { return fingerprint(t.Date, t.Type, t.Amount, t.Descriptions) },
+	)
+	match(
+		func(s StoredTransaction) string { return fmt.Sprint(s.Sequence) },
+		func(t common.Transaction) string { return fmt.Sprint(t.Sequence) },
+	)
+
+	for j := range incoming {
+		if !incomingUsed[j] {
+			m.inserted = append(m.inserted, j)
+		}
+	}
+	for i := range stored {
+		if !storedUsed[i] {
+			m.deleted = append(m.deleted, i)
+		}
+	}
+	slices.SortFunc(m.matched, func(a, b [2]int) int { return a[1] - b[1] })
+	return m
+}
+
+// transactionFieldChanges lists the extracted fields that differ between a stored and a re-extracted transaction
+func transactionFieldChanges(old StoredTransaction, tx common.Transaction) []string {
+	var changes []string
+	if old.Sequence != tx.Sequence {
+		changes = append(changes, fmt.Sprintf("sequence: %d -> %d", old.Sequence, tx.Sequence))
+	}
+	if !old.Date.Equal(tx.Date) {
+		changes = append(changes, fmt.Sprintf("date: %s -> %s", old.Date.Format(time.DateOnly), tx.Date.Format(time.DateOnly)))
+	}
+	if !slices.Equal(old.Descriptions, tx.Descriptions) {
+		changes = append(changes, fmt.Sprintf("descriptions: %q -> %q", old.Descriptions, tx.Descriptions))
+	}
+	if old.Type != tx.Type {
+		changes = append(changes, fmt.Sprintf("type: %s -> %s", old.Type, tx.Type))
+	}
+	if !old.Amount.Equal(tx.Amount) {
+		changes = append(changes, fmt.Sprintf("amount: %s -> %s", old.Amount.StringFixed(2), tx.Amount.StringFixed(2)))
+	}
+	if !old.Balance.Equal(tx.Balance) {
+		changes = append(changes, fmt.Sprintf("balance: %s -> %s", old.Balance.StringFixed(2), tx.Balance.StringFixed(2)))
+	}
+	if old.Reference != tx.Reference {
+		changes = append(changes, fmt.Sprintf("reference: %q -> %q", old.Reference, tx.Reference))
+	}
+	return changes
+}
+
+// mergeStatement re-imports a statement into an existing one without losing user edits.
+// Matched transactions have their extracted fields updated in place, new ones are
+// inserted and vanished ones deleted; tags and data on existing rows are never touched.
+// tx must be a transaction so the merge is applied all-or-nothing.
+func mergeStatement(ctx context.Context, tx Store, statementID string, statement common.Statement) (*MergeReport, error) {
+	report := &MergeReport{
+		AccountNumber: statement.Account.AccountNumber,
+		StatementDate: statement.StatementDate.Format(time.DateOnly),
+		StatementID:   statementID,
+	}
+
+	stored, err := tx.StoredTransactions(ctx, statementID)
+	if err != nil {
+		return nil, err
+	}
+	m := matchTransactions(stored, statement.Transactions)
+
+	// Remove vanished transactions first so their sequences and references are free
+	var deleteIDs []string
+	for _, i := range m.deleted {
+		s := stored[i]
+		deleteIDs = append(deleteIDs, s.ID)
+		report.Changes = append(report.Changes, TransactionChange{
+			Action: ChangeDelete, Sequence: s.Sequence, Date: s.Date.Format(time.DateOnly),
+			Description: NormalizeDescription(s.Descriptions), Amount: s.Amount.StringFixed(2),
+		})
+	}
+	if err := tx.DeleteTransactions(ctx, deleteIDs); err != nil {
+		return nil, err
+	}
+
+	// Every matched row is rewritten so sequences stay consistent, but only
+	// rows whose extracted fields differ are reported
+	var updates []TransactionUpdate
+	for _, pair := range m.matched {
+		old, t := stored[pair[0]], statement.Transactions[pair[1]]
+		updates = append(updates, TransactionUpdate{ID: old.ID, Transaction: t})
+
+		fields := transactionFieldChanges(old, t)
+		if len(fields) == 0 {
+			report.Unchanged++
+			continue
+		}
+		report.Changes = append(report.Changes, TransactionChange{
+			Action: ChangeUpdate, Sequence: t.Sequence, Date: t.Date.Format(time.DateOnly),
+			Description: NormalizeDescription(t.Descriptions), Amount: t.Amount.StringFixed(2),
+			Fields: fields,
+		})
+	}
+	if err := tx.UpdateTransactions(ctx, statementID, updates); err != nil {
+		return nil, err
+	}
+
+	var inserts []common.Transaction
+	for _, j := range m.inserted {
+		t := statement.Transactions[j]
+		inserts = append(inserts, t)
+		report.Changes = append(report.Changes, TransactionChange{
+			Action: ChangeInsert, Sequence: t.Sequence, Date: t.Date.Format(time.DateOnly),
+			Description: NormalizeDescription(t.Descriptions), Amount: t.Amount.StringFixed(2),
+		})
+	}
+	if _, err := tx.CreateTransactions(ctx, statementID, inserts, false); err != nil {
+		return nil, err
+	}
+
+	for _, pair := range m.conflict {
+		first, t := statement.Transactions[pair[0]], statement.Transactions[pair[1]]
+		report.Changes = append(report.Changes, TransactionChange{
+			Action: ChangeConflict, Sequence: t.Sequence, Date: t.Date.Format(time.DateOnly),
+			Description: NormalizeDescription(t.Descriptions), Amount: t.Amount.StringFixed(2),
+			Fields: []string{fmt.Sprintf("reference %q already used by #%d", t.Reference, first.Sequence)},
+		})
+	}
+
+	updated, err := tx.UpdateStatement(ctx, statementID, statement)
+	if err != nil {
+		return nil, err
+	}
+	report.StatementUpdated = updated
+
+	return report, nil
+}
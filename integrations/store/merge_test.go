@@ -0,0 +1,91 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/extractor/common"
+	"github.com/shopspring/decimal"
+)
+
+func day(d int) time.Time {
+	return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestMatchTransactions(t *testing.T) {
+	stored := []StoredTransaction{
+		{ID: "a", Sequence: 1, Date: day(1), Descriptions: []string{"GRAB"}, Type: "debit", Amount: decimal.RequireFromString("12.00")},
+		{ID: "b", Sequence: 2, Date: day(2), Descriptions: []string{"SALARY"}, Type: "credit", Amount: decimal.RequireFromString("5000.00")},
+		{ID: "c", Sequence: 3, Date: day(3), Descriptions: []string{"TOLL"}, Type: "debit", Amount: decimal.RequireFromString("2.10"), Reference: "T1"},
+		{ID: "d", Sequence: 4, Date: day(4), Descriptions: []string{"GONE"}, Type: "debit", Amount: decimal.RequireFromString("1.00")},
+	}
+	incoming := []common.Transaction{
+		// New transaction inserted at the top shifts every sequence by one
+		{Sequence: 1, Date: day(1), Descriptions: []string{"NEW"}, Type: "debit", Amount: decimal.RequireFromString("3.00")},
+		{Sequence: 2, Date: day(1), Descriptions: []string{"GRAB"}, Type: "debit", Amount: decimal.RequireFromString("12.00")},
+		{Sequence: 3, Date: day(2), Descriptions: []string{"SALARY"}, Type: "credit", Amount: decimal.RequireFromString("5000.00")},
+		// Extractor fix changed the amount; still matched by reference
+		{Sequence: 4, Date: day(3), Descriptions: []string{"TOLL"}, Type: "debit", Amount: decimal.RequireFromString("2.20"), Reference: "T1"},
+	}
+
+	m := matchTransactions(stored, incoming)
+
+	got := map[string]int{}
+	for _, pair := range m.matched {
+		got[stored[pair[0]].ID] = pair[1]
+	}
+	want := map[string]int{"a": 1, "b": 2, "c": 3}
+	for id, j := range want {
+		if got[id] != j {
+			t.Errorf("Expected stored %s matched to incoming %d, got %v", id, j, got)
+		}
+	}
+	if len(m.inserted) != 1 || m.inserted[0] != 0 {
+		t.Errorf("Expected incoming 0 to be inserted, got %v", m.inserted)
+	}
+	if len(m.deleted) != 1 || stored[m.deleted[0]].ID != "d" {
+		t.Errorf("Expected stored d to be deleted, got %v", m.deleted)
+	}
+}
+
+func TestMatchTransactions_FallsBackToSequence(t *testing.T) {
+	stored := []StoredTransaction{
+		{ID: "a", Sequence: 1, Date: day(1), Descriptions: []string{"GRAB FOOD"}, Type: "debit", Amount: decimal.RequireFromString("12.00")},
+	}
+	// Description parsing was fixed, so the fingerprint no longer matches
+	incoming := []common.Transaction{
+		{Sequence: 1, Date: day(1), Descriptions: []string{"GRAB", "FOOD KL"}, Type: "debit", Amount: decimal.RequireFromString("12.00")},
+	}
+
+	m := matchTransactions(stored, incoming)
+	if len(m.matched) != 1 || len(m.inserted) != 0 || len(m.deleted) != 0 {
+		t.Fatalf("Expected a single sequence match, got %+v", m)
+	}
+
+	fields := transactionFieldChanges(stored[0], incoming[0])
+	if len(fields) != 1 {
+		t.Errorf("Expected only descriptions to change, got %v", fields)
+	}
+}
+
+func TestMatchTransactions_RepeatedReference(t *testing.T) {
+	stored := []StoredTransaction{
+		{ID: "a", Sequence: 1, Date: day(1), Descriptions: []string{"TOLL"}, Type: "debit", Amount: decimal.RequireFromString("2.10"), Reference: "T1"},
+	}
+	// Only the first T1 can keep the reference; the second would clash with it
+	incoming := []common.Transaction{
+		{Sequence: 1, Date: day(1), Descriptions: []string{"TOLL"}, Type: "debit", Amount: decimal.RequireFromString("2.10"), Reference: "T1"},
+		{Sequence: 2, Date: day(2), Descriptions: []string{"PARKING"}, Type: "debit", Amount: decimal.RequireFromString("3.00"), Reference: "T1"},
+	}
+
+	m := matchTransactions(stored, incoming)
+	if len(m.matched) != 1 || m.matched[0] != [2]int{0, 0} {
+		t.Errorf("Expected stored a matched to incoming 0, got %v", m.matched)
+	}
+	if len(m.inserted) != 0 || len(m.deleted) != 0 {
+		t.Errorf("Expected nothing inserted or deleted, got %+v", m)
+	}
+	if len(m.conflict) != 1 || m.conflict[0] != [2]int{0, 1} {
+		t.Errorf("Expected incoming 1 to conflict with incoming 0, got %v", m.conflict)
+	}
+}
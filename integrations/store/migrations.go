@@ -0,0 +1,162 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Migration is a numbered schema change with its up and down SQL
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	Unknown   bool // Applied in the database but not embedded in this binary
+}
+
+// migrationName matches files like 0001_initial_schema.up.sql
+var migrationName = regexp.MustCompile(`^(\d{4})_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// LoadMigrations reads NNNN_name.up.sql / NNNN_name.down.sql pairs from fsys.
+// Versions must start at 1 and be contiguous, and every migration needs both halves.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		m := migrationName.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("invalid migration file name %q (want NNNN_name.up.sql or NNNN_name.down.sql)", e.Name())
+		}
+		version, _ := strconv.Atoi(m[1])
+		name, direction := m[2], m[3]
+
+		content, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", e.Name(), err)
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: name}
+			byVersion[version] = mig
+		} else if mig.Name != name {
+			return nil, fmt.Errorf("migration %04d has conflicting names %q and %q", version, mig.Name, name)
+		}
+
+		if direction == "up" {
+			mig.Up = string(content)
+		} else {
+			mig.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	for i, mig := range migrations {
+		if mig.Version != i+1 {
+			return nil, fmt.Errorf("migration versions must be contiguous from 0001: expected %04d, found %04d_%s", i+1, mig.Version, mig.Name)
+		}
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s is missing its .up.sql file", mig.Version, mig.Name)
+		}
+		if mig.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s is missing its .down.sql file", mig.Version, mig.Name)
+		}
+	}
+
+	return migrations, nil
+}
+
+// MergeMigrationStatus combines embedded migrations with the applied set
+func MergeMigrationStatus(migrations []Migration, applied map[int]MigrationStatus) []MigrationStatus {
+	var status []MigrationStatus
+	for _, mig := range migrations {
+		s := MigrationStatus{Version: mig.Version, Name: mig.Name}
+		if a, ok := applied[mig.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = a.AppliedAt
+		}
+		status = append(status, s)
+	}
+	for v, a := range applied {
+		if v > len(migrations) || v < 1 {
+			a.Unknown = true
+			status = append(status, a)
+		}
+	}
+	sort.Slice(status, func(i, j int) bool { return status[i].Version < status[j].Version })
+	return status
+}
+
+// CheckNoGaps refuses to apply a migration while an earlier one is missing
+func CheckNoGaps(applied map[int]MigrationStatus, version int) error {
+	for v := 1; v < version; v++ {
+		if _, ok := applied[v]; !ok {
+			return fmt.Errorf("cannot apply migration %04d: earlier migration %04d has not been applied", version, v)
+		}
+	}
+	return nil
+}
+
+// Versions returns the highest applied migration and the latest one known to this binary
+func Versions(status []MigrationStatus) (current int, latest int) {
+	for _, s := range status {
+		if s.Applied && s.Version > current {
+			current = s.Version
+		}
+		if !s.Unknown {
+			latest = s.Version
+		}
+	}
+	return current, latest
+}
+
+// EnsureSchema brings m up to the latest migration.
+// Nothing is executed when the schema is already current, and a database
+// migrated by a newer kwgn is refused rather than silently used.
+func EnsureSchema(ctx context.Context, m Migrator) error {
+	current, latest, err := m.SchemaVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check schema version: %w", err)
+	}
+	if current > latest {
+		return fmt.Errorf("database schema version %d is newer than this kwgn supports (%d)", current, latest)
+	}
+	if current == latest {
+		return nil
+	}
+
+	applied, err := m.MigrateUp(ctx, 0)
+	for _, mig := range applied {
+		log.Printf("Applied migration %04d_%s", mig.Version, mig.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+	return nil
+}
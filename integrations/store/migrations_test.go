@@ -0,0 +1,98 @@
+package store
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func sqlFile(s string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(s)}
+}
+
+func TestLoadMigrations_Ordered(t *testing.T) {
+	migrations, err := LoadMigrations(fstest.MapFS{
+		"0002_second.up.sql":   sqlFile("CREATE TABLE b ();"),
+		"0002_second.down.sql": sqlFile("DROP TABLE b;"),
+		"0001_first.up.sql":    sqlFile("CREATE TABLE a ();"),
+		"0001_first.down.sql":  sqlFile("DROP TABLE a;"),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(migrations) != 2 || migrations[0].Name != "first" || migrations[1].Name != "second" {
+		t.Errorf("Expected [first second], got %+v", migrations)
+	}
+	if migrations[1].Down != "DROP TABLE b;" {
+		t.Errorf("Expected down SQL for 0002, got %q", migrations[1].Down)
+	}
+}
+
+func TestLoadMigrations_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+		want  string
+	}{
+		{
+			name: "gap in versions",
+			files: fstest.MapFS{
+				"0001_first.up.sql":   sqlFile("x"),
+				"0001_first.down.sql": sqlFile("x"),
+				"0003_third.up.sql":   sqlFile("x"),
+				"0003_third.down.sql": sqlFile("x"),
+			},
+			want: "contiguous",
+		},
+		{
+			name: "missing down",
+			files: fstest.MapFS{
+				"0001_first.up.sql": sqlFile("x"),
+			},
+			want: "missing its .down.sql",
+		},
+		{
+			name: "conflicting names",
+			files: fstest.MapFS{
+				"0001_first.up.sql":   sqlFile("x"),
+				"0001_other.down.sql": sqlFile("x"),
+			},
+			want: "conflicting names",
+		},
+		{
+			name: "bad file name",
+			files: fstest.MapFS{
+				"1_first.sql": sqlFile("x"),
+			},
+			want: "invalid migration file name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadMigrations(tt.files)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestMergeStatus_FlagsUnknown(t *testing.T) {
+	migrations := []Migration{{Version: 1, Name: "first"}, {Version: 2, Name: "second"}}
+	applied := map[int]MigrationStatus{
+		1: {Version: 1, Name: "first", Applied: true},
+		3: {Version: 3, Name: "future", Applied: true},
+	}
+
+	status := MergeMigrationStatus(migrations, applied)
+	if len(status) != 3 {
+		t.Fatalf("Expected 3 entries, got %+v", status)
+	}
+	if !status[0].Applied || status[1].Applied {
+		t.Errorf("Expected only 0001 applied, got %+v", status[:2])
+	}
+	if !status[2].Unknown || status[2].Name != "future" {
+		t.Errorf("Expected 0003 flagged unknown, got %+v", status[2])
+	}
+}
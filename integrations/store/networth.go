@@ -0,0 +1,231 @@
+package store
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Net worth sampling intervals
+const (
+	IntervalDay   = "day"
+	IntervalMonth = "month" // Last day of each month
+)
+
+// NetWorthOptions selects the accounts and dates of a net worth timeline
+type NetWorthOptions struct {
+	Account  string    // Account number, or part of the account name
+	From     time.Time // First date of the series (default: earliest known balance)
+	To       time.Time // Last date of the series (default: latest known balance)
+	Interval string    // IntervalDay (default) or IntervalMonth
+}
+
+// NetWorthPoint is a value on a given day
+type NetWorthPoint struct {
+	Date  string          `json:"date"` // YYYY-MM-DD
+	Value decimal.Decimal `json:"value"`
+}
+
+// AccountNetWorth is the balance timeline of one account
+type AccountNetWorth struct {
+	AccountNumber string          `json:"account_number"`
+	AccountName   string          `json:"account_name"`
+	AccountType   string          `json:"account_type"`
+	Liability     bool            `json:"liability"`
+	Points        []NetWorthPoint `json:"points"`
+}
+
+// NetWorth is a timeline per account and in total
+type NetWorth struct {
+	Accounts []AccountNetWorth `json:"accounts"`
+	Total    []NetWorthPoint   `json:"total"`
+}
+
+// balanceHistory holds the known balances of one account, keyed by YYYY-MM-DD
+type balanceHistory struct {
+	series AccountNetWorth
+	known  map[string]decimal.Decimal
+}
+
+// BuildNetWorth computes net worth over time from stored balances.
+//
+// Each statement's ending balance is the account's balance on its statement date.
+// Between statements the balance after the last transaction of each day is used
+// where available, and the last known balance is carried forward otherwise.
+// Liabilities (debit_credit = credit, i.e. credit cards) count negatively; every
+// other account, e-wallets included, is an asset. An account only contributes
+// from its first known balance onwards.
+func BuildNetWorth(ctx context.Context, s Store, opts NetWorthOptions) (*NetWorth, error) {
+	if opts.Interval != "" && opts.Interval != IntervalDay && opts.Interval != IntervalMonth {
+		return nil, fmt.Errorf("invalid interval %q (valid: %s, %s)", opts.Interval, IntervalDay, IntervalMonth)
+	}
+	if !opts.From.IsZero() && !opts.To.IsZero() && opts.To.Before(opts.From) {
+		return nil, fmt.Errorf("date range is empty: %s is before %s", opts.To.Format(time.DateOnly), opts.From.Format(time.DateOnly))
+	}
+
+	statements, err := s.ListStatements(ctx, opts.Account)
+	if err != nil {
+		return nil, err
+	}
+	// Balances before From are still needed to carry them forward
+	transactions, err := s.QueryTransactions(ctx, TransactionQuery{Account: opts.Account, To: opts.To})
+	if err != nil {
+		return nil, err
+	}
+	return netWorth(statements, transactions, opts), nil
+}
+
+// netWorth builds the timeline from statements and transactions ordered by
+// date, account, statement and sequence
+func netWorth(statements []StatementRecord, transactions []TransactionRecord, opts NetWorthOptions) *NetWorth {
+	accounts := make(map[string]*balanceHistory)
+	history := func(number, name, accountType, debitCredit string) *balanceHistory {
+		h, ok := accounts[number]
+		if !ok {
+			h = &balanceHistory{
+				series: AccountNetWorth{
+					AccountNumber: number,
+					AccountName:   name,
+					AccountType:   accountType,
+					Liability:     IsLiability(debitCredit),
+					Points:        []NetWorthPoint{},
+				},
+				known: make(map[string]decimal.Decimal),
+			}
+			accounts[number] = h
+		}
+		return h
+	}
+
+	// The last transaction of a day carries that day's closing balance
+	for _, t := range transactions {
+		h := history(t.AccountNumber, t.AccountName, t.AccountType, t.DebitCredit)
+		h.known[t.Date.Format(time.DateOnly)] = NetBalance(t.Balance, t.DebitCredit)
+	}
+	// Statement ending balances are authoritative on their statement date
+	for _, st := range statements {
+		if st.StatementDate.Equal(sentinelDate) {
+			continue // TNG CSV exports: one open-ended statement, no meaningful ending balance
+		}
+		if !opts.To.IsZero() && st.StatementDate.After(opts.To) {
+			continue
+		}
+		h := history(st.AccountNumber, st.AccountName, st.AccountType, st.DebitCredit)
+		h.known[st.StatementDate.Format(time.DateOnly)] = NetBalance(st.EndingBalance, st.DebitCredit)
+	}
+
+	result := &NetWorth{Accounts: []AccountNetWorth{}, Total: []NetWorthPoint{}}
+	if len(accounts) == 0 {
+		return result
+	}
+
+	// Date range of the series
+	var first, last string
+	for _, h := range accounts {
+		for date := range h.known {
+			if first == "" || date < first {
+				first = date
+			}
+			if date > last {
+				last = date
+			}
+		}
+	}
+	start, _ := time.Parse(time.DateOnly, first)
+	end, _ := time.Parse(time.DateOnly, last)
+	if !opts.From.IsZero() {
+		start = opts.From
+	}
+	if !opts.To.IsZero() {
+		end = opts.To
+	}
+
+	numbers := make([]string, 0, len(accounts))
+	for number := range accounts {
+		numbers = append(numbers, number)
+	}
+	slices.Sort(numbers)
+
+	// Balances known on or before the first day are carried into the series
+	current := make(map[string]decimal.Decimal)
+	for _, number := range numbers {
+		h := accounts[number]
+		latest := ""
+		for date := range h.known {
+			if date <= start.Format(time.DateOnly) && date > latest {
+				latest = date
+			}
+		}
+		if latest != "" {
+			current[number] = h.known[latest]
+		}
+	}
+
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		date := day.Format(time.DateOnly)
+		for _, number := range numbers {
+			if value, ok := accounts[number].known[date]; ok {
+				current[number] = value
+			}
+		}
+
+		if opts.Interval == IntervalMonth && !day.Equal(end) && day.AddDate(0, 0, 1).Month() == day.Month() {
+			continue
+		}
+
+		total := decimal.Zero
+		for _, number := range numbers {
+			value, ok := current[number]
+			if !ok {
+				continue // No balance known yet
+			}
+			h := accounts[number]
+			h.series.Points = append(h.series.Points, NetWorthPoint{Date: date, Value: value})
+			total = total.Add(value)
+		}
+		result.Total = append(result.Total, NetWorthPoint{Date: date, Value: total})
+	}
+
+	for _, number := range numbers {
+		result.Accounts = append(result.Accounts, accounts[number].series)
+	}
+	return result
+}
+
+// Table lays the timeline out with one row per date and one column per account,
+// followed by the total. Accounts without a known balance yet are left blank.
+func (nw *NetWorth) Table() (header []string, rows [][]string) {
+	header = []string{"date"}
+	values := make([]map[string]string, len(nw.Accounts))
+	for i, a := range nw.Accounts {
+		header = append(header, a.AccountNumber)
+		values[i] = make(map[string]string, len(a.Points))
+		for _, p := range a.Points {
+			values[i][p.Date] = p.Value.StringFixed(2)
+		}
+	}
+	header = append(header, "total")
+
+	for _, p := range nw.Total {
+		row := []string{p.Date}
+		for i := range nw.Accounts {
+			row = append(row, values[i][p.Date])
+		}
+		rows = append(rows, append(row, p.Value.StringFixed(2)))
+	}
+	return header, rows
+}
+
+// WriteCSV writes the timeline as laid out by Table
+func (nw *NetWorth) WriteCSV(w io.Writer) error {
+	header, rows := nw.Table()
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+	cw.WriteAll(rows)
+	return cw.Error()
+}
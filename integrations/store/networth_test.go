@@ -0,0 +1,57 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestNetWorth(t *testing.T) {
+	statements := []StatementRecord{
+		{AccountNumber: "CC", DebitCredit: "credit", StatementDate: day(10), EndingBalance: decimal.NewFromInt(300)},
+		{AccountNumber: "SAV", DebitCredit: "debit", StatementDate: day(4), EndingBalance: decimal.NewFromInt(900)},
+		// TNG CSV statements are not balances
+		{AccountNumber: "TNG", StatementDate: sentinelDate, EndingBalance: decimal.Zero},
+	}
+	transactions := []TransactionRecord{
+		record("SAV", "debit", day(2), "credit", "500", "1000"),
+		record("SAV", "debit", day(2), "debit", "-100", "900"), // Last of the day wins
+		record("TNG", "", day(3), "credit", "50", "50"),
+		record("CC", "credit", day(8), "debit", "200", "200"),
+	}
+
+	nw := netWorth(statements, transactions, NetWorthOptions{})
+	if len(nw.Accounts) != 3 || nw.Accounts[0].AccountNumber != "CC" || !nw.Accounts[0].Liability {
+		t.Fatalf("accounts = %+v", nw.Accounts)
+	}
+	// Day 2 to day 10, every day
+	if len(nw.Total) != 9 || nw.Total[0].Date != "2024-01-02" || nw.Total[8].Date != "2024-01-10" {
+		t.Fatalf("total = %+v", nw.Total)
+	}
+	want := map[string]int64{
+		"2024-01-02": 900,
+		"2024-01-03": 950,       // TNG appears
+		"2024-01-07": 950,       // Carried forward
+		"2024-01-08": 950 - 200, // Card charge is a liability
+		"2024-01-10": 950 - 300, // Statement ending balance
+	}
+	for _, p := range nw.Total {
+		if v, ok := want[p.Date]; ok && !p.Value.Equal(decimal.NewFromInt(v)) {
+			t.Errorf("total on %s = %s, want %d", p.Date, p.Value, v)
+		}
+	}
+	if cc := nw.Accounts[0].Points; len(cc) != 3 || cc[0].Date != "2024-01-08" {
+		t.Errorf("card points = %+v", cc)
+	}
+
+	// Balances before the range are carried in; month sampling keeps the last day
+	feb := time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC)
+	nw = netWorth(statements, transactions, NetWorthOptions{From: day(5), To: feb, Interval: IntervalMonth})
+	if len(nw.Total) != 2 || nw.Total[0].Date != "2024-01-31" || nw.Total[1].Date != "2024-02-15" {
+		t.Fatalf("monthly total = %+v", nw.Total)
+	}
+	if !nw.Total[1].Value.Equal(decimal.NewFromInt(650)) {
+		t.Errorf("monthly value = %s, want 650", nw.Total[1].Value)
+	}
+}
@@ -0,0 +1,456 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/extractor"
+	extractor_common "github.com/aqlanhadi/kwgn/extractor/common"
+)
+
+// Actions reported in an import plan
+const (
+	ActionImport    = "import"    // File: will be extracted and imported
+	ActionCreate    = "create"    // Account or statement does not exist yet
+	ActionUpdate    = "update"    // Account fields will change
+	ActionUnchanged = "unchanged" // Account exists and matches
+	ActionSkip      = "skip"      // File or statement already imported
+	ActionReplace   = "replace"   // Statement exists and --force replaces it
+	ActionAppend    = "append"    // TNG CSV: new transactions added to the existing statement
+	ActionMerge     = "merge"     // Statement exists and --merge updates it in place
+	ActionFail      = "fail"      // Nothing can be imported
+)
+
+// Plan describes what an import would change, without changing anything
+type Plan struct {
+	PendingMigrations int             `json:"pending_migrations"`
+	Files             []FilePlan      `json:"files"`
+	Accounts          []AccountPlan   `json:"accounts"`
+	Statements        []StatementPlan `json:"statements"`
+	Summary           PlanSummary     `json:"summary"`
+	Errors            []string        `json:"errors,omitempty"`
+}
+
+// FilePlan is the planned outcome for one input file
+type FilePlan struct {
+	File   string `json:"file"`
+	Action string `json:"action"` // import, skip or fail
+	Reason string `json:"reason,omitempty"`
+}
+
+// AccountPlan is the planned change to one account
+type AccountPlan struct {
+	AccountNumber string   `json:"account_number"`
+	AccountName   string   `json:"account_name"`
+	Action        string   `json:"action"` // create, update or unchanged
+	Changes       []string `json:"changes,omitempty"`
+}
+
+// StatementPlan is the planned change to one statement and its transactions
+type StatementPlan struct {
+	File               string `json:"file"`
+	AccountNumber      string `json:"account_number"`
+	StatementDate      string `json:"statement_date"`
+	Action             string `json:"action"` // create, skip, replace, append or merge
+	ExistingID         string `json:"existing_id,omitempty"`
+	TransactionsInsert int    `json:"transactions_insert"`
+	TransactionsSkip   int    `json:"transactions_skip"`
+	TransactionsUpdate int    `json:"transactions_update,omitempty"`
+	TransactionsDelete int    `json:"transactions_delete,omitempty"`
+}
+
+// PlanSummary totals a plan
+type PlanSummary struct {
+	FilesImport        int `json:"files_import"`
+	FilesSkip          int `json:"files_skip"`
+	FilesFail          int `json:"files_fail"`
+	AccountsCreate     int `json:"accounts_create"`
+	AccountsUpdate     int `json:"accounts_update"`
+	StatementsCreate   int `json:"statements_create"`
+	StatementsSkip     int `json:"statements_skip"`
+	StatementsReplace  int `json:"statements_replace"`
+	StatementsAppend   int `json:"statements_append"`
+	StatementsMerge    int `json:"statements_merge"`
+	TransactionsInsert int `json:"transactions_insert"`
+	TransactionsSkip   int `json:"transactions_skip"`
+	TransactionsUpdate int `json:"transactions_update"`
+	TransactionsDelete int `json:"transactions_delete"`
+}
+
+// planner simulates an import with read-only lookups.
+// Earlier files in the plan are treated as imported when planning later ones.
+type planner struct {
+	store     Store
+	opts      ImportOptions
+	plan      *Plan
+	hasSchema bool // accounts/statements/transactions exist
+	hasLedger bool // import_files exists
+
+	accounts   map[string]string            // account number -> id ("" when it would be created)
+	statements map[string]*plannedStatement // account number|date -> statement state
+	hashes     map[string]string            // content hash -> first file in the plan
+}
+
+// plannedStatement tracks a statement as it would look after earlier files
+type plannedStatement struct {
+	exists     bool
+	id         string
+	count      int
+	references map[string]bool
+}
+
+// extracted holds the extraction result for one input
+type extracted struct {
+	name       string
+	hash       string
+	statements []extractor_common.Statement
+	err        error
+}
+
+// Plan extracts path (file, directory or zip archive) and works out what
+// Import would do with the same options, using only lookups.
+// Nothing is written to the database, including pending migrations.
+func (im *Importer) Plan(ctx context.Context, path string, opts ImportOptions) (*Plan, error) {
+	inputs, err := extractor.CollectInputs(path, opts.Scan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	p := &planner{
+		store:      im.store,
+		opts:       opts,
+		plan:       &Plan{},
+		accounts:   make(map[string]string),
+		statements: make(map[string]*plannedStatement),
+		hashes:     make(map[string]string),
+	}
+
+	current, latest, err := im.store.SchemaVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.plan.PendingMigrations = latest - current
+	if p.hasSchema, err = im.store.TableExists(ctx, "accounts"); err != nil {
+		return nil, err
+	}
+	if p.hasLedger, err = im.store.TableExists(ctx, "import_files"); err != nil {
+		return nil, err
+	}
+
+	// Extraction is the slow part and runs concurrently; planning is serial
+	// so later files see the effects of earlier ones
+	results := make([]extracted, len(inputs))
+	extractor_common.ForEach(len(inputs), opts.Jobs, func(i int) {
+		results[i] = extractInput(inputs[i], opts.StatementType)
+	})
+
+	for _, r := range results {
+		if err := p.planFile(ctx, r); err != nil {
+			return nil, err
+		}
+	}
+
+	p.summarize()
+	return p.plan, nil
+}
+
+// extractInput reads, hashes and extracts a single input
+func extractInput(in extractor.Input, statementType string) extracted {
+	r := extracted{name: in.Name()}
+	data, err := in.ReadAll()
+	if err != nil {
+		r.err = err
+		return r
+	}
+	r.hash = HashContent(data)
+	r.statements, _ = extractor.ProcessReaderDetect(bytes.NewReader(data), in.Location(), statementType)
+	return r
+}
+
+// planFile plans a single input, mirroring ImportInput
+func (p *planner) planFile(ctx context.Context, r extracted) error {
+	fail := func(reason string) {
+		p.plan.Files = append(p.plan.Files, FilePlan{File: r.name, Action: ActionFail, Reason: reason})
+		p.plan.Errors = append(p.plan.Errors, fmt.Sprintf("%s: %s", r.name, reason))
+	}
+
+	if r.err != nil {
+		fail(fmt.Sprintf("failed to open file: %v", r.err))
+		return nil
+	}
+
+	if !p.opts.Force && !p.opts.Merge {
+		if first, ok := p.hashes[r.hash]; ok {
+			p.plan.Files = append(p.plan.Files, FilePlan{File: r.name, Action: ActionSkip, Reason: "duplicate of " + first})
+			return nil
+		}
+		if p.hasLedger {
+			prev, err := p.store.GetFileRecord(ctx, r.hash)
+			if err != nil {
+				return err
+			}
+			if prev != nil && prev.IsSettled(extractor.Version) {
+				p.plan.Files = append(p.plan.Files, FilePlan{File: r.name, Action: ActionSkip, Reason: "already imported as " + prev.Path})
+				return nil
+			}
+		}
+	}
+	p.hashes[r.hash] = r.name
+
+	if len(r.statements) == 0 {
+		fail("no statements extracted")
+		return nil
+	}
+
+	p.plan.Files = append(p.plan.Files, FilePlan{File: r.name, Action: ActionImport})
+	for _, statement := range r.statements {
+		if statement.Account.AccountNumber == "" {
+			p.plan.Errors = append(p.plan.Errors, fmt.Sprintf("%s: no account number extracted", r.name))
+			continue
+		}
+		if statement.StatementDate == nil {
+			p.plan.Errors = append(p.plan.Errors, fmt.Sprintf("%s [%s]: no statement date extracted", r.name, statement.Account.AccountNumber))
+			continue
+		}
+		if err := p.planStatement(ctx, r.name, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// planAccount records the planned change to an account and returns its id
+// ("" when the account would be created)
+func (p *planner) planAccount(ctx context.Context, account extractor_common.Account) (string, error) {
+	if id, ok := p.accounts[account.AccountNumber]; ok {
+		return id, nil
+	}
+
+	var stored *StoredAccount
+	if p.hasSchema {
+		var err error
+		if stored, err = p.store.FindAccount(ctx, account.AccountNumber); err != nil {
+			return "", err
+		}
+	}
+
+	ap := AccountPlan{AccountNumber: account.AccountNumber, AccountName: account.AccountName}
+	id := ""
+	switch {
+	case stored == nil:
+		ap.Action = ActionCreate
+	default:
+		id = stored.ID
+		ap.Changes = accountChanges(*stored, account)
+		ap.Action = ActionUnchanged
+		if len(ap.Changes) > 0 {
+			ap.Action = ActionUpdate
+		}
+	}
+
+	p.plan.Accounts = append(p.plan.Accounts, ap)
+	p.accounts[account.AccountNumber] = id
+	return id, nil
+}
+
+// planStatement plans a single statement, mirroring importStatement
+func (p *planner) planStatement(ctx context.Context, fileName string, statement extractor_common.Statement) error {
+	isTNGCSV := isTNGCSVAccount(statement.Account)
+	effectiveStatementDate := *statement.StatementDate
+	if isTNGCSV {
+		effectiveStatementDate = sentinelDate
+	}
+
+	accountID, err := p.planAccount(ctx, statement.Account)
+	if err != nil {
+		return err
+	}
+
+	ps, err := p.statement(ctx, accountID, statement.Account.AccountNumber, effectiveStatementDate)
+	if err != nil {
+		return err
+	}
+
+	sp := StatementPlan{
+		File:          fileName,
+		AccountNumber: statement.Account.AccountNumber,
+		StatementDate: statement.StatementDate.Format(time.DateOnly),
+		ExistingID:    ps.id,
+	}
+
+	switch {
+	case isTNGCSV:
+		sp.Action = ActionCreate
+		if ps.exists {
+			sp.Action = ActionAppend
+		}
+		sp.TransactionsInsert, sp.TransactionsSkip = planInserts(statement.Transactions, ps.references)
+		if ps.exists && sp.TransactionsInsert == 0 {
+			sp.Action = ActionSkip
+		}
+		ps.count += sp.TransactionsInsert
+
+	case ps.exists && p.opts.Merge:
+		if err := p.planMerge(ctx, &sp, ps, statement); err != nil {
+			return err
+		}
+
+	case ps.exists && !p.opts.Force:
+		sp.Action = ActionSkip
+		sp.TransactionsSkip = len(statement.Transactions)
+
+	default:
+		sp.Action = ActionCreate
+		if ps.exists {
+			sp.Action = ActionReplace
+			sp.TransactionsDelete = ps.count
+		}
+		// Every row is inserted; references are recorded for later files in the plan
+		ps.references = make(map[string]bool)
+		planInserts(statement.Transactions, ps.references)
+		sp.TransactionsInsert = len(statement.Transactions)
+		ps.count = len(statement.Transactions)
+	}
+
+	ps.exists = true
+	p.plan.Statements = append(p.plan.Statements, sp)
+	return nil
+}
+
+// planMerge plans merging a statement into an existing one, mirroring mergeStatement.
+// Unchanged transactions count as skipped.
+func (p *planner) planMerge(ctx context.Context, sp *StatementPlan, ps *plannedStatement, statement extractor_common.Statement) error {
+	sp.Action = ActionMerge
+	if ps.id == "" {
+		// Created by an earlier file in this plan; assume the same content
+		sp.TransactionsSkip = len(statement.Transactions)
+		return nil
+	}
+
+	stored, err := p.store.StoredTransactions(ctx, ps.id)
+	if err != nil {
+		return err
+	}
+	m := matchTransactions(stored, statement.Transactions)
+	for _, pair := range m.matched {
+		if len(transactionFieldChanges(stored[pair[0]], statement.Transactions[pair[1]])) > 0 {
+			sp.TransactionsUpdate++
+		} else {
+			sp.TransactionsSkip++
+		}
+	}
+	sp.TransactionsInsert = len(m.inserted)
+	sp.TransactionsDelete = len(m.deleted)
+
+	ps.references = make(map[string]bool)
+	planInserts(statement.Transactions, ps.references)
+	ps.count = len(statement.Transactions)
+	return nil
+}
+
+// statement returns the planned state of a statement, loading it on first use
+func (p *planner) statement(ctx context.Context, accountID, accountNumber string, date time.Time) (*plannedStatement, error) {
+	key := accountNumber + "|" + date.Format(time.DateOnly)
+	if ps, ok := p.statements[key]; ok {
+		return ps, nil
+	}
+
+	ps := &plannedStatement{references: make(map[string]bool)}
+	if accountID != "" {
+		exists, id, err := p.store.StatementExists(ctx, accountID, date)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			ps.exists, ps.id = true, id
+			var sequences map[int]bool
+			if sequences, ps.references, err = p.store.TransactionKeys(ctx, id); err != nil {
+				return nil, err
+			}
+			ps.count = len(sequences)
+		}
+	}
+
+	p.statements[key] = ps
+	return ps, nil
+}
+
+// planInserts counts which transactions an idempotent insert would write, given
+// the references already stored, and records the ones it writes. Mirrors the
+// importer, which renumbers the rows after the stored ones and skips only
+// clashes on (statement_id, reference).
+func planInserts(transactions []extractor_common.Transaction, references map[string]bool) (insert int, skip int) {
+	for _, tx := range transactions {
+		if tx.Reference != "" && references[tx.Reference] {
+			skip++
+			continue
+		}
+		if tx.Reference != "" {
+			references[tx.Reference] = true
+		}
+		insert++
+	}
+	return insert, skip
+}
+
+// summarize totals the plan
+func (p *planner) summarize() {
+	s := &p.plan.Summary
+	for _, f := range p.plan.Files {
+		switch f.Action {
+		case ActionImport:
+			s.FilesImport++
+		case ActionSkip:
+			s.FilesSkip++
+		case ActionFail:
+			s.FilesFail++
+		}
+	}
+	for _, a := range p.plan.Accounts {
+		switch a.Action {
+		case ActionCreate:
+			s.AccountsCreate++
+		case ActionUpdate:
+			s.AccountsUpdate++
+		}
+	}
+	for _, st := range p.plan.Statements {
+		switch st.Action {
+		case ActionCreate:
+			s.StatementsCreate++
+		case ActionSkip:
+			s.StatementsSkip++
+		case ActionReplace:
+			s.StatementsReplace++
+		case ActionAppend:
+			s.StatementsAppend++
+		case ActionMerge:
+			s.StatementsMerge++
+		}
+		s.TransactionsInsert += st.TransactionsInsert
+		s.TransactionsSkip += st.TransactionsSkip
+		s.TransactionsUpdate += st.TransactionsUpdate
+		s.TransactionsDelete += st.TransactionsDelete
+	}
+}
+
+// accountChanges lists the fields GetOrCreateAccount would update on an existing account.
+// Empty account_type and debit_credit never overwrite stored values.
+func accountChanges(stored StoredAccount, account extractor_common.Account) []string {
+	var changes []string
+	if stored.AccountName != account.AccountName {
+		changes = append(changes, fmt.Sprintf("account_name: %q -> %q", stored.AccountName, account.AccountName))
+	}
+	if account.AccountType != "" && stored.AccountType != account.AccountType {
+		changes = append(changes, fmt.Sprintf("account_type: %q -> %q", stored.AccountType, account.AccountType))
+	}
+	if account.DebitCredit != "" && stored.DebitCredit != account.DebitCredit {
+		changes = append(changes, fmt.Sprintf("debit_credit: %q -> %q", stored.DebitCredit, account.DebitCredit))
+	}
+	if stored.Reconciliable != account.Reconciliable {
+		changes = append(changes, fmt.Sprintf("reconciliable: %t -> %t", stored.Reconciliable, account.Reconciliable))
+	}
+	return changes
+}
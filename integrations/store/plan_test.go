@@ -0,0 +1,42 @@
+package store
+
+import (
+	"testing"
+
+	extractor_common "github.com/aqlanhadi/kwgn/extractor/common"
+)
+
+func TestPlanInserts_MirrorsOnConflict(t *testing.T) {
+	// Reference "B" is already stored
+	references := map[string]bool{"B": true}
+
+	transactions := []extractor_common.Transaction{
+		{Sequence: 1, Reference: "A"}, // new, sequences are renumbered so they never clash
+		{Sequence: 2, Reference: "B"}, // reference clash
+		{Sequence: 3, Reference: "C"}, // new
+		{Sequence: 4, Reference: "C"}, // duplicate reference within the file
+		{Sequence: 5},                 // new, empty references never clash
+		{Sequence: 6},                 // new
+	}
+
+	insert, skip := planInserts(transactions, references)
+	if insert != 4 || skip != 2 {
+		t.Errorf("Expected 4 inserts and 2 skips, got %d and %d", insert, skip)
+	}
+
+	// Inserted references are recorded so later files in the plan see them
+	if !references["A"] || !references["C"] {
+		t.Errorf("Expected inserted references to be recorded, got %v", references)
+	}
+}
+
+func TestNewTransactions(t *testing.T) {
+	transactions := []extractor_common.Transaction{{Sequence: 1, Reference: "A"}, {Sequence: 2, Reference: "B"}, {Sequence: 3}}
+	fresh := newTransactions(transactions, map[int]bool{1: true, 7: true, 3: true}, map[string]bool{"A": true})
+	if len(fresh) != 2 || fresh[0].Reference != "B" || fresh[0].Sequence != 8 || fresh[1].Sequence != 9 {
+		t.Errorf("Expected B and the unreferenced row as 8 and 9, got %+v", fresh)
+	}
+	if transactions[1].Sequence != 2 {
+		t.Error("Expected the input to be left unchanged")
+	}
+}
@@ -0,0 +1,140 @@
+package store
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Fields transactions can be sorted by
+const (
+	SortDate        = "date"
+	SortAmount      = "amount"
+	SortAccount     = "account"
+	SortType        = "type"
+	SortDescription = "description"
+)
+
+// SortFields lists the accepted TransactionQuery.Sort values
+var SortFields = []string{SortDate, SortAmount, SortAccount, SortType, SortDescription}
+
+// TransactionQuery filters, sorts and pages stored transactions.
+// Zero values leave a filter unset.
+type TransactionQuery struct {
+	ID            string    // A single transaction
+	Account       string    // Account number, or part of the account name (case-insensitive)
+	From          time.Time // Earliest transaction date, inclusive
+	To            time.Time // Latest transaction date, inclusive
+	Type          string    // debit or credit
+	MinAmount     *decimal.Decimal
+	MaxAmount     *decimal.Decimal
+	Description   string   // Regular expression matched case-insensitively against the normalized description
+	Tags          []string // Transactions must carry every one of these tags
+	StatementID   string
+	StatementDate time.Time
+
+	Sort   string // One of SortFields (default: date)
+	Desc   bool   // Sort descending
+	Limit  int    // 0 = no limit
+	Offset int
+}
+
+// Validate checks the query before it is sent to a backend
+func (q TransactionQuery) Validate() error {
+	if q.Sort != "" && !slices.Contains(SortFields, q.Sort) {
+		return fmt.Errorf("invalid sort field %q (valid: %v)", q.Sort, SortFields)
+	}
+	if q.Description != "" {
+		if _, err := regexp.Compile(q.Description); err != nil {
+			return fmt.Errorf("invalid description pattern: %w", err)
+		}
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && q.To.Before(q.From) {
+		return fmt.Errorf("date range is empty: %s is before %s", q.To.Format(time.DateOnly), q.From.Format(time.DateOnly))
+	}
+	if q.MinAmount != nil && q.MaxAmount != nil && q.MaxAmount.LessThan(*q.MinAmount) {
+		return fmt.Errorf("amount range is empty: %s is less than %s", q.MaxAmount, q.MinAmount)
+	}
+	if q.Limit < 0 || q.Offset < 0 {
+		return fmt.Errorf("limit and offset must not be negative")
+	}
+	return nil
+}
+
+// AccountQuery filters and pages stored accounts. Zero values leave a filter unset.
+type AccountQuery struct {
+	Account string // Account number, or part of the account name (case-insensitive)
+	Type    string // Account type (case-insensitive)
+	Limit   int    // 0 = no limit
+	Offset  int
+}
+
+// StatementQuery filters and pages the statements of one account, ordered by
+// statement date. Zero values leave a filter unset.
+type StatementQuery struct {
+	AccountID string
+	From      time.Time // Earliest statement date, inclusive
+	To        time.Time // Latest statement date, inclusive
+	Desc      bool      // Newest first
+	Limit     int       // 0 = no limit
+	Offset    int
+}
+
+// TransactionRecord is a stored transaction together with its account and statement
+type TransactionRecord struct {
+	ID            string          `json:"id"`
+	AccountNumber string          `json:"account_number"`
+	AccountName   string          `json:"account_name"`
+	AccountType   string          `json:"account_type"`
+	DebitCredit   string          `json:"debit_credit"`
+	StatementID   string          `json:"statement_id"`
+	StatementDate time.Time       `json:"statement_date"`
+	Sequence      int             `json:"sequence"`
+	Date          time.Time       `json:"date"`
+	Descriptions  []string        `json:"descriptions"`
+	Description   string          `json:"description"`
+	Type          string          `json:"type"`
+	Amount        decimal.Decimal `json:"amount"`
+	Balance       decimal.Decimal `json:"balance"`
+	Reference     string          `json:"ref"`
+	Tags          []string        `json:"tags"`
+	Data          map[string]any  `json:"data"`
+}
+
+// AccountRecord is a stored account together with its latest statement. The latest
+// fields are nil for an account without statements.
+type AccountRecord struct {
+	ID                  string           `json:"id"`
+	AccountNumber       string           `json:"account_number"`
+	AccountName         string           `json:"account_name"`
+	AccountType         string           `json:"account_type"`
+	DebitCredit         string           `json:"debit_credit"`
+	Reconciliable       bool             `json:"reconciliable"`
+	StatementCount      int              `json:"statement_count"`
+	LatestStatementID   *string          `json:"latest_statement_id"`
+	LatestStatementDate *time.Time       `json:"latest_statement_date"`
+	LatestBalance       *decimal.Decimal `json:"latest_balance"` // Ending balance of the latest statement
+}
+
+// StatementRecord is a stored statement together with its account
+type StatementRecord struct {
+	ID                      string          `json:"id"`
+	AccountNumber           string          `json:"account_number"`
+	AccountName             string          `json:"account_name"`
+	AccountType             string          `json:"account_type"`
+	DebitCredit             string          `json:"debit_credit"`
+	Source                  string          `json:"source"`
+	StatementDate           time.Time       `json:"statement_date"`
+	StartingBalance         decimal.Decimal `json:"starting_balance"`
+	EndingBalance           decimal.Decimal `json:"ending_balance"`
+	CalculatedEndingBalance decimal.Decimal `json:"calculated_ending_balance"`
+	TotalCredit             decimal.Decimal `json:"total_credit"`
+	TotalDebit              decimal.Decimal `json:"total_debit"`
+	Nett                    decimal.Decimal `json:"nett"`
+	TransactionStartDate    time.Time       `json:"transaction_start_date"`
+	TransactionEndDate      time.Time       `json:"transaction_end_date"`
+	TransactionCount        int             `json:"transaction_count"`
+}
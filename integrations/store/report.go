@@ -0,0 +1,235 @@
+package store
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Report breakdowns
+const (
+	GroupTag      = "tag"      // One group per transaction tag
+	GroupCategory = "category" // One group per data.category value
+)
+
+// Group names for transactions without a tag or category
+const (
+	Untagged      = "(untagged)"
+	Uncategorized = "(uncategorized)"
+)
+
+// ReportOptions selects the transactions summarized by MonthlyReport
+type ReportOptions struct {
+	Account string    // Account number, or part of the account name
+	From    time.Time // Earliest transaction date, inclusive
+	To      time.Time // Latest transaction date, inclusive
+	By      string    // Optional breakdown: GroupTag or GroupCategory
+}
+
+// MonthlyReport holds per-account and overall totals for each month
+type MonthlyReport struct {
+	By       string         `json:"by,omitempty"`
+	Accounts []AccountMonth `json:"accounts"`
+	Totals   []MonthTotals  `json:"totals"`
+}
+
+// MonthTotals are the credits and debits of one month (or one group within it)
+type MonthTotals struct {
+	Month        string          `json:"month"` // YYYY-MM
+	Group        string          `json:"group,omitempty"`
+	Transactions int             `json:"transactions"`
+	Credits      decimal.Decimal `json:"credits"`
+	Debits       decimal.Decimal `json:"debits"`
+	Nett         decimal.Decimal `json:"nett"` // Credits - debits
+	// ClosingBalance sums the closing balances of every account seen so far,
+	// including those without transactions this month; not set on groups
+	ClosingBalance *decimal.Decimal `json:"closing_balance,omitempty"`
+}
+
+// AccountMonth summarizes one account over one month
+type AccountMonth struct {
+	AccountNumber string `json:"account_number"`
+	AccountName   string `json:"account_name"`
+	Liability     bool   `json:"liability"`
+	MonthTotals
+	Groups []MonthTotals `json:"groups,omitempty"`
+}
+
+// IsLiability reports whether an account's balance is owed rather than held.
+// Credit card accounts are extracted with debit_credit = credit.
+func IsLiability(debitCredit string) bool {
+	return strings.EqualFold(debitCredit, "credit")
+}
+
+// NetBalance returns a balance from the account holder's point of view:
+// liabilities are negative, assets positive
+func NetBalance(balance decimal.Decimal, debitCredit string) decimal.Decimal {
+	if IsLiability(debitCredit) {
+		return balance.Neg()
+	}
+	return balance
+}
+
+// BuildMonthlyReport aggregates transactions into monthly credits, debits, nett and
+// closing balance per account.
+//
+// Amount signs differ between extractors, so transactions are classified by type:
+// credits are money into the account (income, or card payments), debits money out
+// (spending, or card charges). The closing balance is the balance after the month's
+// last transaction, negated for liabilities so accounts can be summed.
+func BuildMonthlyReport(ctx context.Context, s Store, opts ReportOptions) (*MonthlyReport, error) {
+	if opts.By != "" && opts.By != GroupTag && opts.By != GroupCategory {
+		return nil, fmt.Errorf("invalid breakdown %q (valid: %s, %s)", opts.By, GroupTag, GroupCategory)
+	}
+
+	// Ordered by date, account, statement and sequence, so the last transaction
+	// seen for an account in a month carries its closing balance
+	records, err := s.QueryTransactions(ctx, TransactionQuery{Account: opts.Account, From: opts.From, To: opts.To})
+	if err != nil {
+		return nil, err
+	}
+	return monthlyReport(records, opts.By), nil
+}
+
+// monthlyReport aggregates transactions ordered by date, account, statement and sequence
+func monthlyReport(records []TransactionRecord, by string) *MonthlyReport {
+	report := &MonthlyReport{By: by, Accounts: []AccountMonth{}, Totals: []MonthTotals{}}
+	accounts := make(map[string]*AccountMonth)
+	groups := make(map[string]map[string]*MonthTotals)
+	for _, r := range records {
+		month := r.Date.Format("2006-01")
+		key := r.AccountNumber + "|" + month
+
+		am, ok := accounts[key]
+		if !ok {
+			am = &AccountMonth{
+				AccountNumber: r.AccountNumber,
+				AccountName:   r.AccountName,
+				Liability:     IsLiability(r.DebitCredit),
+				MonthTotals:   MonthTotals{Month: month},
+			}
+			accounts[key] = am
+			groups[key] = make(map[string]*MonthTotals)
+		}
+		am.add(r)
+		closing := NetBalance(r.Balance, r.DebitCredit)
+		am.ClosingBalance = &closing
+
+		for _, name := range groupNames(r, by) {
+			g, ok := groups[key][name]
+			if !ok {
+				g = &MonthTotals{Month: month, Group: name}
+				groups[key][name] = g
+			}
+			g.add(r)
+		}
+	}
+
+	for key, am := range accounts {
+		for _, g := range groups[key] {
+			am.Groups = append(am.Groups, *g)
+		}
+		slices.SortFunc(am.Groups, func(a, b MonthTotals) int { return strings.Compare(a.Group, b.Group) })
+		report.Accounts = append(report.Accounts, *am)
+	}
+	slices.SortFunc(report.Accounts, func(a, b AccountMonth) int {
+		if c := strings.Compare(a.Month, b.Month); c != 0 {
+			return c
+		}
+		return strings.Compare(a.AccountNumber, b.AccountNumber)
+	})
+
+	// Accounts without transactions in a month still hold their last closing balance
+	closing := make(map[string]decimal.Decimal)
+	for _, am := range report.Accounts {
+		if n := len(report.Totals); n == 0 || report.Totals[n-1].Month != am.Month {
+			report.Totals = append(report.Totals, MonthTotals{Month: am.Month})
+		}
+		t := &report.Totals[len(report.Totals)-1]
+		t.Transactions += am.Transactions
+		t.Credits = t.Credits.Add(am.Credits)
+		t.Debits = t.Debits.Add(am.Debits)
+		t.Nett = t.Nett.Add(am.Nett)
+
+		closing[am.AccountNumber] = *am.ClosingBalance
+		sum := decimal.Zero
+		for _, balance := range closing {
+			sum = sum.Add(balance)
+		}
+		t.ClosingBalance = &sum
+	}
+	return report
+}
+
+// add counts a transaction towards the totals
+func (t *MonthTotals) add(r TransactionRecord) {
+	amount := r.Amount.Abs()
+	t.Transactions++
+	if r.Type == "credit" {
+		t.Credits = t.Credits.Add(amount)
+	} else {
+		t.Debits = t.Debits.Add(amount)
+	}
+	t.Nett = t.Credits.Sub(t.Debits)
+}
+
+// groupNames returns the breakdown groups a transaction is counted in.
+// A transaction with several tags counts towards each of them.
+func groupNames(r TransactionRecord, by string) []string {
+	switch by {
+	case GroupTag:
+		var names []string
+		for _, tag := range r.Tags {
+			if tag != "" && !slices.Contains(names, tag) {
+				names = append(names, tag)
+			}
+		}
+		if len(names) == 0 {
+			return []string{Untagged}
+		}
+		return names
+	case GroupCategory:
+		if category, ok := r.Data["category"].(string); ok && category != "" {
+			return []string{category}
+		}
+		return []string{Uncategorized}
+	}
+	return nil
+}
+
+// WriteCSV writes the report as CSV: a row per account and month, followed by its
+// breakdown groups, then one "Total" row per month
+func (r *MonthlyReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"month", "account_number", "account_name", "group", "transactions", "credits", "debits", "nett", "closing_balance"})
+
+	row := func(number, name string, t MonthTotals) {
+		closing := ""
+		if t.ClosingBalance != nil {
+			closing = t.ClosingBalance.StringFixed(2)
+		}
+		cw.Write([]string{
+			t.Month, number, name, t.Group, strconv.Itoa(t.Transactions),
+			t.Credits.StringFixed(2), t.Debits.StringFixed(2), t.Nett.StringFixed(2), closing,
+		})
+	}
+	for _, am := range r.Accounts {
+		row(am.AccountNumber, am.AccountName, am.MonthTotals)
+		for _, g := range am.Groups {
+			row(am.AccountNumber, am.AccountName, g)
+		}
+	}
+	for _, t := range r.Totals {
+		row("", "Total", t)
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
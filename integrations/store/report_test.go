@@ -0,0 +1,68 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func record(account, debitCredit string, date time.Time, typ, amount, balance string, tags ...string) TransactionRecord {
+	return TransactionRecord{
+		AccountNumber: account,
+		DebitCredit:   debitCredit,
+		Date:          date,
+		Type:          typ,
+		Amount:        decimal.RequireFromString(amount),
+		Balance:       decimal.RequireFromString(balance),
+		Tags:          tags,
+	}
+}
+
+func TestMonthlyReport(t *testing.T) {
+	feb := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	records := []TransactionRecord{
+		// Savings: extractor stores debits as negative amounts
+		record("SAV", "debit", day(5), "credit", "5000.00", "6000.00", "salary"),
+		record("SAV", "debit", day(6), "debit", "-200.00", "5800.00", "food", "food"),
+		// Credit card: charges are positive debits, payments negative credits
+		record("CC", "credit", day(7), "debit", "150.00", "150.00", "food", "travel"),
+		record("CC", "credit", day(20), "credit", "-100.00", "50.00"),
+		record("SAV", "debit", feb, "debit", "-50.00", "5750.00"),
+	}
+
+	report := monthlyReport(records, GroupTag)
+	if len(report.Accounts) != 3 || len(report.Totals) != 2 {
+		t.Fatalf("got %d account rows and %d totals", len(report.Accounts), len(report.Totals))
+	}
+
+	cc := report.Accounts[0]
+	if cc.AccountNumber != "CC" || !cc.Liability || cc.Month != "2024-01" {
+		t.Fatalf("first row = %+v", cc)
+	}
+	if !cc.Credits.Equal(decimal.NewFromInt(100)) || !cc.Debits.Equal(decimal.NewFromInt(150)) ||
+		!cc.Nett.Equal(decimal.NewFromInt(-50)) || !cc.ClosingBalance.Equal(decimal.NewFromInt(-50)) {
+		t.Errorf("credit card totals = %+v, closing %s", cc.MonthTotals, cc.ClosingBalance)
+	}
+
+	sav := report.Accounts[1]
+	if !sav.Nett.Equal(decimal.NewFromInt(4800)) || !sav.ClosingBalance.Equal(decimal.NewFromInt(5800)) {
+		t.Errorf("savings totals = %+v, closing %s", sav.MonthTotals, sav.ClosingBalance)
+	}
+	// Duplicate tags count once
+	if len(sav.Groups) != 2 || sav.Groups[0].Group != "food" || sav.Groups[0].Transactions != 1 {
+		t.Errorf("savings groups = %+v", sav.Groups)
+	}
+	if len(cc.Groups) != 3 || cc.Groups[0].Group != Untagged {
+		t.Errorf("credit card groups = %+v", cc.Groups)
+	}
+
+	jan := report.Totals[0]
+	if jan.Transactions != 4 || !jan.ClosingBalance.Equal(decimal.NewFromInt(5750)) {
+		t.Errorf("january totals = %+v, closing %s", jan, jan.ClosingBalance)
+	}
+	// The card has no February transactions but still owes 50
+	if f := report.Totals[1]; f.Month != "2024-02" || !f.Debits.Equal(decimal.NewFromInt(50)) || !f.ClosingBalance.Equal(decimal.NewFromInt(5700)) {
+		t.Errorf("february totals = %+v, closing %s", f, f.ClosingBalance)
+	}
+}
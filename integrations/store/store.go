@@ -0,0 +1,187 @@
+// Package store defines the storage interface used to import statements and
+// the backend-independent import logic built on top of it.
+//
+// Backends (integrations/postgres, integrations/sqlite) implement Store with the
+// same deduplication semantics: one statement per account and statement date,
+// and at most one transaction per (statement, sequence) and (statement, reference).
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/extractor/common"
+	"github.com/shopspring/decimal"
+)
+
+// Store persists accounts, statements, transactions and import bookkeeping
+type Store interface {
+	// InTx runs fn inside a single transaction. All calls made through the Store
+	// passed to fn are part of it; it commits when fn returns nil and rolls back otherwise.
+	// Calling InTx on a Store that is already in a transaction joins it.
+	InTx(ctx context.Context, fn func(tx Store) error) error
+
+	// Accounts
+	GetOrCreateAccount(ctx context.Context, account common.Account) (string, error)
+	FindAccount(ctx context.Context, accountNumber string) (*StoredAccount, error)
+	// GetAccount returns the account with the given id and its latest statement; nil if there is none
+	GetAccount(ctx context.Context, id string) (*AccountRecord, error)
+	// ListAccounts returns the accounts matching q with their latest statement, ordered by account number
+	ListAccounts(ctx context.Context, q AccountQuery) ([]AccountRecord, error)
+	// EditAccount applies edit to the account with the given id; false if there is none
+	EditAccount(ctx context.Context, id string, edit AccountEdit) (bool, error)
+
+	// Statements (natural key: account id + statement date)
+	StatementExists(ctx context.Context, accountID string, statementDate time.Time) (bool, string, error)
+	CreateStatement(ctx context.Context, accountID string, stmt common.Statement) (string, error)
+	UpdateStatement(ctx context.Context, statementID string, stmt common.Statement) (bool, error)
+	DeleteStatement(ctx context.Context, statementID string) error
+
+	// Transactions
+	// CreateTransactions returns the number of rows inserted. When idempotent is true,
+	// rows whose non-empty reference is already stored are skipped instead of failing;
+	// a clash on sequence always fails.
+	CreateTransactions(ctx context.Context, statementID string, transactions []common.Transaction, idempotent bool) (int, error)
+	TransactionKeys(ctx context.Context, statementID string) (sequences map[int]bool, references map[string]bool, err error)
+	StoredTransactions(ctx context.Context, statementID string) ([]StoredTransaction, error)
+	// UpdateTransactions rewrites the extracted fields of existing transactions,
+	// leaving tags and data untouched. Sequences may be permuted freely.
+	UpdateTransactions(ctx context.Context, statementID string, updates []TransactionUpdate) error
+	DeleteTransactions(ctx context.Context, ids []string) error
+	// EditTransaction applies edit to the user-owned fields of the transaction with
+	// the given id; false if there is none
+	EditTransaction(ctx context.Context, id string, edit TransactionEdit) (bool, error)
+
+	// Queries
+	// QueryTransactions returns the transactions matching q, joined with their account and statement
+	QueryTransactions(ctx context.Context, q TransactionQuery) ([]TransactionRecord, error)
+	// ListStatements returns statements ordered by account and date; account optionally
+	// matches an account number, or part of the account name
+	ListStatements(ctx context.Context, account string) ([]StatementRecord, error)
+	// QueryStatements returns the statements of one account matching q
+	QueryStatements(ctx context.Context, q StatementQuery) ([]StatementRecord, error)
+
+	// Import ledger
+	GetFileRecord(ctx context.Context, sha string) (*FileRecord, error)
+	RecordFile(ctx context.Context, rec FileRecord) error
+	// RecordDuplicate records that the file at path has the same content as the
+	// ledger entry for sha; it is listed with OutcomeDuplicate
+	RecordDuplicate(ctx context.Context, path, sha string) error
+	// ListFileRecords returns ledger entries and duplicates, most recent first
+	ListFileRecords(ctx context.Context, pathPrefix string) ([]FileRecord, error)
+
+	// API keys
+	CreateAPIKey(ctx context.Context, key APIKey) (string, error)
+	// FindAPIKey looks a key up by hash, revoked or not. Returns nil if it does not exist.
+	FindAPIKey(ctx context.Context, hash string) (*APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]APIKey, error)
+	// RevokeAPIKey revokes the key with the given id or prefix; false if there is none
+	RevokeAPIKey(ctx context.Context, ref string) (bool, error)
+
+	// Schema
+	Migrator
+	TableExists(ctx context.Context, name string) (bool, error)
+
+	Close()
+}
+
+// Migrator manages versioned schema migrations
+type Migrator interface {
+	// EnsureSchema applies pending migrations only when the schema is behind
+	EnsureSchema(ctx context.Context) error
+	SchemaVersion(ctx context.Context) (current int, latest int, err error)
+	MigrationStatus(ctx context.Context) ([]MigrationStatus, error)
+	MigrateUp(ctx context.Context, steps int) ([]Migration, error)
+	MigrateDown(ctx context.Context, steps int) ([]Migration, error)
+}
+
+// StoredAccount is an account row as stored in the database
+type StoredAccount struct {
+	ID            string
+	AccountNumber string
+	AccountName   string
+	AccountType   string
+	DebitCredit   string
+	Reconciliable bool
+}
+
+// StoredTransaction holds the extracted columns of a stored transaction.
+// User-owned columns (tags, data) are deliberately not included.
+type StoredTransaction struct {
+	ID           string
+	Sequence     int
+	Date         time.Time
+	Descriptions []string
+	Type         string
+	Amount       decimal.Decimal
+	Balance      decimal.Decimal
+	Reference    string
+}
+
+// TransactionUpdate replaces the extracted fields of a stored transaction
+type TransactionUpdate struct {
+	ID          string
+	Transaction common.Transaction
+}
+
+// AccountEdit changes the user-editable fields of an account; nil fields are left as they are
+type AccountEdit struct {
+	AccountType *string
+}
+
+// TransactionEdit changes the user-owned fields of a transaction; nil fields are left as they are
+type TransactionEdit struct {
+	Tags *[]string
+	Data map[string]any // Replaces the whole object
+}
+
+// File outcomes recorded in the import_files ledger
+const (
+	OutcomeImported  = "imported"  // All statements stored
+	OutcomeSkipped   = "skipped"   // All statements already existed
+	OutcomePartial   = "partial"   // Some statements stored, some failed
+	OutcomeFailed    = "failed"    // Nothing stored
+	OutcomeDuplicate = "duplicate" // Same content as the file at DuplicateOf, not extracted again
+)
+
+// FileRecord is a row of the import_files ledger
+type FileRecord struct {
+	SHA256           string
+	Path             string
+	Size             int64
+	DetectedType     string
+	ExtractorVersion string
+	StatementIDs     []string
+	Outcome          string
+	Error            string
+	DuplicateOf      string // Path of the imported file with the same content (OutcomeDuplicate only)
+	ImportedAt       time.Time
+}
+
+// IsSettled reports whether the file needs no further work on re-import by the
+// given extractor version. Failed and partial files are retried, and so are files
+// extracted by another version, which may find more in them.
+func (r FileRecord) IsSettled(version string) bool {
+	return (r.Outcome == OutcomeImported || r.Outcome == OutcomeSkipped) && r.ExtractorVersion == version
+}
+
+// HashContent returns the hex-encoded SHA-256 of file contents
+func HashContent(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+var spaceRegex = regexp.MustCompile(`\s+`)
+
+// NormalizeDescription joins the descriptions array, collapses whitespace, and uppercases
+// Result: "TRANSFER TO A/C AQLAN HADI BIN NOR * MAE CASA"
+func NormalizeDescription(descriptions []string) string {
+	joined := strings.Join(descriptions, " ")
+	// Collapse multiple spaces into single space
+	normalized := spaceRegex.ReplaceAllString(joined, " ")
+	return strings.ToUpper(strings.TrimSpace(normalized))
+}
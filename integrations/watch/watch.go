@@ -0,0 +1,265 @@
+// Package watch monitors a directory for new statement files and imports them automatically.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/extractor"
+	"github.com/fsnotify/fsnotify"
+)
+
+// ImportFunc imports a single file, mirroring postgres.DB.ImportFile
+type ImportFunc func(ctx context.Context, filePath string) (processed int, skipped int, failed int, errors []string)
+
+// Config holds the watcher configuration
+type Config struct {
+	Dir             string        // Directory to watch (top level only)
+	SettleDelay     time.Duration // How long a file must stay unchanged before it is imported
+	MoveProcessed   bool          // Move files into done/ or failed/ after importing
+	ProcessExisting bool          // Import files already present when the watcher starts
+	LogPrefix       string
+}
+
+// DefaultConfig returns the default watcher configuration
+func DefaultConfig() Config {
+	return Config{
+		SettleDelay: 2 * time.Second,
+		LogPrefix:   "WATCH: ",
+	}
+}
+
+const (
+	doneDir   = "done"
+	failedDir = "failed"
+)
+
+// Watcher imports statement files as they appear in a directory
+type Watcher struct {
+	config   Config
+	importFn ImportFunc
+
+	mu      sync.Mutex
+	pending map[string]*pendingFile
+	queue   chan string
+	done    chan struct{}
+}
+
+// pendingFile tracks a file that is still being written
+type pendingFile struct {
+	timer *time.Timer
+	size  int64
+	mod   time.Time
+}
+
+// New creates a new watcher with the given configuration
+func New(cfg Config, importFn ImportFunc) *Watcher {
+	return &Watcher{
+		config:   cfg,
+		importFn: importFn,
+		pending:  make(map[string]*pendingFile),
+		queue:    make(chan string, 64),
+		done:     make(chan struct{}),
+	}
+}
+
+// Run watches the directory until ctx is cancelled (blocking).
+// Files are imported one at a time in the order they settle.
+func (w *Watcher) Run(ctx context.Context) error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	defer fsw.Close()
+
+	if err := fsw.Add(w.config.Dir); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", w.config.Dir, err)
+	}
+	log.Printf("%sWatching %s", w.config.LogPrefix, w.config.Dir)
+
+	if w.config.ProcessExisting {
+		entries, err := os.ReadDir(w.config.Dir)
+		if err != nil {
+			return fmt.Errorf("failed to read directory: %w", err)
+		}
+		for _, e := range entries {
+			if !e.IsDir() {
+				w.schedule(filepath.Join(w.config.Dir, e.Name()))
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.worker(ctx)
+	}()
+	defer close(w.done)
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.stopTimers()
+			wg.Wait()
+			return nil
+		case event, ok := <-fsw.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Create) || event.Has(fsnotify.Write) {
+				w.schedule(event.Name)
+			}
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			log.Printf("%sWatcher error: %v", w.config.LogPrefix, err)
+		}
+	}
+}
+
+// schedule (re)starts the settle timer for a file
+// Every new event for the same file pushes the import further out.
+func (w *Watcher) schedule(path string) {
+	if !extractor.IsStatementFile(path) || strings.HasPrefix(filepath.Base(path), ".") {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if p, ok := w.pending[path]; ok {
+		p.timer.Reset(w.config.SettleDelay)
+		return
+	}
+
+	p := &pendingFile{size: -1}
+	p.timer = time.AfterFunc(w.config.SettleDelay, func() { w.checkSettled(path) })
+	w.pending[path] = p
+}
+
+// checkSettled queues the file once its size and modification time stop changing
+func (w *Watcher) checkSettled(path string) {
+	info, err := os.Stat(path)
+
+	w.mu.Lock()
+	p, ok := w.pending[path]
+	if !ok {
+		w.mu.Unlock()
+		return
+	}
+	if err != nil {
+		// File vanished before it settled (e.g. temporary download file renamed)
+		delete(w.pending, path)
+		w.mu.Unlock()
+		return
+	}
+	if info.Size() != p.size || !info.ModTime().Equal(p.mod) || info.Size() == 0 {
+		p.size = info.Size()
+		p.mod = info.ModTime()
+		p.timer.Reset(w.config.SettleDelay)
+		w.mu.Unlock()
+		return
+	}
+	delete(w.pending, path)
+	w.mu.Unlock()
+
+	select {
+	case w.queue <- path:
+	case <-w.done:
+	}
+}
+
+// stopTimers cancels all pending settle timers
+func (w *Watcher) stopTimers() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for path, p := range w.pending {
+		p.timer.Stop()
+		delete(w.pending, path)
+	}
+}
+
+// worker imports queued files until ctx is cancelled.
+// A file that is already being imported is allowed to finish on shutdown.
+func (w *Watcher) worker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case path := <-w.queue:
+			w.process(context.WithoutCancel(ctx), path)
+		}
+	}
+}
+
+// process imports a single settled file and files it into done/ or failed/
+func (w *Watcher) process(ctx context.Context, path string) {
+	name := filepath.Base(path)
+	processed, skipped, failed, errors := w.importFn(ctx, path)
+
+	if failed > 0 {
+		log.Printf("%sFAIL %s (%d processed, %d skipped, %d failed)", w.config.LogPrefix, name, processed, skipped, failed)
+		for _, e := range errors {
+			log.Printf("%s  - %s", w.config.LogPrefix, e)
+		}
+		if err := w.writeReason(name, errors); err != nil {
+			log.Printf("%sCould not write reason file for %s: %v", w.config.LogPrefix, name, err)
+		}
+		w.move(path, failedDir)
+		return
+	}
+
+	log.Printf("%sOK   %s (%d processed, %d skipped)", w.config.LogPrefix, name, processed, skipped)
+	w.move(path, doneDir)
+}
+
+// writeReason records why a file failed in failed/<name>.reason.txt
+func (w *Watcher) writeReason(name string, errors []string) error {
+	dir := filepath.Join(w.config.Dir, failedDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "file: %s\n", name)
+	fmt.Fprintf(&b, "time: %s\n", time.Now().Format(time.RFC3339))
+	b.WriteString("errors:\n")
+	for _, e := range errors {
+		fmt.Fprintf(&b, "  - %s\n", e)
+	}
+
+	return os.WriteFile(filepath.Join(dir, name+".reason.txt"), []byte(b.String()), 0o644)
+}
+
+// move relocates a file into the given subfolder when MoveProcessed is enabled.
+// Existing files with the same name are never overwritten.
+func (w *Watcher) move(path string, subdir string) {
+	if !w.config.MoveProcessed {
+		return
+	}
+
+	dir := filepath.Join(w.config.Dir, subdir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Printf("%sCould not create %s: %v", w.config.LogPrefix, dir, err)
+		return
+	}
+
+	name := filepath.Base(path)
+	target := filepath.Join(dir, name)
+	if _, err := os.Stat(target); err == nil {
+		ext := filepath.Ext(name)
+		target = filepath.Join(dir, fmt.Sprintf("%s-%s%s", strings.TrimSuffix(name, ext), time.Now().Format("20060102150405"), ext))
+	}
+
+	if err := os.Rename(path, target); err != nil {
+		log.Printf("%sCould not move %s to %s: %v", w.config.LogPrefix, name, subdir, err)
+	}
+}
@@ -0,0 +1,169 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder is a fake ImportFunc that records the files it was given
+type recorder struct {
+	mu    sync.Mutex
+	files []string
+	fail  bool
+}
+
+func (r *recorder) importFile(ctx context.Context, filePath string) (int, int, int, []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.files = append(r.files, filepath.Base(filePath))
+	if r.fail {
+		return 0, 0, 1, []string{filepath.Base(filePath) + ": no statements extracted"}
+	}
+	return 1, 0, 0, nil
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.files)
+}
+
+// startWatcher runs a watcher on dir and returns a stop function
+func startWatcher(t *testing.T, cfg Config, r *recorder) func() {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	w := New(cfg, r.importFile)
+
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+
+	// Give fsnotify a moment to register the directory
+	time.Sleep(50 * time.Millisecond)
+
+	return func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Unexpected watcher error: %v", err)
+		}
+	}
+}
+
+// waitFor polls cond until it is true or the deadline passes
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Timed out waiting for condition")
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func testConfig(dir string) Config {
+	cfg := DefaultConfig()
+	cfg.Dir = dir
+	cfg.SettleDelay = 50 * time.Millisecond
+	cfg.MoveProcessed = true
+	return cfg
+}
+
+func TestWatcher_ImportsAndMovesToDone(t *testing.T) {
+	dir := t.TempDir()
+	r := &recorder{}
+	stop := startWatcher(t, testConfig(dir), r)
+	defer stop()
+
+	os.WriteFile(filepath.Join(dir, "statement.pdf"), []byte("pdf"), 0o644)
+
+	waitFor(t, func() bool { return exists(filepath.Join(dir, "done", "statement.pdf")) })
+
+	if r.count() != 1 {
+		t.Errorf("Expected 1 import, got %d", r.count())
+	}
+}
+
+func TestWatcher_FailureWritesReason(t *testing.T) {
+	dir := t.TempDir()
+	r := &recorder{fail: true}
+	stop := startWatcher(t, testConfig(dir), r)
+	defer stop()
+
+	os.WriteFile(filepath.Join(dir, "bad.csv"), []byte("csv"), 0o644)
+
+	reason := filepath.Join(dir, "failed", "bad.csv.reason.txt")
+	waitFor(t, func() bool { return exists(filepath.Join(dir, "failed", "bad.csv")) && exists(reason) })
+
+	content, _ := os.ReadFile(reason)
+	if !strings.Contains(string(content), "no statements extracted") {
+		t.Errorf("Expected reason file to contain the import error, got:\n%s", content)
+	}
+}
+
+func TestWatcher_IgnoresUnsupportedFiles(t *testing.T) {
+	dir := t.TempDir()
+	r := &recorder{}
+	stop := startWatcher(t, testConfig(dir), r)
+
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("text"), 0o644)
+	os.WriteFile(filepath.Join(dir, ".statement.pdf.part"), []byte("partial"), 0o644)
+	time.Sleep(300 * time.Millisecond)
+	stop()
+
+	if r.count() != 0 {
+		t.Errorf("Expected no imports, got %v", r.files)
+	}
+}
+
+func TestWatcher_WaitsForWritesToFinish(t *testing.T) {
+	dir := t.TempDir()
+	cfg := testConfig(dir)
+	cfg.SettleDelay = 200 * time.Millisecond
+	r := &recorder{}
+	stop := startWatcher(t, cfg, r)
+	defer stop()
+
+	// Keep appending for longer than the settle delay
+	path := filepath.Join(dir, "slow.pdf")
+	f, _ := os.Create(path)
+	for i := 0; i < 10; i++ {
+		f.Write([]byte("chunk"))
+		f.Sync()
+		time.Sleep(40 * time.Millisecond)
+		if r.count() != 0 {
+			t.Fatal("Expected file not to be imported while still being written")
+		}
+	}
+	f.Close()
+
+	waitFor(t, func() bool { return r.count() == 1 })
+}
+
+func TestWatcher_ProcessExisting(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "old.pdf"), []byte("pdf"), 0o644)
+
+	cfg := testConfig(dir)
+	cfg.ProcessExisting = true
+	cfg.MoveProcessed = false
+	r := &recorder{}
+	stop := startWatcher(t, cfg, r)
+	defer stop()
+
+	waitFor(t, func() bool { return r.count() == 1 })
+
+	if !exists(filepath.Join(dir, "old.pdf")) {
+		t.Error("Expected file to stay in place when MoveProcessed is false")
+	}
+}
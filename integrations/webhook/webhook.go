@@ -0,0 +1,438 @@
+// Package webhook delivers kwgn events to HTTP endpoints as signed JSON POST requests.
+//
+// Every request carries an HMAC-SHA256 signature of its timestamp and body. Failed
+// deliveries are retried with exponential backoff; events that still cannot be
+// delivered are logged and appended to a dead-letter file.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/extractor"
+	"github.com/aqlanhadi/kwgn/extractor/common"
+	"github.com/aqlanhadi/kwgn/integrations/store"
+	"github.com/shopspring/decimal"
+)
+
+// Event types
+const (
+	EventStatementExtracted = "statement.extracted" // A statement was read from a file
+	EventStatementImported  = "statement.imported"  // A statement was stored (or merged) in the database
+	EventBalanceMismatch    = "balance.mismatch"    // A statement's transactions do not add up to its ending balance
+	EventImportFailed       = "import.failed"       // A file or statement could not be imported
+)
+
+// Events lists every event type
+var Events = []string{EventStatementExtracted, EventStatementImported, EventBalanceMismatch, EventImportFailed}
+
+// Request headers
+const (
+	HeaderEvent     = "X-Kwgn-Event"
+	HeaderDelivery  = "X-Kwgn-Delivery"
+	HeaderTimestamp = "X-Kwgn-Timestamp"
+	HeaderSignature = "X-Kwgn-Signature"
+)
+
+// Endpoint is a URL receiving events
+type Endpoint struct {
+	URL     string
+	Secret  string            // Key for the X-Kwgn-Signature HMAC
+	Events  []string          // Event types to send; empty sends all
+	Headers map[string]string // Extra request headers, e.g. for authentication
+}
+
+// wants reports whether the endpoint subscribes to an event type
+func (e Endpoint) wants(eventType string) bool {
+	return len(e.Events) == 0 || slices.Contains(e.Events, eventType)
+}
+
+// Config holds the webhook configuration
+type Config struct {
+	Endpoints      []Endpoint
+	MaxAttempts    int           // Deliveries tried per event and endpoint
+	InitialBackoff time.Duration // Wait before the first retry, doubled after each attempt
+	MaxBackoff     time.Duration
+	Timeout        time.Duration // Per request
+	QueueSize      int           // Events waiting per endpoint beyond this are dead-lettered
+	DeadLetterFile string        // Undelivered events are appended here as JSON lines; empty only logs them
+	LogPrefix      string
+}
+
+// DefaultConfig returns the default webhook configuration
+func DefaultConfig() Config {
+	return Config{
+		MaxAttempts:    5,
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+		Timeout:        10 * time.Second,
+		QueueSize:      256,
+		LogPrefix:      "WEBHOOK: ",
+	}
+}
+
+// Validate checks the endpoints and delivery settings
+func (c Config) Validate() error {
+	for i, e := range c.Endpoints {
+		u, err := url.Parse(e.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("endpoints[%d]: url must be an http(s) URL, got %q", i, e.URL)
+		}
+		if e.Secret == "" {
+			return fmt.Errorf("endpoints[%d] (%s): secret is required to sign requests", i, e.URL)
+		}
+		for _, ev := range e.Events {
+			if !slices.Contains(Events, ev) {
+				return fmt.Errorf("endpoints[%d] (%s): unknown event %q (valid: %v)", i, e.URL, ev, Events)
+			}
+		}
+	}
+	if c.MaxAttempts < 1 {
+		return fmt.Errorf("max_attempts must be at least 1")
+	}
+	if c.InitialBackoff < 0 || c.MaxBackoff < c.InitialBackoff {
+		return fmt.Errorf("backoff must satisfy 0 <= initial_backoff <= max_backoff")
+	}
+	if c.QueueSize < 1 {
+		return fmt.Errorf("queue_size must be at least 1")
+	}
+	return nil
+}
+
+// Event is the JSON body of a webhook request
+type Event struct {
+	ID        string    `json:"id"` // Also sent as X-Kwgn-Delivery; the same across retries
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      Statement `json:"data"`
+}
+
+// Statement is the data of every event. Fields that do not apply to an event
+// are omitted, including the summary when a file failed before any statement was read.
+type Statement struct {
+	File          string `json:"file"`
+	StatementType string `json:"statement_type,omitempty"`
+	StatementID   string `json:"statement_id,omitempty"`
+	*Summary
+	TransactionsInserted *int   `json:"transactions_inserted,omitempty"`
+	Merged               bool   `json:"merged,omitempty"`
+	Error                string `json:"error,omitempty"`
+}
+
+// Summary describes a statement without its transactions
+type Summary struct {
+	Account                 common.Account  `json:"account"`
+	StatementDate           *time.Time      `json:"statement_date,omitempty"`
+	StartingBalance         decimal.Decimal `json:"starting_balance"`
+	EndingBalance           decimal.Decimal `json:"ending_balance"`
+	CalculatedEndingBalance decimal.Decimal `json:"calculated_ending_balance"`
+	TotalCredit             decimal.Decimal `json:"total_credit"`
+	TotalDebit              decimal.Decimal `json:"total_debit"`
+	Transactions            int             `json:"transactions"`
+}
+
+// summarize returns the event data of a statement read from file
+func summarize(file string, stmt *common.Statement) Statement {
+	if stmt == nil {
+		return Statement{File: file}
+	}
+	return Statement{File: file, Summary: &Summary{
+		Account:                 stmt.Account,
+		StatementDate:           stmt.StatementDate,
+		StartingBalance:         stmt.StartingBalance,
+		EndingBalance:           stmt.EndingBalance,
+		CalculatedEndingBalance: stmt.CalculatedEndingBalance,
+		TotalCredit:             stmt.TotalCredit,
+		TotalDebit:              stmt.TotalDebit,
+		Transactions:            len(stmt.Transactions),
+	}}
+}
+
+// Sign returns the X-Kwgn-Signature value of a request: the hex HMAC-SHA256 of
+// "<timestamp>.<body>", prefixed with "sha256="
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is valid for the timestamp and body.
+// Receivers should also reject timestamps too far from their own clock.
+func Verify(secret, signature string, timestamp int64, body []byte) bool {
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body)))
+}
+
+// Notifier sends events to the configured endpoints in the background.
+// A nil *Notifier ignores every event.
+type Notifier struct {
+	config Config
+	client *http.Client
+
+	mu     sync.RWMutex // Guards closed against sends on closed queues
+	closed bool
+	queues []chan delivery
+	wg     sync.WaitGroup
+
+	ctx    context.Context // Cancelled when Close gives up waiting, ending retries
+	cancel context.CancelFunc
+	deadMu sync.Mutex
+}
+
+// delivery is an event queued for one endpoint
+type delivery struct {
+	endpoint Endpoint
+	event    Event
+	body     []byte
+}
+
+// New validates cfg and starts one delivery worker per endpoint
+func New(cfg Config) (*Notifier, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	n := &Notifier{
+		config: cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+	n.ctx, n.cancel = context.WithCancel(context.Background())
+
+	// Endpoints have their own queue, so a slow one does not hold up the others
+	for range cfg.Endpoints {
+		q := make(chan delivery, cfg.QueueSize)
+		n.queues = append(n.queues, q)
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+			for d := range q {
+				n.deliver(d)
+			}
+		}()
+	}
+	return n, nil
+}
+
+// Close stops accepting events and waits for queued ones to be delivered. When ctx
+// ends first, retries stop and the remaining events are dead-lettered.
+func (n *Notifier) Close(ctx context.Context) {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	if !n.closed {
+		n.closed = true
+		for _, q := range n.queues {
+			close(q)
+		}
+	}
+	n.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		n.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		n.cancel()
+		<-done
+	}
+	n.cancel()
+}
+
+// Publish queues an event for every endpoint subscribed to its type
+func (n *Notifier) Publish(eventType string, data Statement) {
+	if n == nil {
+		return
+	}
+	event := Event{ID: newEventID(), Type: eventType, CreatedAt: time.Now().UTC(), Data: data}
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("%sfailed to encode %s event: %v", n.config.LogPrefix, eventType, err)
+		return
+	}
+
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	for i, endpoint := range n.config.Endpoints {
+		if !endpoint.wants(eventType) {
+			continue
+		}
+		d := delivery{endpoint: endpoint, event: event, body: body}
+		if n.closed {
+			n.deadLetter(d, 0, fmt.Errorf("notifier closed"))
+			continue
+		}
+		select {
+		case n.queues[i] <- d:
+		default:
+			n.deadLetter(d, 0, fmt.Errorf("queue full"))
+		}
+	}
+}
+
+// ObserveExtraction publishes statement.extracted, and balance.mismatch for statements
+// that do not add up; pass it to extractor.OnExtract
+func (n *Notifier) ObserveExtraction(e extractor.Extraction) {
+	if n == nil {
+		return
+	}
+	for i := range e.Results {
+		stmt := &e.Results[i]
+		data := summarize(e.Filename, stmt)
+		data.StatementType = e.StatementType
+		n.Publish(EventStatementExtracted, data)
+		if extractor.BalanceMismatch(*stmt) {
+			n.Publish(EventBalanceMismatch, data)
+		}
+	}
+}
+
+// ObserveImport publishes statement.imported and import.failed; pass it to
+// store.ImportOptions.OnEvent. Statements skipped as already stored are not reported.
+func (n *Notifier) ObserveImport(ev store.ImportEvent) {
+	if n == nil {
+		return
+	}
+	data := summarize(ev.File, ev.Statement)
+	switch ev.Outcome {
+	case store.OutcomeImported:
+		inserted := ev.TransactionsInserted
+		data.StatementID = ev.StatementID
+		data.TransactionsInserted = &inserted
+		data.Merged = ev.Merged
+		n.Publish(EventStatementImported, data)
+	case store.OutcomeFailed:
+		data.Error = ev.Error
+		n.Publish(EventImportFailed, data)
+	}
+}
+
+// deliver sends d until it succeeds, fails permanently or runs out of attempts
+func (n *Notifier) deliver(d delivery) {
+	if n.ctx.Err() != nil {
+		n.deadLetter(d, 0, fmt.Errorf("not sent before shutdown"))
+		return
+	}
+
+	backoff := n.config.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		retry, err := n.send(d)
+		if err == nil {
+			return
+		}
+		if !retry || attempt == n.config.MaxAttempts {
+			n.deadLetter(d, attempt, err)
+			return
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-n.ctx.Done():
+			n.deadLetter(d, attempt, fmt.Errorf("%w (gave up on shutdown)", err))
+			return
+		}
+		backoff = min(2*backoff, n.config.MaxBackoff)
+	}
+}
+
+// send makes one delivery attempt, reporting whether a failure is worth retrying.
+// Network errors, timeouts, 408, 429 and 5xx responses are retried.
+func (n *Notifier) send(d delivery) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(n.ctx, http.MethodPost, d.endpoint.URL, bytes.NewReader(d.body))
+	if err != nil {
+		return false, err
+	}
+	for k, v := range d.endpoint.Headers {
+		req.Header.Set(k, v)
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "kwgn-webhook/"+extractor.Version)
+	req.Header.Set(HeaderEvent, d.event.Type)
+	req.Header.Set(HeaderDelivery, d.event.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(d.endpoint.Secret, timestamp, d.body))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // Lets the connection be reused
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		return true, fmt.Errorf("status %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("status %d", resp.StatusCode)
+	}
+}
+
+// DeadLetter is a line of the dead-letter file
+type DeadLetter struct {
+	FailedAt time.Time `json:"failed_at"`
+	URL      string    `json:"url"`
+	Attempts int       `json:"attempts"`
+	Error    string    `json:"error"`
+	Event    Event     `json:"event"`
+}
+
+// deadLetter logs an undelivered event and appends it to the dead-letter file
+func (n *Notifier) deadLetter(d delivery, attempts int, cause error) {
+	log.Printf("%sgave up delivering %s %s to %s after %d attempt(s): %v",
+		n.config.LogPrefix, d.event.Type, d.event.ID, d.endpoint.URL, attempts, cause)
+	if n.config.DeadLetterFile == "" {
+		return
+	}
+
+	line, err := json.Marshal(DeadLetter{
+		FailedAt: time.Now().UTC(),
+		URL:      d.endpoint.URL,
+		Attempts: attempts,
+		Error:    cause.Error(),
+		Event:    d.event,
+	})
+	if err != nil {
+		log.Printf("%sfailed to encode dead letter: %v", n.config.LogPrefix, err)
+		return
+	}
+
+	n.deadMu.Lock()
+	defer n.deadMu.Unlock()
+	f, err := os.OpenFile(n.config.DeadLetterFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		log.Printf("%sfailed to open dead-letter file: %v", n.config.LogPrefix, err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		log.Printf("%sfailed to write dead-letter file: %v", n.config.LogPrefix, err)
+	}
+}
+
+// newEventID returns a random 128-bit hex id
+func newEventID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,316 @@
+package webhook
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aqlanhadi/kwgn/extractor"
+	"github.com/aqlanhadi/kwgn/extractor/common"
+	"github.com/aqlanhadi/kwgn/integrations/store"
+	"github.com/shopspring/decimal"
+)
+
+// receiver is a webhook endpoint answering with a scripted sequence of statuses
+// (200 once they run out) and recording the requests it accepted
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	calls    int
+	events   []Event
+	headers  []http.Header
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls++
+	if len(r.statuses) > 0 {
+		status := r.statuses[0]
+		r.statuses = r.statuses[1:]
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+	}
+	var event Event
+	json.Unmarshal(body, &event)
+	r.events = append(r.events, event)
+	r.headers = append(r.headers, req.Header.Clone())
+	r.bodies = append(r.bodies, body)
+}
+
+// testConfig returns a config with fast retries sending to url
+func testConfig(t *testing.T, urls ...string) Config {
+	cfg := DefaultConfig()
+	cfg.InitialBackoff = time.Millisecond
+	cfg.MaxBackoff = 5 * time.Millisecond
+	cfg.MaxAttempts = 3
+	cfg.DeadLetterFile = filepath.Join(t.TempDir(), "dead.jsonl")
+	for _, u := range urls {
+		cfg.Endpoints = append(cfg.Endpoints, Endpoint{URL: u, Secret: "s3cret"})
+	}
+	return cfg
+}
+
+// closeNotifier waits for every queued event to be delivered or dead-lettered
+func closeNotifier(t *testing.T, n *Notifier) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	n.Close(ctx)
+}
+
+// readDeadLetters returns the events in the dead-letter file
+func readDeadLetters(t *testing.T, path string) []DeadLetter {
+	t.Helper()
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var letters []DeadLetter
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var l DeadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &l); err != nil {
+			t.Fatalf("Invalid dead letter %q: %v", scanner.Text(), err)
+		}
+		letters = append(letters, l)
+	}
+	return letters
+}
+
+func testStatement() common.Statement {
+	date := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	return common.Statement{
+		StatementDate:           &date,
+		Account:                 common.Account{AccountNumber: "1234", AccountType: "SAVINGS"},
+		StartingBalance:         decimal.NewFromInt(100),
+		EndingBalance:           decimal.NewFromInt(150),
+		CalculatedEndingBalance: decimal.NewFromInt(140),
+		Transactions:            []common.Transaction{{Amount: decimal.NewFromInt(40)}},
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"type":"statement.imported"}`)
+	sig := Sign("key", 1700000000, body)
+	if !Verify("key", sig, 1700000000, body) {
+		t.Fatal("Expected the signature to verify")
+	}
+	for name, ok := range map[string]bool{
+		"wrong key":       Verify("other", sig, 1700000000, body),
+		"wrong timestamp": Verify("key", sig, 1700000001, body),
+		"wrong body":      Verify("key", sig, 1700000000, []byte(`{}`)),
+	} {
+		if ok {
+			t.Errorf("%s: expected the signature to be rejected", name)
+		}
+	}
+}
+
+func TestNotifier_DeliversSignedEvents(t *testing.T) {
+	r := &receiver{}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	cfg := testConfig(t, server.URL)
+	cfg.Endpoints[0].Headers = map[string]string{"Authorization": "Bearer abc"}
+	n, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stmt := testStatement()
+	n.ObserveExtraction(extractor.Extraction{Filename: "jan.pdf", StatementType: "MAYBANK_CASA_AND_MAE", Results: []common.Statement{stmt}})
+	n.ObserveImport(store.ImportEvent{File: "jan.pdf", Outcome: store.OutcomeImported, Statement: &stmt, StatementID: "st-1"})
+	n.ObserveImport(store.ImportEvent{File: "jan.pdf", Outcome: store.OutcomeSkipped, Statement: &stmt})
+	n.ObserveImport(store.ImportEvent{File: "feb.pdf", Outcome: store.OutcomeFailed, Error: "feb.pdf: no statements extracted"})
+	closeNotifier(t, n)
+
+	var types []string
+	for _, e := range r.events {
+		types = append(types, e.Type)
+	}
+	want := []string{EventStatementExtracted, EventBalanceMismatch, EventStatementImported, EventImportFailed}
+	if len(types) != len(want) {
+		t.Fatalf("Expected events %v, got %v", want, types)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Fatalf("Expected events %v, got %v", want, types)
+		}
+	}
+
+	for i, h := range r.headers {
+		timestamp, _ := strconv.ParseInt(h.Get(HeaderTimestamp), 10, 64)
+		if !Verify("s3cret", h.Get(HeaderSignature), timestamp, r.bodies[i]) {
+			t.Errorf("Event %d: invalid signature %q", i, h.Get(HeaderSignature))
+		}
+		if h.Get(HeaderEvent) != r.events[i].Type || h.Get(HeaderDelivery) != r.events[i].ID || h.Get("Authorization") != "Bearer abc" {
+			t.Errorf("Event %d: unexpected headers %v", i, h)
+		}
+	}
+
+	imported := r.events[2].Data
+	if imported.StatementID != "st-1" || imported.TransactionsInserted == nil || imported.Account.AccountNumber != "1234" {
+		t.Errorf("Unexpected statement.imported data: %+v", imported)
+	}
+	if r.events[0].Data.StatementType != "MAYBANK_CASA_AND_MAE" || !r.events[0].Data.EndingBalance.Equal(decimal.NewFromInt(150)) {
+		t.Errorf("Unexpected statement.extracted data: %+v", r.events[0].Data)
+	}
+	if failed := r.events[3].Data; failed.Summary != nil || failed.Error == "" {
+		t.Errorf("Unexpected import.failed data: %+v", failed)
+	}
+}
+
+func TestNotifier_FiltersEvents(t *testing.T) {
+	r := &receiver{}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	cfg := testConfig(t, server.URL)
+	cfg.Endpoints[0].Events = []string{EventImportFailed}
+	n, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt := testStatement()
+	n.ObserveExtraction(extractor.Extraction{Filename: "jan.pdf", Results: []common.Statement{stmt}})
+	n.ObserveImport(store.ImportEvent{File: "feb.pdf", Outcome: store.OutcomeFailed, Error: "boom"})
+	closeNotifier(t, n)
+
+	if len(r.events) != 1 || r.events[0].Type != EventImportFailed {
+		t.Errorf("Expected only import.failed, got %+v", r.events)
+	}
+}
+
+func TestNotifier_Retries(t *testing.T) {
+	r := &receiver{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	cfg := testConfig(t, server.URL)
+	n, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.Publish(EventImportFailed, Statement{File: "a.pdf"})
+	closeNotifier(t, n)
+
+	if r.calls != 3 || len(r.events) != 1 {
+		t.Errorf("Expected delivery on the third attempt, got %d calls and %d events", r.calls, len(r.events))
+	}
+	if letters := readDeadLetters(t, cfg.DeadLetterFile); len(letters) != 0 {
+		t.Errorf("Expected no dead letters, got %+v", letters)
+	}
+}
+
+func TestNotifier_DeadLetters(t *testing.T) {
+	r := &receiver{statuses: []int{500, 500, 500, http.StatusBadRequest}}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	cfg := testConfig(t, server.URL)
+	n, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.Publish(EventImportFailed, Statement{File: "a.pdf"}) // Runs out of attempts
+	n.Publish(EventImportFailed, Statement{File: "b.pdf"}) // Rejected, not retried
+	closeNotifier(t, n)
+	n.Publish(EventImportFailed, Statement{File: "c.pdf"}) // After Close
+
+	letters := readDeadLetters(t, cfg.DeadLetterFile)
+	if len(letters) != 3 {
+		t.Fatalf("Expected 3 dead letters, got %+v", letters)
+	}
+	for i, want := range []struct {
+		file     string
+		attempts int
+	}{{"a.pdf", 3}, {"b.pdf", 1}, {"c.pdf", 0}} {
+		if l := letters[i]; l.Event.Data.File != want.file || l.Attempts != want.attempts || l.URL != server.URL || l.Error == "" {
+			t.Errorf("Dead letter %d: expected %s after %d attempts, got %+v", i, want.file, want.attempts, l)
+		}
+	}
+	if r.calls != 4 {
+		t.Errorf("Expected 4 requests, got %d", r.calls)
+	}
+}
+
+func TestNotifier_CloseGivesUp(t *testing.T) {
+	r := &receiver{statuses: []int{500, 500, 500, 500, 500}}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	cfg := testConfig(t, server.URL)
+	cfg.InitialBackoff = time.Hour
+	cfg.MaxBackoff = time.Hour
+	n, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.Publish(EventImportFailed, Statement{File: "a.pdf"})
+	n.Publish(EventImportFailed, Statement{File: "b.pdf"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	n.Close(ctx)
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("Expected Close to stop waiting for retries")
+	}
+	if letters := readDeadLetters(t, cfg.DeadLetterFile); len(letters) != 2 {
+		t.Errorf("Expected both events dead-lettered, got %+v", letters)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint Endpoint
+		ok       bool
+	}{
+		{"valid", Endpoint{URL: "https://example.com/hook", Secret: "x", Events: []string{EventBalanceMismatch}}, true},
+		{"not http", Endpoint{URL: "ftp://example.com", Secret: "x"}, false},
+		{"no host", Endpoint{URL: "https:///hook", Secret: "x"}, false},
+		{"no secret", Endpoint{URL: "https://example.com/hook"}, false},
+		{"unknown event", Endpoint{URL: "https://example.com/hook", Secret: "x", Events: []string{"statement.deleted"}}, false},
+	}
+	for _, tt := range tests {
+		cfg := DefaultConfig()
+		cfg.Endpoints = []Endpoint{tt.endpoint}
+		if err := cfg.Validate(); (err == nil) != tt.ok {
+			t.Errorf("%s: Validate() = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+
+	cfg := DefaultConfig()
+	cfg.MaxAttempts = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected max_attempts 0 to be rejected")
+	}
+}
+
+func TestNilNotifier(t *testing.T) {
+	var n *Notifier
+	n.Publish(EventImportFailed, Statement{})
+	n.ObserveImport(store.ImportEvent{Outcome: store.OutcomeFailed})
+	n.ObserveExtraction(extractor.Extraction{Results: []common.Statement{testStatement()}})
+	n.Close(context.Background())
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/aqlanhadi/kwgn/cmd"
+	"github.com/aqlanhadi/kwgn/extractor"
 )
 
 var version = "dev"
@@ -15,5 +16,6 @@ func main() {
 		os.Exit(0)
 	}
 
+	extractor.Version = version
 	cmd.Execute()
 }